@@ -0,0 +1,4 @@
+// Package adapters lets golog sit underneath other logging APIs, so code
+// migrating incrementally can keep its zap or zerolog call sites while
+// gaining golog's rotation and sinks.
+package adapters
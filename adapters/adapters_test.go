@@ -0,0 +1,89 @@
+package adapters
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/zap"
+
+	"github.com/samiullahsaleem/golog"
+)
+
+func newBufferLogger(t *testing.T) (*golog.Logger, *bytes.Buffer) {
+	t.Helper()
+	logger, err := golog.NewLogger(golog.Config{Level: golog.INFO})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	var buf bytes.Buffer
+	logger.AddSink(golog.NewWriterSink(&buf, &golog.JSONFormatter{DisableTimestamp: true}))
+	return logger, &buf
+}
+
+func TestZapCore(t *testing.T) {
+	logger, buf := newBufferLogger(t)
+	zl := zap.New(NewZapCore(logger)).Named("billing").With(zap.String("region", "eu"))
+
+	zl.Debug("hidden")
+	zl.Warn("invoice overdue", zap.Int("invoice", 42))
+
+	expected := `{"invoice":42,"level":"WARN","logger":"billing","message":"invoice overdue","region":"eu"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected zap output:\n got: %s\nwant: %s", buf.String(), expected)
+	}
+}
+
+func TestZerologWriter(t *testing.T) {
+	logger, buf := newBufferLogger(t)
+	zl := zerolog.New(NewZerologWriter(logger)).With().Timestamp().Logger()
+
+	zl.Debug().Msg("hidden")
+	zl.Error().Str("user", "alice").Int("attempt", 3).Msg("login failed")
+
+	output := buf.String()
+	if strings.Contains(output, "hidden") {
+		t.Errorf("Entry below the logger level was written: %s", output)
+	}
+	expected := `{"attempt":3,"level":"ERROR","message":"login failed","user":"alice"}` + "\n"
+	if output != expected {
+		t.Errorf("Unexpected zerolog output:\n got: %s\nwant: %s", output, expected)
+	}
+}
+
+// recordingHook is a logrus hook that records messages and tags entries.
+type recordingHook struct {
+	messages []string
+}
+
+func (h *recordingHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.ErrorLevel, logrus.FatalLevel, logrus.PanicLevel}
+}
+
+func (h *recordingHook) Fire(entry *logrus.Entry) error {
+	h.messages = append(h.messages, entry.Message+" "+entry.Data["user"].(string))
+	entry.Data["reported"] = true
+	delete(entry.Data, "password")
+	return nil
+}
+
+func TestLogrusHook(t *testing.T) {
+	logger, buf := newBufferLogger(t)
+	hook := &recordingHook{}
+	logger.AddHook(NewLogrusHook(hook))
+
+	logger.Info("ignored", map[string]interface{}{"user": "bob"})
+	logger.Error("payment failed", map[string]interface{}{"user": "alice", "password": "hunter2"})
+
+	if len(hook.messages) != 1 || hook.messages[0] != "payment failed alice" {
+		t.Errorf("Unexpected hook calls: %v", hook.messages)
+	}
+	if !strings.Contains(buf.String(), `"message":"payment failed","reported":true`) {
+		t.Errorf("Field added by the hook was not written: %s", buf.String())
+	}
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Errorf("Field removed by the hook was written: %s", buf.String())
+	}
+}
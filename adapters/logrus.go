@@ -0,0 +1,97 @@
+package adapters
+
+import (
+	"io"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/samiullahsaleem/golog"
+)
+
+// logrusHook runs a logrus hook as a golog hook.
+type logrusHook struct {
+	hook   logrus.Hook
+	logger *logrus.Logger
+}
+
+// NewLogrusHook wraps an existing logrus hook, such as a Sentry, syslog or
+// Logstash integration, so it can be attached with golog's AddHook:
+//
+//	logger.AddHook(adapters.NewLogrusHook(sentryHook))
+//
+// The hook sees each entry as a *logrus.Entry; the fields it leaves in
+// Data, including additions, changes and removals, replace the golog
+// entry's fields.
+func NewLogrusHook(hook logrus.Hook) golog.Hook {
+	logger := logrus.New()
+	logger.Out = io.Discard
+	logger.Level = logrus.TraceLevel
+	return &logrusHook{hook: hook, logger: logger}
+}
+
+// Levels implements golog.Hook.
+func (h *logrusHook) Levels() []golog.LogLevel {
+	var levels []golog.LogLevel
+	seen := make(map[golog.LogLevel]bool)
+	for _, l := range h.hook.Levels() {
+		if level := fromLogrusLevel(l); !seen[level] {
+			seen[level] = true
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// Fire implements golog.Hook.
+func (h *logrusHook) Fire(entry *golog.Entry) error {
+	data := make(logrus.Fields, len(entry.Fields))
+	for k, v := range entry.Fields {
+		data[k] = v
+	}
+	le := &logrus.Entry{
+		Logger:  h.logger,
+		Data:    data,
+		Time:    entry.Time,
+		Level:   toLogrusLevel(entry.Level),
+		Message: entry.Message,
+	}
+	if err := h.hook.Fire(le); err != nil {
+		return err
+	}
+	entry.Fields = map[string]interface{}(le.Data)
+	return nil
+}
+
+// toLogrusLevel maps a golog level to the matching logrus level.
+func toLogrusLevel(level golog.LogLevel) logrus.Level {
+	switch level {
+	case golog.TRACE:
+		return logrus.TraceLevel
+	case golog.DEBUG:
+		return logrus.DebugLevel
+	case golog.WARN:
+		return logrus.WarnLevel
+	case golog.ERROR:
+		return logrus.ErrorLevel
+	case golog.FATAL:
+		return logrus.FatalLevel
+	}
+	return logrus.InfoLevel
+}
+
+// fromLogrusLevel maps a logrus level to the closest golog level.
+func fromLogrusLevel(level logrus.Level) golog.LogLevel {
+	switch level {
+	case logrus.TraceLevel:
+		return golog.TRACE
+	case logrus.DebugLevel:
+		return golog.DEBUG
+	case logrus.WarnLevel:
+		return golog.WARN
+	case logrus.ErrorLevel:
+		return golog.ERROR
+	case logrus.FatalLevel, logrus.PanicLevel:
+		return golog.FATAL
+	}
+	return golog.INFO
+}
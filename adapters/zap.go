@@ -0,0 +1,94 @@
+package adapters
+
+import (
+	"go.uber.org/zap/zapcore"
+
+	"github.com/samiullahsaleem/golog"
+)
+
+// zapCore is a zapcore.Core that writes through a golog logger.
+type zapCore struct {
+	logger *golog.Logger
+	fields map[string]interface{}
+}
+
+// NewZapCore returns a zapcore.Core that writes entries to logger. The
+// logger's level decides which entries are enabled, and the name of a zap
+// logger is kept in golog's "logger" field:
+//
+//	zl := zap.New(adapters.NewZapCore(golog.RootLogger()))
+func NewZapCore(logger *golog.Logger) zapcore.Core {
+	return &zapCore{logger: logger}
+}
+
+// Enabled implements zapcore.LevelEnabler.
+func (c *zapCore) Enabled(level zapcore.Level) bool {
+	return fromZapLevel(level) >= c.logger.Level()
+}
+
+// With implements zapcore.Core.
+func (c *zapCore) With(fields []zapcore.Field) zapcore.Core {
+	merged := make(map[string]interface{}, len(c.fields)+len(fields))
+	for k, v := range c.fields {
+		merged[k] = v
+	}
+	addZapFields(merged, fields)
+	return &zapCore{logger: c.logger, fields: merged}
+}
+
+// Check implements zapcore.Core.
+func (c *zapCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write implements zapcore.Core. Zap itself panics or exits after
+// DPanic, Panic and Fatal entries, so the entry is only logged here.
+func (c *zapCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	merged := make(map[string]interface{}, len(c.fields)+len(fields)+1)
+	for k, v := range c.fields {
+		merged[k] = v
+	}
+	addZapFields(merged, fields)
+	if entry.LoggerName != "" {
+		merged["logger"] = entry.LoggerName
+	}
+	if entry.Stack != "" {
+		merged["stack"] = entry.Stack
+	}
+	c.logger.Log(fromZapLevel(entry.Level), entry.Message, merged)
+	return nil
+}
+
+// Sync implements zapcore.Core by flushing the golog logger.
+func (c *zapCore) Sync() error {
+	return c.logger.Flush()
+}
+
+// addZapFields encodes zap fields into plain golog field values.
+func addZapFields(dst map[string]interface{}, fields []zapcore.Field) {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	for k, v := range enc.Fields {
+		dst[k] = v
+	}
+}
+
+// fromZapLevel maps a zap level to the closest golog level.
+func fromZapLevel(level zapcore.Level) golog.LogLevel {
+	switch {
+	case level < zapcore.InfoLevel:
+		return golog.DEBUG
+	case level == zapcore.InfoLevel:
+		return golog.INFO
+	case level == zapcore.WarnLevel:
+		return golog.WARN
+	case level <= zapcore.DPanicLevel:
+		return golog.ERROR
+	}
+	return golog.FATAL
+}
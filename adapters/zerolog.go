@@ -0,0 +1,78 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rs/zerolog"
+
+	"github.com/samiullahsaleem/golog"
+)
+
+// ZerologWriter is a zerolog.LevelWriter that decodes zerolog's JSON
+// events and writes them through a golog logger:
+//
+//	zl := zerolog.New(adapters.NewZerologWriter(golog.RootLogger()))
+//
+// The event's message and level become the entry's message and level, its
+// other keys become fields, and its timestamp is replaced by golog's own.
+type ZerologWriter struct {
+	logger *golog.Logger
+}
+
+// NewZerologWriter returns a writer that logs zerolog events to logger.
+func NewZerologWriter(logger *golog.Logger) *ZerologWriter {
+	return &ZerologWriter{logger: logger}
+}
+
+// Write implements io.Writer, taking the level from the event itself.
+func (w *ZerologWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter.
+func (w *ZerologWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level == zerolog.Disabled {
+		return len(p), nil
+	}
+
+	fields := make(map[string]interface{})
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return 0, fmt.Errorf("failed to decode zerolog event: %v", err)
+	}
+
+	msg, _ := fields[zerolog.MessageFieldName].(string)
+	delete(fields, zerolog.MessageFieldName)
+	if name, ok := fields[zerolog.LevelFieldName].(string); ok {
+		if level == zerolog.NoLevel {
+			if parsed, err := zerolog.ParseLevel(name); err == nil {
+				level = parsed
+			}
+		}
+		delete(fields, zerolog.LevelFieldName)
+	}
+	delete(fields, zerolog.TimestampFieldName)
+
+	w.logger.Log(fromZerologLevel(level), msg, fields)
+	return len(p), nil
+}
+
+// fromZerologLevel maps a zerolog level to the closest golog level.
+func fromZerologLevel(level zerolog.Level) golog.LogLevel {
+	switch level {
+	case zerolog.TraceLevel:
+		return golog.TRACE
+	case zerolog.DebugLevel:
+		return golog.DEBUG
+	case zerolog.WarnLevel:
+		return golog.WARN
+	case zerolog.ErrorLevel:
+		return golog.ERROR
+	case zerolog.FatalLevel, zerolog.PanicLevel:
+		return golog.FATAL
+	}
+	return golog.INFO
+}
@@ -0,0 +1,368 @@
+package golog
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// maxAggregateRecord is the longest record an aggregator accepts.
+const maxAggregateRecord = 1024 * 1024
+
+// AggregatorConfig holds aggregation server options.
+type AggregatorConfig struct {
+	Network string        // "unix" or "tcp"; defaults to "unix"
+	Address string        // Socket path or host:port to listen on
+	Window  time.Duration // Hold entries this long and write them in timestamp order; 0 writes them as they arrive
+}
+
+// Aggregator is a server that funnels the entries of several processes into
+// one logger, and so into its rotated file and sinks. Processes connect with
+// an AggregatorSink. Entries keep their original timestamps and gain the
+// sending process's process, pid and host fields. Each process's entries are
+// written in the order it logged them; with a Window, entries of different
+// processes are also ordered by timestamp.
+type Aggregator struct {
+	config   AggregatorConfig
+	dest     *Logger
+	listener net.Listener
+
+	mutex   sync.Mutex
+	conns   map[net.Conn]struct{}
+	pending entryHeap
+	arrived uint64
+	closed  bool
+
+	wg   sync.WaitGroup
+	stop chan struct{}
+	done chan struct{}
+}
+
+// aggregateHello identifies the process on the other end of a connection.
+// It is the first record a client sends.
+type aggregateHello struct {
+	Process string `json:"process"`
+	PID     int    `json:"pid"`
+	Host    string `json:"host"`
+}
+
+// NewAggregator listens on config.Address and writes the entries it receives
+// to dest. A stale unix socket left by a crashed server is replaced.
+func NewAggregator(dest *Logger, config AggregatorConfig) (*Aggregator, error) {
+	if config.Network == "" {
+		config.Network = "unix"
+	}
+	if config.Address == "" {
+		return nil, fmt.Errorf("aggregator requires an address")
+	}
+	if config.Network == "unix" {
+		removeStaleSocket(config.Address)
+	}
+	listener, err := net.Listen(config.Network, config.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for log entries: %v", err)
+	}
+
+	a := &Aggregator{
+		config:   config,
+		dest:     dest,
+		listener: listener,
+		conns:    make(map[net.Conn]struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	a.wg.Add(1)
+	go a.accept()
+	if config.Window > 0 {
+		go a.release()
+	} else {
+		close(a.done)
+	}
+	return a, nil
+}
+
+// removeStaleSocket removes a unix socket nobody is listening on.
+func removeStaleSocket(path string) {
+	info, err := os.Stat(path)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return
+	}
+	os.Remove(path)
+}
+
+// Addr returns the address the aggregator listens on.
+func (a *Aggregator) Addr() net.Addr {
+	return a.listener.Addr()
+}
+
+// accept serves connections until the listener is closed.
+func (a *Aggregator) accept() {
+	defer a.wg.Done()
+	for {
+		conn, err := a.listener.Accept()
+		if err != nil {
+			return
+		}
+		a.mutex.Lock()
+		if a.closed {
+			a.mutex.Unlock()
+			conn.Close()
+			return
+		}
+		a.conns[conn] = struct{}{}
+		a.wg.Add(1)
+		a.mutex.Unlock()
+		go a.serve(conn)
+	}
+}
+
+// serve reads one process's hello and entries.
+func (a *Aggregator) serve(conn net.Conn) {
+	defer a.wg.Done()
+	defer func() {
+		a.mutex.Lock()
+		delete(a.conns, conn)
+		a.mutex.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), maxAggregateRecord)
+	if !scanner.Scan() {
+		return
+	}
+	var hello aggregateHello
+	if err := json.Unmarshal(scanner.Bytes(), &hello); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to identify log client %s: %v\n", conn.RemoteAddr(), err)
+		return
+	}
+
+	for scanner.Scan() {
+		var record spoolRecord
+		dec := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		dec.UseNumber()
+		if err := dec.Decode(&record); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to decode entry from %s: %v\n", hello.Process, err)
+			continue
+		}
+		fields := record.Fields
+		if fields == nil {
+			fields = make(map[string]interface{}, 3)
+		}
+		for k, v := range map[string]interface{}{"process": hello.Process, "pid": hello.PID, "host": hello.Host} {
+			if _, ok := fields[k]; !ok {
+				fields[k] = v
+			}
+		}
+		a.receive(&Entry{Time: record.Time, Level: record.Level, Message: record.Message, Fields: fields})
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read entries from %s: %v\n", hello.Process, err)
+	}
+}
+
+// receive writes an entry, or holds it for the ordering window.
+func (a *Aggregator) receive(entry *Entry) {
+	if a.config.Window <= 0 {
+		a.emit(entry)
+		return
+	}
+	a.mutex.Lock()
+	a.arrived++
+	heap.Push(&a.pending, heldEntry{entry: entry, arrival: a.arrived})
+	a.mutex.Unlock()
+}
+
+// release writes held entries once they are older than the window.
+func (a *Aggregator) release() {
+	defer close(a.done)
+	ticker := time.NewTicker(a.config.Window / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.stop:
+			a.releaseBefore(time.Time{})
+			return
+		case now := <-ticker.C:
+			a.releaseBefore(now.Add(-a.config.Window))
+		}
+	}
+}
+
+// releaseBefore writes the held entries logged before cutoff, or all of them
+// if cutoff is zero, in timestamp order.
+func (a *Aggregator) releaseBefore(cutoff time.Time) {
+	a.mutex.Lock()
+	var ready []*Entry
+	for len(a.pending) > 0 && (cutoff.IsZero() || a.pending[0].entry.Time.Before(cutoff)) {
+		ready = append(ready, heap.Pop(&a.pending).(heldEntry).entry)
+	}
+	a.mutex.Unlock()
+	for _, entry := range ready {
+		a.emit(entry)
+	}
+}
+
+// emit writes an entry to the destination logger if its level is enabled.
+func (a *Aggregator) emit(entry *Entry) {
+	if entry.Level < a.dest.Level() {
+		return
+	}
+	a.dest.deliver(entry)
+}
+
+// Close stops accepting entries, disconnects every client and writes any
+// held entries. The destination logger is left open.
+func (a *Aggregator) Close() error {
+	a.mutex.Lock()
+	if a.closed {
+		a.mutex.Unlock()
+		return nil
+	}
+	a.closed = true
+	err := a.listener.Close()
+	for conn := range a.conns {
+		conn.Close()
+	}
+	a.mutex.Unlock()
+
+	a.wg.Wait()
+	close(a.stop)
+	<-a.done
+	if err != nil {
+		return fmt.Errorf("failed to close aggregator: %v", err)
+	}
+	return nil
+}
+
+// heldEntry is an entry waiting in the ordering window.
+type heldEntry struct {
+	entry   *Entry
+	arrival uint64
+}
+
+// entryHeap orders held entries by timestamp, then arrival.
+type entryHeap []heldEntry
+
+func (h entryHeap) Len() int { return len(h) }
+func (h entryHeap) Less(i, j int) bool {
+	if !h[i].entry.Time.Equal(h[j].entry.Time) {
+		return h[i].entry.Time.Before(h[j].entry.Time)
+	}
+	return h[i].arrival < h[j].arrival
+}
+func (h entryHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *entryHeap) Push(x interface{}) { *h = append(*h, x.(heldEntry)) }
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// AggregatorSinkConfig holds aggregation client options.
+type AggregatorSinkConfig struct {
+	Network     string        // "unix" or "tcp"; defaults to "unix"
+	Address     string        // Aggregator socket path or host:port
+	Process     string        // Name identifying this process; defaults to the executable's base name
+	DialTimeout time.Duration // Connection timeout; defaults to 5s
+}
+
+// AggregatorSink sends entries to an Aggregator. It connects on first write
+// and reconnects after a failure; entries written while the aggregator is
+// unreachable fail, so wrap the sink in a SpoolSink to keep them.
+type AggregatorSink struct {
+	mutex  sync.Mutex
+	config AggregatorSinkConfig
+	hello  []byte
+	conn   net.Conn
+	writer *bufio.Writer
+}
+
+// NewAggregatorSink creates a sink sending entries to the aggregator at
+// config.Address.
+func NewAggregatorSink(config AggregatorSinkConfig) (*AggregatorSink, error) {
+	if config.Network == "" {
+		config.Network = "unix"
+	}
+	if config.Address == "" {
+		return nil, fmt.Errorf("aggregator sink requires an address")
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = 5 * time.Second
+	}
+	if config.Process == "" {
+		config.Process = filepath.Base(os.Args[0])
+	}
+	host, _ := os.Hostname()
+	hello, err := json.Marshal(aggregateHello{Process: config.Process, PID: os.Getpid(), Host: host})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode hello: %v", err)
+	}
+	return &AggregatorSink{config: config, hello: append(hello, '\n')}, nil
+}
+
+// Write implements Sink. A write that fails on an established connection is
+// retried once on a new one.
+func (s *AggregatorSink) Write(entry *Entry) error {
+	line, err := json.Marshal(spoolRecord{Time: entry.Time, Level: entry.Level, Message: entry.Message, Fields: entry.Fields})
+	if err != nil {
+		return fmt.Errorf("failed to encode entry: %v", err)
+	}
+	line = append(line, '\n')
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	reused := s.conn != nil
+	if err = s.send(line); err != nil && reused {
+		err = s.send(line)
+	}
+	return err
+}
+
+// send writes one record, connecting first if needed; s.mutex must be held.
+func (s *AggregatorSink) send(line []byte) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.config.Network, s.config.Address, s.config.DialTimeout)
+		if err != nil {
+			return fmt.Errorf("failed to connect to aggregator: %v", err)
+		}
+		s.conn = conn
+		s.writer = bufio.NewWriter(conn)
+		s.writer.Write(s.hello)
+	}
+	s.writer.Write(line)
+	if err := s.writer.Flush(); err != nil {
+		s.disconnect()
+		return fmt.Errorf("failed to send entry to aggregator: %v", err)
+	}
+	return nil
+}
+
+// disconnect drops the connection; s.mutex must be held.
+func (s *AggregatorSink) disconnect() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+		s.writer = nil
+	}
+}
+
+// Close implements Sink.
+func (s *AggregatorSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.disconnect()
+	return nil
+}
@@ -0,0 +1,79 @@
+package golog
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForLines polls buf until it holds n lines.
+func waitForLines(t *testing.T, logger *Logger, buf *bytes.Buffer, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		logger.mutex.Lock()
+		content := buf.String()
+		logger.mutex.Unlock()
+		if lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n"); content != "" && len(lines) >= n {
+			return lines
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %d lines: %s", n, content)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	dest, buf := newBufferLogger(t, INFO)
+	socket := filepath.Join(t.TempDir(), "agg.sock")
+	server, err := NewAggregator(dest, AggregatorConfig{Address: socket, Window: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to start aggregator: %v", err)
+	}
+	defer server.Close()
+
+	api, _ := NewAggregatorSink(AggregatorSinkConfig{Address: socket, Process: "api"})
+	worker, _ := NewAggregatorSink(AggregatorSinkConfig{Address: socket, Process: "worker"})
+	now := time.Now()
+	api.Write(&Entry{Time: now.Add(20 * time.Millisecond), Level: INFO, Message: "third"})
+	api.Write(&Entry{Time: now.Add(-time.Millisecond), Level: DEBUG, Message: "filtered"})
+	worker.Write(&Entry{Time: now, Level: WARN, Message: "first", Fields: map[string]interface{}{"job": 7}})
+	worker.Write(&Entry{Time: now.Add(10 * time.Millisecond), Level: INFO, Message: "second"})
+
+	// A dropped connection is replaced on the next write.
+	api.mutex.Lock()
+	api.conn.Close()
+	api.mutex.Unlock()
+	if err := api.Write(&Entry{Time: now.Add(30 * time.Millisecond), Level: INFO, Message: "fourth"}); err != nil {
+		t.Fatalf("Expected write to reconnect: %v", err)
+	}
+	api.Close()
+	worker.Close()
+
+	lines := waitForLines(t, dest, buf, 4)
+	var processes, messages []string
+	for _, line := range lines {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid entry %q: %v", line, err)
+		}
+		processes = append(processes, entry["process"].(string))
+		messages = append(messages, entry["message"].(string))
+		if entry["pid"] == nil || entry["host"] == nil {
+			t.Errorf("Entry lacks process identification: %s", line)
+		}
+	}
+	if got := strings.Join(messages, ","); got != "first,second,third,fourth" {
+		t.Errorf("Unexpected order: %s", got)
+	}
+	if got := strings.Join(processes, ","); got != "worker,worker,api,api" {
+		t.Errorf("Unexpected processes: %s", got)
+	}
+	if !strings.Contains(lines[0], `"job":7`) {
+		t.Errorf("Expected fields to survive the round trip: %s", lines[0])
+	}
+}
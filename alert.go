@@ -0,0 +1,224 @@
+package golog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AlertConfig holds the options of an alerting hook.
+type AlertConfig struct {
+	Level       LogLevel                  // Minimum level that raises an alert; TRACE, the zero value, means FATAL
+	Match       func(entry *Entry) bool   // Further limits the entries that raise an alert; nil accepts all
+	DedupFields []string                  // Fields that, with the message, identify an incident
+	DedupKey    func(entry *Entry) string // Derives the dedup key instead of DedupFields
+	Cooldown    time.Duration             // Skip alerts whose dedup key was sent this recently; 0 sends every alert
+	Source      string                    // Alert source; defaults to the host name
+	URL         string                    // API endpoint; overrides the provider's default
+	Client      *http.Client              // Client sending alerts; defaults to one with a 10s timeout
+}
+
+// alert is a provider-neutral incident.
+type alert struct {
+	entry    *Entry
+	dedupKey string
+	source   string
+}
+
+// AlertHook raises an incident with an alerting service when a matching
+// entry is logged, for services without a full alerting pipeline. Entries
+// with the same message and DedupFields share a dedup key, so the service
+// groups repeats into one incident; for templated messages the template is
+// used, so rendered values do not split incidents. The alert is sent before
+// Fire returns, so a FATAL entry's incident exists before the process
+// exits.
+type AlertHook struct {
+	config AlertConfig
+	levels []LogLevel
+	send   func(a alert) error
+
+	mutex sync.Mutex
+	sent  map[string]time.Time // dedup key to last send, for Cooldown
+}
+
+// NewPagerDutyHook returns a hook triggering PagerDuty incidents through the
+// Events API v2 with an integration's routing key.
+func NewPagerDutyHook(routingKey string, config AlertConfig) (*AlertHook, error) {
+	if routingKey == "" {
+		return nil, fmt.Errorf("PagerDuty hook requires a routing key")
+	}
+	if config.URL == "" {
+		config.URL = "https://events.pagerduty.com/v2/enqueue"
+	}
+	h := newAlertHook(config)
+	h.send = func(a alert) error {
+		summary := a.entry.Message[:runeBoundary(a.entry.Message, 1024)]
+		event := map[string]interface{}{
+			"routing_key":  routingKey,
+			"event_action": "trigger",
+			"dedup_key":    a.dedupKey,
+			"payload": map[string]interface{}{
+				"summary":        summary,
+				"source":         a.source,
+				"severity":       pagerDutySeverity(a.entry.Level),
+				"timestamp":      a.entry.Time.Format(time.RFC3339Nano),
+				"custom_details": normalizeFields(a.entry.Fields),
+			},
+		}
+		return h.post(event, nil, "PagerDuty")
+	}
+	return h, nil
+}
+
+// NewOpsgenieHook returns a hook creating Opsgenie alerts with an API
+// integration key. Set config.URL to "https://api.eu.opsgenie.com/v2/alerts"
+// for EU accounts.
+func NewOpsgenieHook(apiKey string, config AlertConfig) (*AlertHook, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("Opsgenie hook requires an API key")
+	}
+	if config.URL == "" {
+		config.URL = "https://api.opsgenie.com/v2/alerts"
+	}
+	h := newAlertHook(config)
+	h.send = func(a alert) error {
+		message := a.entry.Message[:runeBoundary(a.entry.Message, 130)]
+		details := make(map[string]string, len(a.entry.Fields))
+		for k, v := range a.entry.Fields {
+			details[k] = fmt.Sprint(normalizeValue(v))
+		}
+		body := map[string]interface{}{
+			"message":     message,
+			"alias":       a.dedupKey,
+			"description": a.entry.Message,
+			"details":     details,
+			"priority":    opsgeniePriority(a.entry.Level),
+			"source":      a.source,
+		}
+		return h.post(body, http.Header{"Authorization": {"GenieKey " + apiKey}}, "Opsgenie")
+	}
+	return h, nil
+}
+
+// newAlertHook applies the defaults shared by every provider.
+func newAlertHook(config AlertConfig) *AlertHook {
+	if config.Level == TRACE {
+		config.Level = FATAL
+	}
+	if config.Source == "" {
+		config.Source, _ = os.Hostname()
+	}
+	var levels []LogLevel
+	for _, level := range AllLevels {
+		if level >= config.Level {
+			levels = append(levels, level)
+		}
+	}
+	return &AlertHook{config: config, levels: levels, sent: make(map[string]time.Time)}
+}
+
+// Levels implements Hook.
+func (h *AlertHook) Levels() []LogLevel {
+	return h.levels
+}
+
+// Fire implements Hook.
+func (h *AlertHook) Fire(entry *Entry) error {
+	if h.config.Match != nil && !h.config.Match(entry) {
+		return nil
+	}
+	key := h.dedupKey(entry)
+	if h.config.Cooldown > 0 && !h.claim(key) {
+		return nil
+	}
+	return h.send(alert{entry: entry, dedupKey: key, source: h.config.Source})
+}
+
+// claim reports whether an alert with key may be sent now, recording it.
+func (h *AlertHook) claim(key string) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	now := time.Now()
+	for k, at := range h.sent {
+		if now.Sub(at) >= h.config.Cooldown {
+			delete(h.sent, k)
+		}
+	}
+	if _, ok := h.sent[key]; ok {
+		return false
+	}
+	h.sent[key] = now
+	return true
+}
+
+// dedupKey identifies the incident an entry belongs to.
+func (h *AlertHook) dedupKey(entry *Entry) string {
+	if h.config.DedupKey != nil {
+		return h.config.DedupKey(entry)
+	}
+	msg := entry.Message
+	if template, ok := entry.Fields[TemplateKey].(string); ok {
+		msg = template
+	}
+	sum := sha256.New()
+	sum.Write([]byte(msg))
+	names := append([]string(nil), h.config.DedupFields...)
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(sum, "\x00%s=%v", name, normalizeValue(entry.Fields[name]))
+	}
+	return "golog-" + hex.EncodeToString(sum.Sum(nil)[:16])
+}
+
+// post sends an alert body as JSON.
+func (h *AlertHook) post(body interface{}, header http.Header, service string) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s alert: %v", service, err)
+	}
+	req, err := http.NewRequest(http.MethodPost, h.config.URL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %v", service, err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	_, err = postRequest(h.config.Client, req, service)
+	return err
+}
+
+// pagerDutySeverity maps a level to a PagerDuty event severity.
+func pagerDutySeverity(level LogLevel) string {
+	switch {
+	case level >= FATAL:
+		return "critical"
+	case level == ERROR:
+		return "error"
+	case level == WARN:
+		return "warning"
+	}
+	return "info"
+}
+
+// opsgeniePriority maps a level to an Opsgenie alert priority.
+func opsgeniePriority(level LogLevel) string {
+	switch level {
+	case FATAL:
+		return "P1"
+	case ERROR:
+		return "P2"
+	case WARN:
+		return "P3"
+	case INFO:
+		return "P4"
+	}
+	return "P5"
+}
@@ -0,0 +1,142 @@
+package golog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shedSummaryInterval is how often a shedding logger reports dropped entries.
+const shedSummaryInterval = 10 * time.Second
+
+// shedMarks are the queue fill percentages at which load shedding starts
+// dropping each level. WARN and above are never dropped.
+var shedMarks = [...]int{TRACE: 50, DEBUG: 70, INFO: 90}
+
+// asyncQueue hands entries to a background goroutine that writes them to
+// the logger's outputs, so logging calls do not wait on slow outputs.
+type asyncQueue struct {
+	mutex   sync.RWMutex // held for writing while closing
+	closed  bool
+	items   chan asyncItem
+	done    chan struct{}
+	shed    bool
+	dropped [INFO + 1]atomic.Int64 // since the last summarize
+	shedAll atomic.Int64           // since the queue started, for Config.Summary
+}
+
+// asyncItem is a queued entry, or a flush marker when flushed is set.
+type asyncItem struct {
+	entry   *Entry
+	flushed chan struct{}
+}
+
+// newAsyncQueue starts the background writer for l.
+func newAsyncQueue(l *Logger, size int, shed bool) *asyncQueue {
+	q := &asyncQueue{
+		items: make(chan asyncItem, size),
+		done:  make(chan struct{}),
+		shed:  shed,
+	}
+	go q.run(l)
+	return q
+}
+
+// push queues an entry. With load shedding, low-level entries are dropped
+// once the queue passes their high-water mark; otherwise push blocks while
+// the queue is full. After close, entries are written synchronously.
+func (q *asyncQueue) push(l *Logger, entry *Entry) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	if q.closed {
+		l.write(entry)
+		return
+	}
+	if q.shed && entry.Level <= INFO && len(q.items)*100 >= shedMarks[entry.Level]*cap(q.items) {
+		q.dropped[entry.Level].Add(1)
+		q.shedAll.Add(1)
+		return
+	}
+	q.items <- asyncItem{entry: entry}
+}
+
+// flush waits until every entry queued before the call has been written.
+func (q *asyncQueue) flush() {
+	q.mutex.RLock()
+	if q.closed {
+		q.mutex.RUnlock()
+		return
+	}
+	flushed := make(chan struct{})
+	q.items <- asyncItem{flushed: flushed}
+	q.mutex.RUnlock()
+	<-flushed
+}
+
+// close writes the remaining entries and stops the background writer.
+func (q *asyncQueue) close() {
+	q.mutex.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.items)
+	}
+	q.mutex.Unlock()
+	<-q.done
+}
+
+// run writes queued entries until the queue is closed.
+func (q *asyncQueue) run(l *Logger) {
+	defer close(q.done)
+
+	var tick <-chan time.Time
+	if q.shed {
+		ticker := time.NewTicker(shedSummaryInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case item, ok := <-q.items:
+			if !ok {
+				q.summarize(l)
+				return
+			}
+			if item.entry != nil {
+				l.write(item.entry)
+			}
+			if item.flushed != nil {
+				q.summarize(l)
+				close(item.flushed)
+			}
+		case <-tick:
+			q.summarize(l)
+		}
+	}
+}
+
+// summarize writes a WARN entry counting the entries dropped since the
+// last summary, if any.
+func (q *asyncQueue) summarize(l *Logger) {
+	fields := make(map[string]interface{})
+	var total int64
+	for level := range q.dropped {
+		if n := q.dropped[level].Swap(0); n > 0 {
+			fields["dropped_"+strings.ToLower(LogLevel(level).String())] = n
+			total += n
+		}
+	}
+	if total == 0 {
+		return
+	}
+	fields["dropped"] = total
+	l.write(&Entry{
+		Time:    time.Now(),
+		Level:   WARN,
+		Message: fmt.Sprintf("dropped %d entries under load", total),
+		Fields:  fields,
+	})
+}
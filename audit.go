@@ -0,0 +1,111 @@
+package golog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditRequiredFields are the fields every audit entry must carry.
+var AuditRequiredFields = []string{"actor", "action", "resource", "outcome"}
+
+// ErrMissingAuditFields is returned when an audit entry lacks required fields.
+var ErrMissingAuditFields = errors.New("audit entry missing required fields")
+
+// AuditConfig holds audit logger configuration options.
+type AuditConfig struct {
+	FilePath       string    // Append-only audit file; never rotated by size
+	RequiredFields []string  // Extra required fields beyond AuditRequiredFields
+	Formatter      Formatter // Defaults to JSONFormatter
+}
+
+// AuditLogger records security-relevant events. Unlike Logger it rejects
+// entries that lack the required fields, reports write failures to the
+// caller, and syncs the file to disk after every entry.
+type AuditLogger struct {
+	mutex     sync.Mutex
+	file      *os.File
+	formatter Formatter
+	required  []string
+}
+
+// NewAuditLogger opens the audit file for appending.
+func NewAuditLogger(config AuditConfig) (*AuditLogger, error) {
+	if config.FilePath == "" {
+		return nil, fmt.Errorf("audit logger requires a file path")
+	}
+
+	file, err := os.OpenFile(config.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %v", err)
+	}
+
+	formatter := config.Formatter
+	if formatter == nil {
+		formatter = &JSONFormatter{KeyOrder: CoreKeysFirst}
+	}
+
+	return &AuditLogger{
+		file:      file,
+		formatter: formatter,
+		required:  append(append([]string(nil), AuditRequiredFields...), config.RequiredFields...),
+	}, nil
+}
+
+// Log writes an audit entry after checking that every required field is
+// present and non-empty. The entry is on disk when Log returns nil.
+func (a *AuditLogger) Log(msg string, fields ...map[string]interface{}) error {
+	merged := mergeFields(fields)
+
+	var missing []string
+	for _, key := range a.required {
+		if v, ok := merged[key]; !ok || v == nil || v == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingAuditFields, strings.Join(missing, ", "))
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.file == nil {
+		return fmt.Errorf("audit logger is closed")
+	}
+
+	entry := &Entry{Time: time.Now(), Level: INFO, Message: msg, Fields: merged}
+	if _, err := a.file.WriteString(formatEntry(a.formatter, entry)); err != nil {
+		return fmt.Errorf("failed to write audit entry: %v", err)
+	}
+	if err := a.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit file: %v", err)
+	}
+	return nil
+}
+
+// Record is a convenience wrapper around Log for the standard fields.
+func (a *AuditLogger) Record(actor, action, resource, outcome string, fields ...map[string]interface{}) error {
+	merged := mergeFields(fields)
+	merged["actor"] = actor
+	merged["action"] = action
+	merged["resource"] = resource
+	merged["outcome"] = outcome
+	return a.Log(action, merged)
+}
+
+// Close closes the audit file.
+func (a *AuditLogger) Close() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
@@ -0,0 +1,41 @@
+package golog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditLoggerRequiredFields(t *testing.T) {
+	tempDir := t.TempDir()
+	auditFile := filepath.Join(tempDir, "audit.log")
+
+	audit, err := NewAuditLogger(AuditConfig{FilePath: auditFile, RequiredFields: []string{"request_id"}})
+	if err != nil {
+		t.Fatalf("Failed to create audit logger: %v", err)
+	}
+	defer audit.Close()
+
+	err = audit.Log("login", map[string]interface{}{"actor": "alice", "action": "login"})
+	if !errors.Is(err, ErrMissingAuditFields) {
+		t.Fatalf("Expected ErrMissingAuditFields, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "resource, outcome, request_id") {
+		t.Errorf("Error does not list missing fields: %v", err)
+	}
+
+	if err := audit.Record("alice", "delete", "invoice/42", "success", map[string]interface{}{"request_id": "r1"}); err != nil {
+		t.Fatalf("Failed to record audit entry: %v", err)
+	}
+
+	content, err := os.ReadFile(auditFile)
+	if err != nil {
+		t.Fatalf("Failed to read audit file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], `"resource":"invoice/42"`) {
+		t.Errorf("Unexpected audit file content: %s", content)
+	}
+}
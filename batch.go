@@ -0,0 +1,105 @@
+package golog
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BatchConfig holds batching options for sinks that send entries in groups.
+type BatchConfig struct {
+	MaxEntries    int           // Send a batch once it holds this many entries; defaults to 100
+	FlushInterval time.Duration // Send a partial batch this long after its first entry; defaults to 1s
+}
+
+// batcher collects entries and hands them to send in batches, in the order
+// they were written. A batch is sent by the Write that fills it, whose error
+// it returns, or by a timer, which reports errors on stderr. Failed batches
+// are dropped; wrap the sink in a SpoolSink to keep them.
+type batcher struct {
+	config BatchConfig
+	send   func(entries []*Entry) error
+
+	sendMu  sync.Mutex // serializes sends, keeping batches in order
+	mutex   sync.Mutex
+	entries []*Entry
+	timer   *time.Timer
+}
+
+// newBatcher creates a batcher sending through send.
+func newBatcher(config BatchConfig, send func(entries []*Entry) error) *batcher {
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	return &batcher{config: config, send: send}
+}
+
+// add queues an entry, sending the batch once it is full.
+func (b *batcher) add(entry *Entry) error {
+	b.mutex.Lock()
+	b.entries = append(b.entries, entry)
+	full := len(b.entries) >= b.config.MaxEntries
+	if !full && b.timer == nil {
+		b.timer = time.AfterFunc(b.config.FlushInterval, func() {
+			if err := b.flush(); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to send log batch: %v\n", err)
+			}
+		})
+	}
+	b.mutex.Unlock()
+	if full {
+		return b.flush()
+	}
+	return nil
+}
+
+// flush sends the queued entries.
+func (b *batcher) flush() error {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+	b.mutex.Lock()
+	entries := b.entries
+	b.entries = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mutex.Unlock()
+	if len(entries) == 0 {
+		return nil
+	}
+	return b.send(entries)
+}
+
+// defaultHTTPClient sends the requests of HTTP sinks and hooks without a
+// configured client.
+var defaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// postRequest sends req with client, returning an error naming service if it
+// fails or is not answered with a 2xx status, and otherwise the start of the
+// response body.
+func postRequest(client *http.Client, req *http.Request, service string) ([]byte, error) {
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to %s: %v", service, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if len(body) > 512 {
+			body = body[:512]
+		}
+		return nil, fmt.Errorf("%s rejected request: %s: %s", service, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
@@ -0,0 +1,301 @@
+package golog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBroker accepts connections and records what handle reads from them.
+type fakeBroker struct {
+	listener net.Listener
+	mutex    sync.Mutex
+	received []string
+}
+
+func newFakeBroker(t *testing.T, handle func(b *fakeBroker, conn net.Conn)) *fakeBroker {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	b := &fakeBroker{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(b, conn)
+			}()
+		}
+	}()
+	return b
+}
+
+func (b *fakeBroker) record(s string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.received = append(b.received, s)
+}
+
+func (b *fakeBroker) records() []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]string(nil), b.received...)
+}
+
+// serveNATS speaks enough of the NATS protocol to accept publishes,
+// rejecting those to subjects ending in "denied".
+func serveNATS(b *fakeBroker, conn net.Conn) {
+	io.WriteString(conn, "INFO {\"server_id\":\"fake\"}\r\n")
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "CONNECT "):
+			if !strings.Contains(line, `"auth_token":"secret"`) {
+				io.WriteString(conn, "-ERR 'Authorization Violation'\r\n")
+				return
+			}
+		case line == "PING":
+			io.WriteString(conn, "PONG\r\n")
+		case strings.HasPrefix(line, "PUB "):
+			parts := strings.Fields(line)
+			n, _ := strconv.Atoi(parts[len(parts)-1])
+			payload := make([]byte, n+2)
+			io.ReadFull(reader, payload)
+			if strings.HasSuffix(parts[1], "denied") {
+				b.record("rejected " + parts[1])
+				fmt.Fprintf(conn, "-ERR 'Permissions Violation for Publish to %q'\r\n", parts[1])
+				continue
+			}
+			b.record(parts[1] + " " + string(payload[:n]))
+		}
+	}
+}
+
+func TestNATSSink(t *testing.T) {
+	broker := newFakeBroker(t, serveNATS)
+	url := "nats://" + broker.listener.Addr().String()
+
+	denied, _ := NewNATSSink(NATSConfig{URL: url, Subject: "logs"})
+	if err := denied.Write(&Entry{Time: time.Now(), Level: INFO, Message: "denied"}); err != nil {
+		t.Fatalf("Expected the entry to be queued: %v", err)
+	}
+	if err := denied.Flush(); err == nil || !strings.Contains(err.Error(), "Authorization Violation") {
+		t.Errorf("Expected an authorization error, got %v", err)
+	}
+
+	sink, err := NewNATSSink(NATSConfig{URL: url, Token: "secret", Subject: "logs.{service}.{level}", Batch: BatchConfig{MaxEntries: 2}})
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	sink.Write(&Entry{Time: time.Now(), Level: INFO, Message: "one", Fields: map[string]interface{}{"service": "api.v2"}})
+	if got := broker.records(); len(got) != 0 {
+		t.Fatalf("Expected the first entry to wait for a full batch, got %v", got)
+	}
+	if err := sink.Write(&Entry{Time: time.Now(), Level: ERROR, Message: "two"}); err != nil {
+		t.Fatalf("Failed to publish batch: %v", err)
+	}
+	sink.Write(&Entry{Time: time.Now(), Level: WARN, Message: "three", Fields: map[string]interface{}{"service": "worker"}})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Failed to close sink: %v", err)
+	}
+
+	got := broker.records()
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 messages, got %v", got)
+	}
+	for i, want := range []string{`logs.api_v2.info {`, `logs.unknown.error {`, `logs.worker.warn {`} {
+		if !strings.HasPrefix(got[i], want) {
+			t.Errorf("Message %d: expected prefix %q, got %q", i, want, got[i])
+		}
+	}
+	if !strings.Contains(got[0], `"message":"one"`) || strings.HasSuffix(got[0], "\n") {
+		t.Errorf("Unexpected payload: %q", got[0])
+	}
+
+	// A rejected batch on an established connection is not resent.
+	sink, _ = NewNATSSink(NATSConfig{URL: url, Token: "secret", Subject: "logs.{stream}"})
+	for _, stream := range []string{"ok", "denied", "ok"} {
+		sink.Write(&Entry{Time: time.Now(), Level: INFO, Message: stream, Fields: map[string]interface{}{"stream": stream}})
+		err := sink.Flush()
+		if rejected := stream == "denied"; rejected != (err != nil) || (rejected && !strings.Contains(err.Error(), "Permissions Violation")) {
+			t.Errorf("Unexpected result publishing to %s: %v", stream, err)
+		}
+	}
+	sink.Close()
+	if got := broker.records()[3:]; len(got) != 3 || got[1] != "rejected logs.denied" {
+		t.Errorf("Expected the rejected batch to be sent once, got %v", got)
+	}
+}
+
+// serveRedis answers RESP commands, rejecting XADD to the "denied" stream.
+func serveRedis(b *fakeBroker, conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+		args := make([]string, n)
+		for i := range args {
+			size, _ := reader.ReadString('\n')
+			l, _ := strconv.Atoi(strings.TrimSpace(size[1:]))
+			buf := make([]byte, l+2)
+			io.ReadFull(reader, buf)
+			args[i] = string(buf[:l])
+		}
+		b.record(strings.Join(args, " "))
+		switch {
+		case args[0] == "XADD" && args[1] == "denied":
+			io.WriteString(conn, "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n")
+		case args[0] == "XADD":
+			io.WriteString(conn, "$15\r\n1700000000000-0\r\n")
+		default:
+			io.WriteString(conn, "+OK\r\n")
+		}
+	}
+}
+
+func TestRedisStreamSink(t *testing.T) {
+	broker := newFakeBroker(t, serveRedis)
+	sink, err := NewRedisStreamSink(RedisStreamConfig{
+		Address:  broker.listener.Addr().String(),
+		Password: "secret",
+		DB:       2,
+		Stream:   "{stream}",
+		MaxLen:   1000,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	sink.Write(&Entry{Time: ts, Level: INFO, Message: "hello", Fields: map[string]interface{}{"stream": "logs:api", "user": "alice", "attempt": 2}})
+	sink.Write(&Entry{Time: ts, Level: INFO, Message: "rejected", Fields: map[string]interface{}{"stream": "denied"}})
+	sink.Write(&Entry{Time: ts, Level: WARN, Message: "after", Fields: map[string]interface{}{"stream": "logs:api"}})
+	if err := sink.Flush(); err == nil || !strings.Contains(err.Error(), "WRONGTYPE") {
+		t.Errorf("Expected the rejected command's error, got %v", err)
+	}
+	sink.Write(&Entry{Time: ts, Level: INFO, Message: "same connection"})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Failed to close sink: %v", err)
+	}
+
+	got := broker.records()
+	want := []string{
+		"AUTH secret",
+		"SELECT 2",
+		"XADD logs:api MAXLEN ~ 1000 * time 2024-01-02T03:04:05Z level INFO message hello attempt 2 stream logs:api user alice",
+		"XADD denied MAXLEN ~ 1000 * time 2024-01-02T03:04:05Z level INFO message rejected stream denied",
+		"XADD logs:api MAXLEN ~ 1000 * time 2024-01-02T03:04:05Z level WARN message after stream logs:api",
+		"XADD unknown MAXLEN ~ 1000 * time 2024-01-02T03:04:05Z level INFO message same connection",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected commands:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+// serveMQTT accepts MQTT 3.1.1 sessions authenticated with password "pw"
+// and acknowledges QoS 1 publishes.
+func serveMQTT(b *fakeBroker, conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		header, err := reader.ReadByte()
+		if err != nil {
+			return
+		}
+		length, multiplier := 0, 1
+		for {
+			c, _ := reader.ReadByte()
+			length += int(c&0x7F) * multiplier
+			if c&0x80 == 0 {
+				break
+			}
+			multiplier *= 128
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			return
+		}
+		switch header & 0xF0 {
+		case mqttConnect:
+			if !strings.HasSuffix(string(body), "pw") {
+				conn.Write([]byte{mqttConnack, 2, 0, 4})
+				return
+			}
+			conn.Write([]byte{mqttConnack, 2, 0, 0})
+		case mqttPublish:
+			qos := header >> 1 & 0x03
+			n := int(body[0])<<8 | int(body[1])
+			topic, rest := string(body[2:2+n]), body[2+n:]
+			if qos > 0 {
+				conn.Write([]byte{mqttPuback, 2, rest[0], rest[1]})
+				rest = rest[2:]
+			}
+			b.record(fmt.Sprintf("%s qos=%d retain=%d %s", topic, qos, header&0x01, rest))
+		case mqttDisconnect:
+			b.record("disconnect")
+			return
+		}
+	}
+}
+
+func TestMQTTSink(t *testing.T) {
+	broker := newFakeBroker(t, serveMQTT)
+	url := "tcp://" + broker.listener.Addr().String()
+
+	denied, _ := NewMQTTSink(MQTTConfig{Broker: url, Topic: "logs", Username: "edge", Password: "wrong"})
+	if err := denied.Write(&Entry{Time: time.Now(), Level: INFO, Message: "denied"}); err == nil || !strings.Contains(err.Error(), "bad user name or password") {
+		t.Errorf("Expected the broker to refuse the connection, got %v", err)
+	}
+	if _, err := NewMQTTSink(MQTTConfig{Broker: url, Topic: "logs", QoS: 2}); err == nil {
+		t.Error("Expected QoS 2 to be rejected")
+	}
+
+	sink, err := NewMQTTSink(MQTTConfig{Broker: url, Topic: "devices/{device_id}/logs/{level}", QoS: 1, Retained: true, Username: "edge", Password: "pw"})
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	if err := sink.Write(&Entry{Time: time.Now(), Level: WARN, Message: "battery low", Fields: map[string]interface{}{"device_id": "pump/7"}}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	// A dropped connection is replaced on the next write.
+	sink.mutex.Lock()
+	sink.conn.Close()
+	sink.mutex.Unlock()
+	if err := sink.Write(&Entry{Time: time.Now(), Level: INFO, Message: "reconnected"}); err != nil {
+		t.Fatalf("Expected the sink to reconnect: %v", err)
+	}
+	sink.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(broker.records()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	got := broker.records()
+	if len(got) != 3 {
+		t.Fatalf("Expected 2 publishes and a disconnect, got %v", got)
+	}
+	if !strings.HasPrefix(got[0], "devices/pump_7/logs/warn qos=1 retain=1 {") || !strings.Contains(got[0], `"message":"battery low"`) {
+		t.Errorf("Unexpected first publish: %q", got[0])
+	}
+	if !strings.HasPrefix(got[1], "devices/unknown/logs/info qos=1 retain=1 ") || got[2] != "disconnect" {
+		t.Errorf("Unexpected publishes: %v", got)
+	}
+}
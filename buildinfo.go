@@ -0,0 +1,29 @@
+package golog
+
+import "runtime/debug"
+
+// readBuildInfo is replaced in tests.
+var readBuildInfo = debug.ReadBuildInfo
+
+// buildInfoFields returns the fields added by Config.BuildInfo: the main
+// module version and, when the binary was built from a VCS checkout, the
+// revision, whether the tree had local modifications, and the commit time.
+// It returns nil if the binary carries no build information.
+func buildInfoFields() map[string]interface{} {
+	info, ok := readBuildInfo()
+	if !ok {
+		return nil
+	}
+	fields := map[string]interface{}{"build_version": info.Main.Version}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			fields["build_revision"] = setting.Value
+		case "vcs.modified":
+			fields["build_modified"] = setting.Value == "true"
+		case "vcs.time":
+			fields["build_time"] = setting.Value
+		}
+	}
+	return fields
+}
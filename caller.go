@@ -0,0 +1,47 @@
+package golog
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// packageDir is the directory of golog's sources. Frames in it, other than
+// tests, are skipped when looking for the caller.
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file) + "/"
+}()
+
+// stdlibFrame reports whether a frame's fully qualified function name
+// belongs to the standard library, whose frames are also skipped, for
+// example sync.Once running Scope.Close or net/http calling HTTPTransport.
+// Standard library package paths have no dot in their first element, unlike
+// module paths; the file paths of frames cannot be used, as -trimpath
+// rewrites them.
+func stdlibFrame(function string) bool {
+	if i := strings.IndexByte(function, '/'); i >= 0 {
+		return !strings.Contains(function[:i], ".")
+	}
+	pkg, _, _ := strings.Cut(function, ".")
+	return pkg != "main"
+}
+
+// callerLocation returns "dir/file:line" of the first frame outside golog,
+// its adapters and the standard library, or "" if there is none.
+func callerLocation() string {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		internal := strings.HasPrefix(frame.File, packageDir) && !strings.HasSuffix(frame.File, "_test.go")
+		if !internal && !stdlibFrame(frame.Function) {
+			return filepath.Base(filepath.Dir(frame.File)) + "/" + filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,344 @@
+// Command golog views, filters, follows and converts log files written by
+// the golog library.
+//
+// Usage:
+//
+//	golog [flags] [file ...]
+//
+// With no files, golog reads standard input. Examples:
+//
+//	golog -level warn app.log                  # pretty-print WARN and above
+//	golog -f app.log                           # follow a live log
+//	golog -field user_id=123 -since 1h app.log # filter by field and time
+//	golog -output json app.log.*.gz            # convert backups to JSON
+//	golog -replay 10 app.log.*.gz              # re-emit at 10x the original pace
+//	golog -parquet logs.parquet app.log.*.gz   # export to Parquet for analytics
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/samiullahsaleem/golog"
+	"github.com/samiullahsaleem/golog/reader"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// fieldFlags collects repeated -field key=value flags.
+type fieldFlags []string
+
+// String implements flag.Value.
+func (f *fieldFlags) String() string {
+	return strings.Join(*f, ",")
+}
+
+// Set implements flag.Value.
+func (f *fieldFlags) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	*f = append(*f, value)
+	return nil
+}
+
+// options holds the parsed command-line flags.
+type options struct {
+	follow  bool
+	all     bool
+	level   string
+	grep    string
+	since   string
+	until   string
+	input   string
+	output  string
+	color   string
+	fields  fieldFlags
+	replay  float64
+	parquet string
+}
+
+// run executes the command and returns the process exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var opts options
+	fs := flag.NewFlagSet("golog", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.BoolVar(&opts.follow, "f", false, "follow the file as it grows, surviving rotation")
+	fs.BoolVar(&opts.all, "all", false, "with -f, start from the beginning of the file")
+	fs.StringVar(&opts.level, "level", "trace", "minimum level to show")
+	fs.StringVar(&opts.grep, "grep", "", "only show entries whose message contains this text")
+	fs.StringVar(&opts.since, "since", "", "only show entries at or after this RFC3339 time or duration ago (e.g. 1h)")
+	fs.StringVar(&opts.until, "until", "", "only show entries before this RFC3339 time or duration ago")
+	fs.StringVar(&opts.input, "input", "auto", "input format: auto, text, json or logfmt")
+	fs.StringVar(&opts.output, "output", "pretty", "output format: pretty, text, json or logfmt")
+	fs.StringVar(&opts.color, "color", "auto", "colorize pretty output: auto, always or never")
+	fs.Var(&opts.fields, "field", "only show entries with field key=value (repeatable)")
+	fs.Float64Var(&opts.replay, "replay", 0, "re-emit entries paced at this multiple of their original speed")
+	fs.StringVar(&opts.parquet, "parquet", "", "write matching entries of all inputs to this Parquet file instead of printing")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	filter, err := buildFilter(opts, time.Now())
+	if err != nil {
+		fmt.Fprintf(stderr, "golog: %v\n", err)
+		return 2
+	}
+	input, err := parseInputFormat(opts.input)
+	if err != nil {
+		fmt.Fprintf(stderr, "golog: %v\n", err)
+		return 2
+	}
+	p, err := newPrinter(opts.output, useColor(opts.color, stdout))
+	if err != nil {
+		fmt.Fprintf(stderr, "golog: %v\n", err)
+		return 2
+	}
+
+	files := fs.Args()
+	emit := printAll
+	if opts.replay > 0 {
+		emit = func(r *reader.Reader, filter reader.Filter, p printer, stdout, stderr io.Writer) int {
+			return replay(r, filter, opts.replay, p, stdout, stderr)
+		}
+	}
+	if opts.parquet != "" {
+		if opts.follow || opts.replay > 0 {
+			fmt.Fprintln(stderr, "golog: -parquet cannot be combined with -f or -replay")
+			return 2
+		}
+		return exportParquet(opts.parquet, files, input, filter, stdin, stderr)
+	}
+	if opts.follow {
+		if len(files) != 1 {
+			fmt.Fprintln(stderr, "golog: -f requires exactly one file")
+			return 2
+		}
+		return follow(files[0], input, filter, !opts.all, p, stdout, stderr)
+	}
+
+	if len(files) == 0 {
+		r, err := reader.New(stdin, input)
+		if err != nil {
+			fmt.Fprintf(stderr, "golog: %v\n", err)
+			return 1
+		}
+		return emit(r, filter, p, stdout, stderr)
+	}
+
+	status := 0
+	for _, path := range files {
+		r, err := reader.Open(path, input)
+		if err != nil {
+			fmt.Fprintf(stderr, "golog: %v\n", err)
+			status = 1
+			continue
+		}
+		if code := emit(r, filter, p, stdout, stderr); code != 0 {
+			status = code
+		}
+		r.Close()
+	}
+	return status
+}
+
+// printAll prints every matching entry of r.
+func printAll(r *reader.Reader, filter reader.Filter, p printer, stdout, stderr io.Writer) int {
+	r.SetFilter(filter)
+	for r.Next() {
+		io.WriteString(stdout, p(r.Entry()))
+	}
+	if err := r.Err(); err != nil {
+		fmt.Fprintf(stderr, "golog: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// exportParquet writes the matching entries of files, or of stdin when
+// there are none, to one Parquet file at path.
+func exportParquet(path string, files []string, input reader.Format, filter reader.Filter, stdin io.Reader, stderr io.Writer) int {
+	out, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "golog: %v\n", err)
+		return 1
+	}
+	defer out.Close()
+	pw := reader.NewParquetWriter(out, reader.ParquetConfig{Compress: true})
+
+	status := 0
+	add := func(r *reader.Reader) {
+		r.SetFilter(filter)
+		for r.Next() {
+			pw.Write(r.Entry())
+		}
+		if err := r.Err(); err != nil {
+			fmt.Fprintf(stderr, "golog: %v\n", err)
+			status = 1
+		}
+	}
+	if len(files) == 0 {
+		r, err := reader.New(stdin, input)
+		if err != nil {
+			fmt.Fprintf(stderr, "golog: %v\n", err)
+			return 1
+		}
+		add(r)
+	}
+	for _, file := range files {
+		r, err := reader.Open(file, input)
+		if err != nil {
+			fmt.Fprintf(stderr, "golog: %v\n", err)
+			status = 1
+			continue
+		}
+		add(r)
+		r.Close()
+	}
+	if err := pw.Close(); err != nil {
+		fmt.Fprintf(stderr, "golog: %v\n", err)
+		return 1
+	}
+	if err := out.Close(); err != nil {
+		fmt.Fprintf(stderr, "golog: %v\n", err)
+		return 1
+	}
+	return status
+}
+
+// printerSink adapts a printer to golog.Sink for replay.
+type printerSink struct {
+	printer printer
+	out     io.Writer
+}
+
+// Write implements golog.Sink.
+func (s printerSink) Write(entry *golog.Entry) error {
+	_, err := io.WriteString(s.out, s.printer(entry))
+	return err
+}
+
+// Close implements golog.Sink.
+func (s printerSink) Close() error {
+	return nil
+}
+
+// replay prints matching entries of r paced at speed times their original
+// rate until the input ends or the command is interrupted.
+func replay(r *reader.Reader, filter reader.Filter, speed float64, p printer, stdout, stderr io.Writer) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	r.SetFilter(filter)
+	if _, err := reader.Replay(ctx, r, printerSink{printer: p, out: stdout}, reader.ReplayConfig{Speed: speed}); err != nil {
+		fmt.Fprintf(stderr, "golog: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// follow prints matching entries as they are appended to path until
+// interrupted.
+func follow(path string, input reader.Format, filter reader.Filter, fromEnd bool, p printer, stdout, stderr io.Writer) int {
+	follower, err := reader.FollowWithConfig(path, reader.FollowConfig{FromEnd: fromEnd, Format: input, Filter: filter})
+	if err != nil {
+		fmt.Fprintf(stderr, "golog: %v\n", err)
+		return 1
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		follower.Close()
+	}()
+
+	for entry := range follower.Entries() {
+		io.WriteString(stdout, p(entry))
+	}
+	if err := follower.Err(); err != nil {
+		fmt.Fprintf(stderr, "golog: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// buildFilter converts the filtering flags into a reader.Filter.
+func buildFilter(opts options, now time.Time) (reader.Filter, error) {
+	var filter reader.Filter
+
+	level, err := golog.ParseLevel(opts.level)
+	if err != nil {
+		return filter, err
+	}
+	filter.MinLevel = level
+
+	if filter.Since, err = parseTimeFlag(opts.since, now); err != nil {
+		return filter, fmt.Errorf("invalid -since: %v", err)
+	}
+	if filter.Until, err = parseTimeFlag(opts.until, now); err != nil {
+		return filter, fmt.Errorf("invalid -until: %v", err)
+	}
+
+	if opts.grep != "" {
+		filter.Predicates = append(filter.Predicates, reader.MessageContains(opts.grep))
+	}
+	for _, f := range opts.fields {
+		key, value, _ := strings.Cut(f, "=")
+		filter.Predicates = append(filter.Predicates, reader.FieldEquals(key, value))
+	}
+	return filter, nil
+}
+
+// parseTimeFlag accepts an RFC3339 timestamp or a duration before now.
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// parseInputFormat converts the -input flag.
+func parseInputFormat(value string) (reader.Format, error) {
+	switch value {
+	case "auto":
+		return reader.FormatAuto, nil
+	case "text":
+		return reader.FormatText, nil
+	case "json":
+		return reader.FormatJSON, nil
+	case "logfmt":
+		return reader.FormatLogfmt, nil
+	}
+	return reader.FormatAuto, fmt.Errorf("unknown input format %q", value)
+}
+
+// useColor resolves the -color flag against the output stream.
+func useColor(mode string, out io.Writer) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunFiltersAndConverts(t *testing.T) {
+	input := `{"timestamp":"2025-07-18T21:48:00Z","level":"INFO","message":"started","version":"1.0.0"}
+{"timestamp":"2025-07-18T21:49:00Z","level":"ERROR","message":"connection failed","error":"timeout","user_id":123}
+{"timestamp":"2025-07-18T21:50:00Z","level":"ERROR","message":"connection failed","error":"refused","user_id":456}
+`
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-level", "error", "-field", "user_id=123", "-output", "logfmt"}, strings.NewReader(input), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	expected := `time=2025-07-18T21:49:00Z level=error msg="connection failed" error=timeout user_id=123` + "\n"
+	if stdout.String() != expected {
+		t.Errorf("Unexpected output:\n got: %q\nwant: %q", stdout.String(), expected)
+	}
+}
+
+func TestRunPrettyOutput(t *testing.T) {
+	input := "level=warn msg=\"disk almost full\" usage=93\n"
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-color", "never"}, strings.NewReader(input), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if stdout.String() != "WARN  disk almost full usage=93\n" {
+		t.Errorf("Unexpected pretty output: %q", stdout.String())
+	}
+}
+
+func TestRunRejectsBadFlags(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-level", "loud"}, strings.NewReader(""), &stdout, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2 for an unknown level, got %d", code)
+	}
+	if code := run([]string{"-parquet", "out.parquet", "-replay", "2"}, strings.NewReader(""), &stdout, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2 for -parquet with -replay, got %d", code)
+	}
+}
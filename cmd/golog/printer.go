@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samiullahsaleem/golog"
+)
+
+// printer renders one entry for output, including the trailing newline.
+type printer func(entry *golog.Entry) string
+
+// ANSI escape sequences used by the pretty printer.
+const (
+	ansiReset = "\x1b[0m"
+	ansiDim   = "\x1b[2m"
+	ansiBold  = "\x1b[1m"
+)
+
+// newPrinter returns the printer for the -output flag.
+func newPrinter(format string, color bool) (printer, error) {
+	var f golog.EntryFormatter
+	switch format {
+	case "pretty":
+		return prettyPrinter(color), nil
+	case "text":
+		f = &golog.TextFormatter{}
+	case "json":
+		f = &golog.JSONFormatter{KeyOrder: golog.CoreKeysFirst}
+	case "logfmt":
+		f = &golog.LogfmtFormatter{}
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+	return f.FormatEntry, nil
+}
+
+// prettyPrinter renders entries for humans: aligned level, dimmed
+// timestamp and key=value fields, optionally colorized.
+func prettyPrinter(color bool) printer {
+	paint := func(code, s string) string {
+		if !color || code == "" {
+			return s
+		}
+		return code + s + ansiReset
+	}
+
+	return func(entry *golog.Entry) string {
+		var sb strings.Builder
+		if !entry.Time.IsZero() {
+			sb.WriteString(paint(ansiDim, entry.Time.Format("2006-01-02 15:04:05.000")))
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(paint(golog.DefaultTheme.Escape(entry.Level), fmt.Sprintf("%-5s", entry.Level.String())))
+		sb.WriteByte(' ')
+		sb.WriteString(paint(ansiBold, entry.Message))
+
+		keys := make([]string, 0, len(entry.Fields))
+		for k := range entry.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sb.WriteByte(' ')
+			sb.WriteString(paint(ansiDim, k+"="))
+			sb.WriteString(fmt.Sprint(entry.Fields[k]))
+		}
+		sb.WriteByte('\n')
+		return sb.String()
+	}
+}
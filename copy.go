@@ -0,0 +1,88 @@
+package golog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// maxCopyLine bounds the lines CopyFrom logs as single entries; longer
+// lines are logged in pieces of this size.
+const maxCopyLine = 1024 * 1024
+
+// CopyFrom logs the lines read from r through the root logger. See
+// Logger.CopyFrom.
+func CopyFrom(r io.Reader, level LogLevel, fields map[string]interface{}) error {
+	return RootLogger().CopyFrom(r, level, fields)
+}
+
+// CopyFrom logs every line read from r, such as the stdout or stderr of a
+// child process, until r is exhausted. A line holding a JSON object becomes
+// a structured entry: its "message" (or "msg") and "level" (or "lvl",
+// "severity") keys set the message and level, and its other keys become
+// fields. Any other line is logged as the message at level. fields are
+// added to every entry, unless the line sets the same key.
+//
+// The next line is read only after the previous entry has been logged, so
+// a producer writing faster than the logger's outputs accept is slowed
+// down instead of its output being buffered without bound.
+func (l *Logger) CopyFrom(r io.Reader, level LogLevel, fields map[string]interface{}) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	var line []byte
+	for {
+		chunk, err := br.ReadSlice('\n')
+		line = append(line, chunk...)
+		if err == bufio.ErrBufferFull && len(line) < maxCopyLine {
+			continue
+		}
+		if len(line) > 0 {
+			l.copyLine(line, level, fields)
+			line = line[:0]
+		}
+		switch err {
+		case nil, bufio.ErrBufferFull:
+		case io.EOF:
+			return nil
+		default:
+			return fmt.Errorf("failed to read log lines: %v", err)
+		}
+	}
+}
+
+// copyLine logs one line read by CopyFrom.
+func (l *Logger) copyLine(line []byte, level LogLevel, fields map[string]interface{}) {
+	line = bytes.TrimRight(line, "\r\n")
+	if len(bytes.TrimSpace(line)) == 0 {
+		return
+	}
+	msg, entryFields := string(line), make(map[string]interface{}, len(fields))
+	var object map[string]interface{}
+	if line[0] == '{' && json.Unmarshal(line, &object) == nil {
+		msg = ""
+		for k, v := range object {
+			switch k {
+			case "message", "msg":
+				if s, ok := v.(string); ok {
+					msg = s
+					continue
+				}
+			case "level", "lvl", "severity":
+				if s, ok := v.(string); ok {
+					if parsed, err := ParseLevel(s); err == nil {
+						level = parsed
+						continue
+					}
+				}
+			}
+			entryFields[k] = v
+		}
+	}
+	for k, v := range fields {
+		if _, ok := entryFields[k]; !ok {
+			entryFields[k] = v
+		}
+	}
+	l.log(level, msg, entryFields)
+}
@@ -0,0 +1,154 @@
+package golog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultCrashRecent is the number of recent entries kept for crash reports
+// when Config.CrashReportRecent is 0.
+const defaultCrashRecent = 100
+
+// maxStackDump bounds the goroutine dump in a crash report.
+const maxStackDump = 64 * 1024 * 1024
+
+// CrashReport is the content of a crash report file.
+type CrashReport struct {
+	Time       time.Time         `json:"time"`
+	PID        int               `json:"pid"`
+	Hostname   string            `json:"hostname,omitempty"`
+	Args       []string          `json:"args"`
+	Entry      json.RawMessage   `json:"entry"`      // The FATAL or panic entry, as JSONFormatter writes it
+	Goroutines string            `json:"goroutines"` // Stack traces of all goroutines
+	GoVersion  string            `json:"go_version"`
+	Build      map[string]string `json:"build,omitempty"` // Main module path and version, and VCS settings
+	Recent     []json.RawMessage `json:"recent"`          // Entries logged before the crash, oldest first
+}
+
+// crashReporter keeps the most recent entries and writes crash reports.
+type crashReporter struct {
+	dir    string
+	mutex  sync.Mutex
+	recent []*Entry // ring buffer
+	next   int
+	full   bool
+}
+
+// newCrashReporter returns a reporter writing to dir keeping the given
+// number of recent entries, or nil if dir is empty.
+func newCrashReporter(dir string, recent int) *crashReporter {
+	if dir == "" {
+		return nil
+	}
+	if recent <= 0 {
+		recent = defaultCrashRecent
+	}
+	// One more slot holds the crashing entry itself, which is left out of
+	// the recent entries.
+	return &crashReporter{dir: dir, recent: make([]*Entry, recent+1)}
+}
+
+// record adds entry to the recent entries.
+func (c *crashReporter) record(entry *Entry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.recent[c.next] = entry
+	c.next = (c.next + 1) % len(c.recent)
+	if c.next == 0 {
+		c.full = true
+	}
+}
+
+// snapshot returns the recent entries, oldest first.
+func (c *crashReporter) snapshot() []*Entry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.full {
+		return append([]*Entry(nil), c.recent[:c.next]...)
+	}
+	return append(append([]*Entry(nil), c.recent[c.next:]...), c.recent[:c.next]...)
+}
+
+// report writes a crash report for entry, normally the last one recorded.
+// Failures are reported on stderr, since the process is usually about to
+// die.
+func (c *crashReporter) report(entry *Entry) {
+	recent := c.snapshot()
+	if n := len(recent); n > 0 && recent[n-1] == entry {
+		recent = recent[:n-1]
+	}
+	report := CrashReport{
+		Time:       entry.Time,
+		PID:        os.Getpid(),
+		Args:       os.Args,
+		Entry:      encodeCrashEntry(entry),
+		Goroutines: string(allStacks()),
+		GoVersion:  runtime.Version(),
+		Build:      crashBuildInfo(),
+		Recent:     make([]json.RawMessage, len(recent)),
+	}
+	report.Hostname, _ = os.Hostname()
+	for i, e := range recent {
+		report.Recent[i] = encodeCrashEntry(e)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode crash report: %v\n", err)
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create crash report directory: %v\n", err)
+		return
+	}
+	name := fmt.Sprintf("crash-%s-%d.json", entry.Time.UTC().Format("20060102T150405.000000000Z"), report.PID)
+	if err := os.WriteFile(filepath.Join(c.dir, name), append(data, '\n'), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write crash report: %v\n", err)
+	}
+}
+
+// encodeCrashEntry encodes an entry as JSONFormatter does.
+func encodeCrashEntry(entry *Entry) json.RawMessage {
+	return json.RawMessage(strings.TrimSuffix((&JSONFormatter{}).FormatEntry(entry), "\n"))
+}
+
+// allStacks returns the stack traces of all goroutines.
+func allStacks() []byte {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxStackDump {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// crashBuildInfo returns the main module and VCS settings of the binary.
+func crashBuildInfo() map[string]string {
+	info, ok := readBuildInfo()
+	if !ok {
+		return nil
+	}
+	build := map[string]string{"path": info.Main.Path, "version": info.Main.Version}
+	for _, setting := range info.Settings {
+		if strings.HasPrefix(setting.Key, "vcs") {
+			build[setting.Key] = setting.Value
+		}
+	}
+	return build
+}
+
+// reportCrash writes a crash report for entry if l's root logger has
+// CrashReportDir set.
+func (l *Logger) reportCrash(entry *Entry) {
+	if crash := l.root().crash; crash != nil {
+		crash.report(entry)
+	}
+}
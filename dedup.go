@@ -0,0 +1,96 @@
+package golog
+
+import (
+	"fmt"
+	"time"
+)
+
+// DedupKey selects what makes two consecutive entries identical for
+// duplicate suppression.
+type DedupKey int
+
+const (
+	// DedupMessage compares the level and message only.
+	DedupMessage DedupKey = iota
+	// DedupMessageAndFields also compares the fields.
+	DedupMessageAndFields
+)
+
+// deduper collapses bursts of identical consecutive entries into a single
+// "last message repeated N times" entry, like classic syslog. All methods
+// must be called with the owning logger's mutex held.
+type deduper struct {
+	window time.Duration
+	key    DedupKey
+	last   string
+	entry  *Entry
+	first  time.Time
+	count  int
+	timer  *time.Timer
+	burst  uint64 // incremented by flush, so a timer firing late can tell its burst has ended
+}
+
+// newDeduper returns nil if window disables suppression.
+func newDeduper(window time.Duration, key DedupKey) *deduper {
+	if window <= 0 {
+		return nil
+	}
+	return &deduper{window: window, key: key}
+}
+
+// suppress reports whether entry repeats the previous entry within the
+// window. When a burst ends it first writes the pending summary.
+func (d *deduper) suppress(l *Logger, entry *Entry) bool {
+	key := d.keyFor(entry)
+	if key == d.last && entry.Time.Sub(d.first) < d.window {
+		d.count++
+		if d.timer == nil {
+			remaining := d.window - entry.Time.Sub(d.first)
+			burst := d.burst
+			d.timer = time.AfterFunc(remaining, func() {
+				l.mutex.Lock()
+				defer l.mutex.Unlock()
+				if d.burst == burst {
+					d.flush(l)
+				}
+			})
+		}
+		return true
+	}
+
+	d.flush(l)
+	d.last = key
+	d.entry = entry
+	d.first = entry.Time
+	return false
+}
+
+// flush writes the summary for the current burst, if any, and forgets it
+// so the next entry starts a new burst.
+func (d *deduper) flush(l *Logger) {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if d.count > 0 {
+		l.output(&Entry{
+			Time:    time.Now(),
+			Level:   d.entry.Level,
+			Message: fmt.Sprintf("last message repeated %d times", d.count),
+			Fields:  map[string]interface{}{"repeated": d.count, "original": d.entry.Message},
+		})
+	}
+	d.last = ""
+	d.entry = nil
+	d.count = 0
+	d.burst++
+}
+
+// keyFor returns the comparison key for entry.
+func (d *deduper) keyFor(entry *Entry) string {
+	key := entry.Level.String() + "\x00" + entry.Message
+	if d.key == DedupMessageAndFields && len(entry.Fields) > 0 {
+		key += "\x00" + fmt.Sprint(normalizeFields(entry.Fields))
+	}
+	return key
+}
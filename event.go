@@ -0,0 +1,96 @@
+package golog
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// EventDef describes a stable, catalogued event that operations can alert
+// on by ID instead of by message text.
+type EventDef struct {
+	ID          int
+	Name        string   // Short stable name, e.g. "payment_declined"
+	Description string   // Human-readable description, used when no message is given
+	Level       LogLevel // Severity the event is logged at; the zero value (TRACE) means INFO
+}
+
+var (
+	eventMutex   sync.RWMutex
+	eventCatalog = make(map[int]EventDef)
+)
+
+// RegisterEvent adds an event definition to the catalog. IDs must be unique.
+// Catalogued events exist to be alerted on, so a definition without a Level
+// is logged at INFO rather than at TRACE, where it would be filtered out.
+func RegisterEvent(def EventDef) error {
+	_, err := registerEvent(def)
+	return err
+}
+
+// registerEvent adds def to the catalog and returns it as stored.
+func registerEvent(def EventDef) (EventDef, error) {
+	eventMutex.Lock()
+	defer eventMutex.Unlock()
+
+	if existing, ok := eventCatalog[def.ID]; ok {
+		return def, fmt.Errorf("event %d already registered as %q", def.ID, existing.Name)
+	}
+	if def.Level == TRACE {
+		def.Level = INFO
+	}
+	eventCatalog[def.ID] = def
+	return def, nil
+}
+
+// MustRegisterEvent is like RegisterEvent but panics on error. It is meant
+// for package-level catalog declarations.
+func MustRegisterEvent(def EventDef) EventDef {
+	def, err := registerEvent(def)
+	if err != nil {
+		panic(err)
+	}
+	return def
+}
+
+// LookupEvent returns the definition registered for id.
+func LookupEvent(id int) (EventDef, bool) {
+	eventMutex.RLock()
+	defer eventMutex.RUnlock()
+	def, ok := eventCatalog[id]
+	return def, ok
+}
+
+// Events returns the catalog sorted by ID.
+func Events() []EventDef {
+	eventMutex.RLock()
+	defer eventMutex.RUnlock()
+
+	defs := make([]EventDef, 0, len(eventCatalog))
+	for _, def := range eventCatalog {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].ID < defs[j].ID })
+	return defs
+}
+
+// Event logs a catalogued event with "event_id" and "event" fields at the
+// level registered for id. An empty msg falls back to the event's
+// description. Unregistered IDs are logged at WARN so they are noticed.
+func (l *Logger) Event(id int, msg string, fields ...map[string]interface{}) {
+	merged := mergeFields(fields)
+	merged["event_id"] = id
+
+	def, ok := LookupEvent(id)
+	if !ok {
+		l.log(WARN, msg, merged)
+		return
+	}
+	if def.Name != "" {
+		merged["event"] = def.Name
+	}
+	if msg == "" {
+		msg = def.Description
+	}
+	l.log(def.Level, msg, merged)
+}
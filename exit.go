@@ -0,0 +1,89 @@
+package golog
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+var (
+	exitMutex    sync.Mutex
+	exitHandlers []func()
+	exitFunc     = os.Exit
+	exiting      chan struct{} // closed once the running handlers finish
+	exitCode     int           // code passed to the running Exit
+)
+
+// RegisterExitHandler adds a function to run before Fatal terminates the
+// program, for example to flush sinks or close database connections.
+// Handlers run once, in registration order; a panicking handler is reported
+// on stderr and does not prevent the remaining handlers from running.
+// Handlers must not call Fatal or Exit themselves.
+func RegisterExitHandler(handler func()) {
+	exitMutex.Lock()
+	defer exitMutex.Unlock()
+	exitHandlers = append(exitHandlers, handler)
+}
+
+// SetExitFunc replaces the function Fatal uses to terminate the program,
+// which is os.Exit by default. Passing nil restores os.Exit.
+func SetExitFunc(fn func(code int)) {
+	exitMutex.Lock()
+	defer exitMutex.Unlock()
+
+	if fn == nil {
+		fn = os.Exit
+	}
+	exitFunc = fn
+}
+
+// Exit runs the registered exit handlers and terminates the program with
+// the given code. Callers arriving while the handlers are running wait for
+// them to finish before exiting, so concurrent Fatal calls cannot cut the
+// teardown short.
+func Exit(code int) {
+	exitMutex.Lock()
+	if done := exiting; done != nil {
+		exitMutex.Unlock()
+		<-done
+		exitMutex.Lock()
+		exit := exitFunc
+		exitMutex.Unlock()
+		exit(code)
+		return
+	}
+	done := make(chan struct{})
+	exiting, exitCode = done, code
+	handlers := exitHandlers
+	exitHandlers = nil
+	exitMutex.Unlock()
+
+	for _, handler := range handlers {
+		runExitHandler(handler)
+	}
+
+	exitMutex.Lock()
+	exiting = nil
+	exit := exitFunc
+	exitMutex.Unlock()
+	close(done)
+	exit(code)
+}
+
+// pendingExitCode returns the code the running Exit will terminate with,
+// for exit handlers that close loggers.
+func pendingExitCode() (int, bool) {
+	exitMutex.Lock()
+	defer exitMutex.Unlock()
+	return exitCode, exiting != nil
+}
+
+// runExitHandler calls handler, reporting rather than propagating a panic.
+func runExitHandler(handler func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "Exit handler panicked: %v\n", r)
+		}
+	}()
+	handler()
+}
@@ -0,0 +1,371 @@
+package golog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ObjectEncoder receives the key-value pairs of a LogObjectMarshaler.
+type ObjectEncoder interface {
+	AddField(key string, value interface{})
+}
+
+// LogObjectMarshaler is implemented by types that know how to render
+// themselves as a group of log fields.
+type LogObjectMarshaler interface {
+	MarshalLogObject(enc ObjectEncoder) error
+}
+
+// Dict builds a nested group of fields from alternating keys and values.
+// A trailing key without a value is stored under "!BADKEY".
+func Dict(keysAndValues ...interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(keysAndValues)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 == len(keysAndValues) {
+			result["!BADKEY"] = keysAndValues[i]
+			break
+		}
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		result[key] = keysAndValues[i+1]
+	}
+	return result
+}
+
+// Array builds a list field value from the given values.
+func Array(values ...interface{}) []interface{} {
+	return values
+}
+
+// mapEncoder is the ObjectEncoder handed to LogObjectMarshaler implementations.
+type mapEncoder map[string]interface{}
+
+// AddField implements ObjectEncoder.
+func (m mapEncoder) AddField(key string, value interface{}) {
+	m[key] = normalizeValue(value)
+}
+
+// Limits for values encoded by reflection. They keep arbitrary domain
+// objects from producing runaway output; set them during initialization.
+var (
+	// MaxFieldDepth is the nesting depth below which values are replaced by
+	// "!MAXDEPTH".
+	MaxFieldDepth = 10
+	// MaxFieldElements is the number of slice, array or map elements kept;
+	// the rest are summarized in a "!TRUNCATED" marker.
+	MaxFieldElements = 1000
+)
+
+// NonFinitePolicy selects how NaN and infinite floats are encoded, since
+// JSON cannot represent them.
+type NonFinitePolicy int
+
+const (
+	// NonFiniteString encodes them as the strings "NaN", "+Inf" and "-Inf".
+	NonFiniteString NonFinitePolicy = iota
+	// NonFiniteNull encodes them as null.
+	NonFiniteNull
+	// NonFiniteZero encodes them as 0.
+	NonFiniteZero
+)
+
+// NilPolicy selects how nil values (nil pointers, maps, slices and
+// interfaces) are encoded.
+type NilPolicy int
+
+const (
+	// NilNull keeps them as null.
+	NilNull NilPolicy = iota
+	// NilOmit drops fields and map entries holding them; nil slice
+	// elements stay null.
+	NilOmit
+)
+
+// Policies for field values that cannot be encoded as given; set them
+// during initialization.
+var (
+	// NonFiniteFloats selects the encoding of NaN and infinite floats.
+	NonFiniteFloats = NonFiniteString
+	// NilValues selects the encoding of nil values.
+	NilValues = NilNull
+	// OnCoercion, when set, is called every time a field value is replaced
+	// because it could not be encoded as given, such as a NaN float or a
+	// value JSON encoding rejects.
+	OnCoercion func(err error)
+)
+
+// reportCoercion passes a coercion to OnCoercion.
+func reportCoercion(format string, args ...interface{}) {
+	if OnCoercion != nil {
+		OnCoercion(fmt.Errorf(format, args...))
+	}
+}
+
+// normalizeFields converts every field value into a tree of maps, slices and
+// scalars that both the text and JSON formatters can render faithfully.
+func normalizeFields(fields map[string]interface{}) map[string]interface{} {
+	if len(fields) == 0 {
+		return fields
+	}
+	result := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		enc := valueEncoder{seen: make(map[uintptr]bool), key: k}
+		if value := enc.value(v, 0); value != nil || NilValues != NilOmit {
+			result[k] = value
+		}
+	}
+	return result
+}
+
+// normalizeValue converts a single field value. Scalars keep their original
+// type so that, for example, time.Duration still prints as "1.5s".
+func normalizeValue(v interface{}) interface{} {
+	enc := valueEncoder{seen: make(map[uintptr]bool)}
+	return enc.value(v, 0)
+}
+
+// valueEncoder tracks the references on the path being encoded, so cycles
+// are cut instead of recursing forever.
+type valueEncoder struct {
+	seen map[uintptr]bool
+	key  string // field being encoded, for coercion reports
+}
+
+// value converts v found at the given nesting depth.
+func (e *valueEncoder) value(v interface{}, depth int) (result interface{}) {
+	if v == nil {
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+
+	switch val := v.(type) {
+	case float64:
+		return e.float(val, v)
+	case float32:
+		return e.float(float64(val), v)
+	case string, bool, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64, uintptr, []byte:
+		return v
+	case LogObjectMarshaler:
+		defer recoverValue(&result)
+		enc := make(mapEncoder)
+		if err := val.MarshalLogObject(enc); err != nil {
+			enc["!ERROR"] = err.Error()
+		}
+		return map[string]interface{}(enc)
+	case json.Marshaler:
+		defer recoverValue(&result)
+		data, err := val.MarshalJSON()
+		if err != nil {
+			return fmt.Sprintf("!ERROR: %v", err)
+		}
+		var out interface{}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&out); err != nil {
+			return string(data)
+		}
+		return out
+	case error:
+		defer recoverValue(&result)
+		return val.Error()
+	}
+
+	return e.reflect(rv, v, depth)
+}
+
+// float applies NonFiniteFloats to NaN and infinite floats and returns
+// other floats, orig, unchanged.
+func (e *valueEncoder) float(f float64, orig interface{}) interface{} {
+	if !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return orig
+	}
+	var replacement interface{}
+	switch NonFiniteFloats {
+	case NonFiniteNull:
+		replacement = nil
+	case NonFiniteZero:
+		replacement = 0
+	default:
+		replacement = "NaN"
+		if !math.IsNaN(f) {
+			replacement = fmt.Sprintf("%+v", f) // "+Inf" or "-Inf"
+		}
+	}
+	if e.key != "" {
+		reportCoercion("field %s: replaced %v with %v", e.key, f, replacement)
+	} else {
+		reportCoercion("replaced %v with %v", f, replacement)
+	}
+	return replacement
+}
+
+// recoverValue turns a panic in a marshaling method into a "!PANIC" value.
+func recoverValue(result *interface{}) {
+	if r := recover(); r != nil {
+		*result = fmt.Sprintf("!PANIC: %v", r)
+	}
+}
+
+// reflect is the fallback for values without a dedicated encoding.
+func (e *valueEncoder) reflect(rv reflect.Value, orig interface{}, depth int) interface{} {
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		if depth >= MaxFieldDepth {
+			return "!MAXDEPTH"
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		if rv.Kind() == reflect.Ptr {
+			if !e.enter(rv.Pointer()) {
+				return "!CYCLE"
+			}
+			defer e.leave(rv.Pointer())
+		}
+		return e.value(rv.Elem().Interface(), depth+1)
+	case reflect.Struct:
+		result := make(map[string]interface{})
+		e.addStructFields(result, rv, depth)
+		return result
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil
+		}
+		if !e.enter(rv.Pointer()) {
+			return "!CYCLE"
+		}
+		defer e.leave(rv.Pointer())
+		keys := rv.MapKeys()
+		names := make([]string, len(keys))
+		for i, key := range keys {
+			names[i] = mapKeyString(key)
+		}
+		sort.Sort(byName{keys, names})
+		result := make(map[string]interface{}, len(keys))
+		for i, key := range keys {
+			if i == MaxFieldElements {
+				result["!TRUNCATED"] = len(keys) - i
+				break
+			}
+			if value := e.value(rv.MapIndex(key).Interface(), depth+1); value != nil || NilValues != NilOmit {
+				result[names[i]] = value
+			}
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice {
+			if rv.IsNil() {
+				return nil
+			}
+			if rv.Len() > 0 {
+				if !e.enter(rv.Pointer()) {
+					return "!CYCLE"
+				}
+				defer e.leave(rv.Pointer())
+			}
+		}
+		n := rv.Len()
+		if n > MaxFieldElements {
+			n = MaxFieldElements
+		}
+		result := make([]interface{}, n, n+1)
+		for i := 0; i < n; i++ {
+			result[i] = e.value(rv.Index(i).Interface(), depth+1)
+		}
+		if more := rv.Len() - n; more > 0 {
+			result = append(result, fmt.Sprintf("!TRUNCATED: %d more", more))
+		}
+		return result
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return fmt.Sprint(orig)
+	}
+	return orig
+}
+
+// enter records a reference on the current path, reporting false if it is
+// already there.
+func (e *valueEncoder) enter(ptr uintptr) bool {
+	if e.seen[ptr] {
+		return false
+	}
+	e.seen[ptr] = true
+	return true
+}
+
+// leave removes a reference added by enter.
+func (e *valueEncoder) leave(ptr uintptr) {
+	delete(e.seen, ptr)
+}
+
+// addStructFields copies the exported fields of a struct into result. A
+// log:"name" tag renames a field and log:"-" omits it; without a log tag
+// json tag names are honored. Embedded structs are flattened like
+// encoding/json, and ",omitempty" skips zero values.
+func (e *valueEncoder) addStructFields(result map[string]interface{}, rv reflect.Value, depth int) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, hasTag := field.Tag.Lookup("log")
+		if !hasTag {
+			tag = field.Tag.Get("json")
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && tag == "" {
+			e.addStructFields(result, rv.Field(i), depth)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		tagName, options, _ := strings.Cut(tag, ",")
+		if tag == "-" {
+			continue
+		}
+		if tagName != "" {
+			name = tagName
+		}
+		if strings.Contains(options, "omitempty") && rv.Field(i).IsZero() {
+			continue
+		}
+		if value := e.value(rv.Field(i).Interface(), depth+1); value != nil || NilValues != NilOmit {
+			result[name] = value
+		}
+	}
+}
+
+// byName sorts map keys by their rendered names.
+type byName struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (b byName) Len() int           { return len(b.keys) }
+func (b byName) Less(i, j int) bool { return b.names[i] < b.names[j] }
+func (b byName) Swap(i, j int) {
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+	b.names[i], b.names[j] = b.names[j], b.names[i]
+}
+
+// mapKeyString renders a map key as a field name.
+func mapKeyString(key reflect.Value) string {
+	if key.Kind() == reflect.String {
+		return key.String()
+	}
+	return fmt.Sprint(key.Interface())
+}
@@ -0,0 +1,46 @@
+package golog
+
+import (
+	"fmt"
+	"os"
+)
+
+// LockSuffix is appended to a log path to name the lock file used by
+// Config.FileLock.
+const LockSuffix = ".lock"
+
+// fileLock is an advisory lock shared by every process writing one log
+// path. It lives in a separate file so it stays valid across rotation.
+type fileLock struct {
+	file *os.File
+}
+
+// newFileLock opens the lock file for a log path.
+func newFileLock(logPath string) (*fileLock, error) {
+	file, err := os.OpenFile(logPath+LockSuffix, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %v", err)
+	}
+	return &fileLock{file: file}, nil
+}
+
+// lock blocks until the calling process holds the lock exclusively.
+func (fl *fileLock) lock() error {
+	if err := lockFile(fl.file); err != nil {
+		return fmt.Errorf("failed to lock log file: %v", err)
+	}
+	return nil
+}
+
+// unlock releases the lock.
+func (fl *fileLock) unlock() error {
+	if err := unlockFile(fl.file); err != nil {
+		return fmt.Errorf("failed to unlock log file: %v", err)
+	}
+	return nil
+}
+
+// close closes the lock file, releasing the lock if held.
+func (fl *fileLock) close() error {
+	return fl.file.Close()
+}
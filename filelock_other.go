@@ -0,0 +1,16 @@
+//go:build !unix
+
+package golog
+
+import "os"
+
+// lockFile is a no-op on platforms without flock; writes from several
+// processes are then only protected by O_APPEND.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile is a no-op on platforms without flock.
+func unlockFile(f *os.File) error {
+	return nil
+}
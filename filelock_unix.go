@@ -0,0 +1,23 @@
+//go:build unix
+
+package golog
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive flock on f.
+func lockFile(f *os.File) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+// unlockFile releases a flock taken by lockFile.
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -1,9 +1,14 @@
 package golog
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // Formatter defines the interface for log formatting.
@@ -11,32 +16,493 @@ type Formatter interface {
 	Format(level LogLevel, msg string, fields map[string]interface{}) string
 }
 
+// EntryFormatter is implemented by formatters that can render a complete
+// Entry, including its original timestamp. Loggers and sinks prefer it over
+// Format when available.
+type EntryFormatter interface {
+	FormatEntry(entry *Entry) string
+}
+
+// formatEntry renders an entry with f, preserving the entry's timestamp
+// when f supports it.
+func formatEntry(f Formatter, entry *Entry) string {
+	if ef, ok := f.(EntryFormatter); ok {
+		return ef.FormatEntry(entry)
+	}
+	return f.Format(entry.Level, entry.Message, entry.Fields)
+}
+
+// withoutTimestamp returns a copy of a built-in formatter with its
+// timestamp disabled, or f itself for other formatters.
+func withoutTimestamp(f Formatter) Formatter {
+	switch ff := f.(type) {
+	case *TextFormatter:
+		clone := *ff
+		clone.DisableTimestamp = true
+		return &clone
+	case *JSONFormatter:
+		clone := *ff
+		clone.DisableTimestamp = true
+		return &clone
+	case *LogfmtFormatter:
+		clone := *ff
+		clone.DisableTimestamp = true
+		return &clone
+	}
+	return f
+}
+
+// CoreKeysFirst is a KeyOrder that places the built-in JSON keys ahead of
+// user fields.
+var CoreKeysFirst = []string{"timestamp", "level", "message"}
+
 // TextFormatter formats logs in plain text.
-type TextFormatter struct{}
+type TextFormatter struct {
+	// KeyOrder lists field keys to emit first, in the given order. Remaining
+	// keys always follow in lexical order, so output is reproducible.
+	KeyOrder []string
+
+	// MultiLine renders each field on its own line below the message, with
+	// nested groups indented one level further.
+	MultiLine bool
+
+	// Indent is the indentation used for field lines in MultiLine mode.
+	// Defaults to two spaces.
+	Indent string
+
+	// ContinuationPrefix is written at the start of every continuation line
+	// of a multi-line message or field value, such as a stack trace.
+	ContinuationPrefix string
+
+	// DisableTimestamp omits the timestamp, for environments such as
+	// systemd or Docker that add their own.
+	DisableTimestamp bool
+
+	// Theme styles the level name with colors and symbols, for console
+	// output. Nil leaves it plain.
+	Theme Theme
+
+	// LegacyFields renders fields as Go's "map[key:value]" dump, the
+	// format used before key=value pairs, for parsers that depend on it.
+	LegacyFields bool
+
+	// TimestampFormat is the time.Format layout of the timestamp, e.g.
+	// "Mon 02 Jan 03:04:05 PM" for a 12-hour clock; it may also contain
+	// {isoweek}, {isoyear} and {weekday}. Defaults to the Locale's layout,
+	// then "2006-01-02 15:04:05".
+	TimestampFormat string
+
+	// Locale localizes month and day names and 12-hour clock markers in
+	// the timestamp, for operational logs read in regional conventions.
+	Locale *Locale
+}
+
+// timestamp formats t with the formatter's layout and locale.
+func (f *TextFormatter) timestamp(t time.Time) string {
+	layout := f.TimestampFormat
+	if layout == "" && f.Locale != nil {
+		layout = f.Locale.Layout
+	}
+	if layout == "" {
+		layout = "2006-01-02 15:04:05"
+	}
+	return formatTime(t, layout, f.Locale)
+}
 
 // Format implements text formatting.
 func (f *TextFormatter) Format(level LogLevel, msg string, fields map[string]interface{}) string {
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	base := fmt.Sprintf("[%s] %s %s", timestamp, level.String(), msg)
+	return f.FormatEntry(&Entry{Time: time.Now(), Level: level, Message: msg, Fields: fields})
+}
+
+// FormatEntry implements EntryFormatter.
+func (f *TextFormatter) FormatEntry(entry *Entry) string {
+	level, msg, fields := entry.Level, entry.Message, entry.Fields
+	var sb strings.Builder
+	if !f.DisableTimestamp {
+		fmt.Fprintf(&sb, "[%s] ", f.timestamp(entry.Time))
+	}
+	if !f.LegacyFields && !f.MultiLine && (strings.Contains(msg, "=") || strings.HasPrefix(msg, `"`)) {
+		// A quoted message cannot be mistaken for the key=value fields.
+		msg = strconv.Quote(msg)
+	}
+	msg = escapeControl(msg)
+	levelName := level.String()
+	if f.Theme != nil {
+		levelName = f.Theme.render(level)
+	}
+	fmt.Fprintf(&sb, "%s %s", levelName, f.continueLines(msg, ""))
 	if len(fields) == 0 {
-		return base + "\n"
+		sb.WriteByte('\n')
+		return sb.String()
+	}
+
+	normalized := normalizeFields(fields)
+	if f.MultiLine {
+		sb.WriteByte('\n')
+		f.writeFieldLines(&sb, normalized, f.indent())
+		return sb.String()
+	}
+
+	if f.LegacyFields {
+		sb.WriteString(" map[")
+		for i, k := range orderedKeys(normalized, f.KeyOrder) {
+			if i > 0 {
+				sb.WriteByte(' ')
+			}
+			fmt.Fprintf(&sb, "%s:%s", escapeControl(k), f.continueLines(escapeControl(fmt.Sprint(normalized[k])), ""))
+		}
+		sb.WriteString("]\n")
+		return sb.String()
+	}
+
+	f.writePairs(&sb, normalized, "", f.KeyOrder)
+	sb.WriteByte('\n')
+	return sb.String()
+}
+
+// writePairs writes the fields as key=value pairs, quoting and escaping
+// values that contain spaces, quotes or newlines. Nested groups are
+// flattened into dotted keys ("user.id=7").
+func (f *TextFormatter) writePairs(sb *strings.Builder, fields map[string]interface{}, prefix string, keyOrder []string) {
+	for _, k := range orderedKeys(fields, keyOrder) {
+		if nested, ok := fields[k].(map[string]interface{}); ok && len(nested) > 0 {
+			f.writePairs(sb, nested, prefix+k+".", nil)
+			continue
+		}
+		sb.WriteByte(' ')
+		sb.WriteString(logfmtKey(prefix + k))
+		sb.WriteByte('=')
+		sb.WriteString(logfmtValue(fields[k]))
+	}
+}
+
+// writeFieldLines writes one "key: value" line per field, recursing into
+// nested groups with a deeper indentation.
+func (f *TextFormatter) writeFieldLines(sb *strings.Builder, fields map[string]interface{}, indent string) {
+	for _, k := range orderedKeys(fields, f.KeyOrder) {
+		if nested, ok := fields[k].(map[string]interface{}); ok && len(nested) > 0 {
+			fmt.Fprintf(sb, "%s%s:\n", indent, escapeControl(k))
+			f.writeFieldLines(sb, nested, indent+f.indent())
+			continue
+		}
+		fmt.Fprintf(sb, "%s%s: %s\n", indent, escapeControl(k), f.continueLines(escapeControl(fmt.Sprint(fields[k])), indent+f.indent()))
+	}
+}
+
+// continueLines prefixes every line after the first with indent followed by
+// the continuation prefix.
+func (f *TextFormatter) continueLines(s, indent string) string {
+	if !strings.Contains(s, "\n") || (indent == "" && f.ContinuationPrefix == "") {
+		return s
+	}
+	return strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\n"+indent+f.ContinuationPrefix)
+}
+
+// indent returns the configured MultiLine indentation.
+func (f *TextFormatter) indent() string {
+	if f.Indent == "" {
+		return "  "
 	}
-	return fmt.Sprintf("%s %v\n", base, fields)
+	return f.Indent
 }
 
 // JSONFormatter formats logs in JSON.
-type JSONFormatter struct{}
+type JSONFormatter struct {
+	// KeyOrder lists keys, including the built-in "timestamp", "level" and
+	// "message", to emit first in the given order. Remaining keys always
+	// follow in lexical order, so output is reproducible.
+	KeyOrder []string
+
+	// DisableTimestamp omits the "timestamp" key.
+	DisableTimestamp bool
+
+	// Collisions selects how user fields named "timestamp", "level" or
+	// "message" are handled. By default they replace the built-in keys.
+	Collisions CollisionPolicy
+
+	// CollisionKeyPrefix is prepended to colliding user fields with the
+	// CollisionPrefix policy. Defaults to "fields.".
+	CollisionKeyPrefix string
+}
+
+// CollisionPolicy selects how JSONFormatter handles user fields that
+// collide with its built-in keys.
+type CollisionPolicy int
+
+const (
+	// CollisionOverwrite lets user fields replace the built-in keys.
+	CollisionOverwrite CollisionPolicy = iota
+	// CollisionPrefix renames colliding user fields with CollisionKeyPrefix.
+	CollisionPrefix
+	// CollisionNest nests every user field under a "fields" object.
+	CollisionNest
+	// CollisionStrict drops colliding user fields and names them in an
+	// "!ERROR" key, so the mistake is visible without losing the entry.
+	CollisionStrict
+)
+
+// jsonCoreKeys are the keys written by JSONFormatter itself.
+var jsonCoreKeys = map[string]bool{"timestamp": true, "level": true, "message": true}
 
 // Format implements JSON formatting.
 func (f *JSONFormatter) Format(level LogLevel, msg string, fields map[string]interface{}) string {
+	return f.FormatEntry(&Entry{Time: time.Now(), Level: level, Message: msg, Fields: fields})
+}
+
+// FormatEntry implements EntryFormatter.
+func (f *JSONFormatter) FormatEntry(entry *Entry) string {
 	logEntry := map[string]interface{}{
-		"timestamp": time.Now().Format(time.RFC3339),
-		"level":     level.String(),
-		"message":   msg,
+		"level":   entry.Level.String(),
+		"message": entry.Message,
+	}
+	if !f.DisableTimestamp {
+		logEntry["timestamp"] = entry.Time.Format(time.RFC3339)
 	}
-	for k, v := range fields {
-		logEntry[k] = v
+	fields := normalizeFields(entry.Fields)
+	switch f.Collisions {
+	case CollisionNest:
+		if len(fields) > 0 {
+			logEntry["fields"] = fields
+		}
+	case CollisionPrefix, CollisionStrict:
+		var collided []string
+		for k, v := range fields {
+			if !jsonCoreKeys[k] {
+				logEntry[k] = v
+				continue
+			}
+			if f.Collisions == CollisionStrict {
+				collided = append(collided, k)
+				continue
+			}
+			prefix := f.CollisionKeyPrefix
+			if prefix == "" {
+				prefix = "fields."
+			}
+			logEntry[prefix+k] = v
+		}
+		if len(collided) > 0 {
+			sort.Strings(collided)
+			logEntry["!ERROR"] = "reserved field names: " + strings.Join(collided, ", ")
+		}
+	default:
+		for k, v := range fields {
+			logEntry[k] = v
+		}
 	}
-	data, _ := json.Marshal(logEntry)
+	data, _ := marshalOrdered(logEntry, f.KeyOrder)
 	return string(data) + "\n"
 }
+
+// LogfmtFormatter formats logs as logfmt key=value pairs, starting with
+// time, level and msg.
+type LogfmtFormatter struct {
+	// KeyOrder lists field keys to emit first, in the given order. Remaining
+	// keys always follow in lexical order, so output is reproducible.
+	KeyOrder []string
+
+	// DisableTimestamp omits the "time" key.
+	DisableTimestamp bool
+}
+
+// Format implements logfmt formatting.
+func (f *LogfmtFormatter) Format(level LogLevel, msg string, fields map[string]interface{}) string {
+	return f.FormatEntry(&Entry{Time: time.Now(), Level: level, Message: msg, Fields: fields})
+}
+
+// FormatEntry implements EntryFormatter.
+func (f *LogfmtFormatter) FormatEntry(entry *Entry) string {
+	var sb strings.Builder
+	if !f.DisableTimestamp {
+		sb.WriteString("time=")
+		sb.WriteString(entry.Time.Format(time.RFC3339))
+		sb.WriteByte(' ')
+	}
+	sb.WriteString("level=")
+	sb.WriteString(strings.ToLower(entry.Level.String()))
+	sb.WriteString(" msg=")
+	sb.WriteString(logfmtValue(entry.Message))
+
+	normalized := normalizeFields(entry.Fields)
+	for _, k := range orderedKeys(normalized, f.KeyOrder) {
+		sb.WriteByte(' ')
+		sb.WriteString(logfmtKey(k))
+		sb.WriteByte('=')
+		sb.WriteString(logfmtValue(normalized[k]))
+	}
+	sb.WriteByte('\n')
+	return sb.String()
+}
+
+// logfmtKey replaces characters that would break key=value parsing.
+func logfmtKey(k string) string {
+	if k == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || isControl(r) {
+			return '_'
+		}
+		return r
+	}, k)
+}
+
+// logfmtValue renders a normalized value, quoting it when needed. Nested
+// maps and lists are rendered as JSON.
+func logfmtValue(v interface{}) string {
+	var s string
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		s = val
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(val)
+		if err != nil {
+			s = fmt.Sprint(val)
+		} else {
+			s = string(data)
+		}
+	default:
+		s = fmt.Sprint(val)
+	}
+	if needsQuoting(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// escapeControl escapes invalid UTF-8 and control characters other than
+// newlines and tabs as \xNN or \uNNNN, so unquoted text output cannot
+// carry terminal escape sequences or corrupt the encoding of a log.
+func escapeControl(s string) string {
+	if !needsEscape(s) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&sb, `\x%02x`, s[i])
+		case isControl(r):
+			if r < utf8.RuneSelf {
+				fmt.Fprintf(&sb, `\x%02x`, r)
+			} else {
+				fmt.Fprintf(&sb, `\u%04x`, r)
+			}
+		default:
+			sb.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return sb.String()
+}
+
+// needsEscape reports whether escapeControl would change s.
+func needsEscape(s string) bool {
+	if !utf8.ValidString(s) {
+		return true
+	}
+	for _, r := range s {
+		if isControl(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// isControl reports whether r is a C0 or C1 control character other than
+// a newline or tab.
+func isControl(r rune) bool {
+	return (r < ' ' && r != '\n' && r != '\t') || (r >= 0x7f && r <= 0x9f)
+}
+
+// needsQuoting reports whether a logfmt value must be quoted.
+func needsQuoting(s string) bool {
+	if s == "" || !utf8.ValidString(s) {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// orderedKeys returns the keys of m with the keys listed in priority first,
+// followed by the rest in lexical order. Go maps carry no insertion order,
+// so an explicit priority list is the only stable alternative to sorting.
+func orderedKeys(m map[string]interface{}, priority []string) []string {
+	keys := make([]string, 0, len(m))
+	seen := make(map[string]bool, len(priority))
+	for _, k := range priority {
+		if _, ok := m[k]; ok && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+	rest := make([]string, 0, len(m)-len(keys))
+	for k := range m {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// marshalOrdered encodes m as a JSON object whose top-level keys follow
+// orderedKeys. Nested objects use encoding/json's sorted order.
+func marshalOrdered(m map[string]interface{}, priority []string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range orderedKeys(m, priority) {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m[k])
+		if err != nil {
+			// One unencodable value must not cost the whole entry.
+			reportCoercion("field %s: %v", k, err)
+			value, _ = json.Marshal("!ERROR: " + err.Error())
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return escapeJSONControl(buf.Bytes()), nil
+}
+
+// escapeJSONControl escapes DEL and C1 control characters, which
+// encoding/json leaves raw although terminals and some line-oriented tools
+// treat them as escapes or line breaks. Valid JSON can hold them only
+// inside strings, so they are replaced wherever they occur.
+func escapeJSONControl(data []byte) []byte {
+	i := 0
+	for i < len(data) && data[i] != 0x7f && !(data[i] == 0xc2 && i+1 < len(data) && data[i+1] <= 0x9f) {
+		i++
+	}
+	if i == len(data) {
+		return data
+	}
+	escaped := append([]byte(nil), data[:i]...)
+	for ; i < len(data); i++ {
+		switch {
+		case data[i] == 0x7f:
+			escaped = append(escaped, `\u007f`...)
+		case data[i] == 0xc2 && i+1 < len(data) && data[i+1] <= 0x9f:
+			escaped = fmt.Appendf(escaped, `\u%04x`, data[i+1])
+			i++
+		default:
+			escaped = append(escaped, data[i])
+		}
+	}
+	return escaped
+}
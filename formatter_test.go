@@ -0,0 +1,345 @@
+package golog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testUser struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	token string
+}
+
+type testMarshaler struct{ id int }
+
+func (m testMarshaler) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddField("id", m.id)
+	enc.AddField("tags", Array("a", "b"))
+	return nil
+}
+
+func TestJSONFormatterNestedValues(t *testing.T) {
+	f := &JSONFormatter{}
+	out := f.Format(INFO, "nested", map[string]interface{}{
+		"dict":   Dict("a", 1, "b", Dict("c", true)),
+		"list":   []int{1, 2, 3},
+		"user":   testUser{ID: 7, Name: "bob", token: "secret"},
+		"object": testMarshaler{id: 42},
+		"err":    errors.New("boom"),
+	})
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("Failed to decode JSON output: %v", err)
+	}
+
+	checks := []string{
+		`"dict":{"a":1,"b":{"c":true}}`,
+		`"list":[1,2,3]`,
+		`"user":{"id":7,"name":"bob"}`,
+		`"object":{"id":42,"tags":["a","b"]}`,
+		`"err":"boom"`,
+	}
+	for _, c := range checks {
+		if !strings.Contains(out, c) {
+			t.Errorf("JSON output %q does not contain %q", out, c)
+		}
+	}
+	if strings.Contains(out, "secret") {
+		t.Errorf("JSON output leaked unexported struct field: %q", out)
+	}
+}
+
+func TestTextFormatterNestedValues(t *testing.T) {
+	f := &TextFormatter{}
+	out := f.Format(INFO, "nested", map[string]interface{}{
+		"user":   testUser{ID: 7, Name: "bob"},
+		"object": testMarshaler{id: 42},
+	})
+
+	if !strings.Contains(out, `object.id=42 object.tags="[\"a\",\"b\"]"`) {
+		t.Errorf("Text output does not render LogObjectMarshaler: %q", out)
+	}
+	if !strings.Contains(out, "user.id=7 user.name=bob") {
+		t.Errorf("Text output does not render struct fields: %q", out)
+	}
+}
+
+func TestDictOddArguments(t *testing.T) {
+	d := Dict("a", 1, "dangling")
+	if d["a"] != 1 || d["!BADKEY"] != "dangling" {
+		t.Errorf("Unexpected Dict result: %v", d)
+	}
+}
+
+func TestFormatterKeyOrder(t *testing.T) {
+	fields := map[string]interface{}{"b": 2, "a": 1, "c": 3}
+
+	text := (&TextFormatter{KeyOrder: []string{"c"}}).Format(INFO, "ordered", fields)
+	if !strings.HasSuffix(text, "INFO ordered c=3 a=1 b=2\n") {
+		t.Errorf("Unexpected text key order: %q", text)
+	}
+
+	for i := 0; i < 20; i++ {
+		out := (&JSONFormatter{KeyOrder: CoreKeysFirst}).Format(INFO, "ordered", fields)
+		if !strings.HasPrefix(out, `{"timestamp":`) || !strings.HasSuffix(out, `"level":"INFO","message":"ordered","a":1,"b":2,"c":3}`+"\n") {
+			t.Fatalf("Unexpected JSON key order: %q", out)
+		}
+	}
+
+	sorted := (&JSONFormatter{}).Format(INFO, "ordered", fields)
+	if !strings.HasPrefix(sorted, `{"a":1,"b":2,"c":3,"level":"INFO","message":"ordered","timestamp":`) {
+		t.Errorf("Unexpected default JSON key order: %q", sorted)
+	}
+}
+
+func TestTextFormatterMultiLine(t *testing.T) {
+	f := &TextFormatter{MultiLine: true, ContinuationPrefix: "| "}
+	out := f.Format(ERROR, "query failed\nretrying", map[string]interface{}{
+		"db":    "users",
+		"stack": "main.main()\n\tmain.go:10",
+		"user":  Dict("id", 7),
+	})
+
+	expected := " ERROR query failed\n| retrying\n" +
+		"  db: users\n" +
+		"  stack: main.main()\n    | \tmain.go:10\n" +
+		"  user:\n" +
+		"    id: 7\n"
+	if !strings.HasSuffix(out, expected) {
+		t.Errorf("Unexpected multi-line output:\n%s", out)
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	fields := map[string]interface{}{"user": "alice", "ip": "10.0.0.1", "user_info": Dict("id", 7)}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"user {user} logged in from {ip}", "user alice logged in from 10.0.0.1"},
+		{"details {user_info}", "details map[id:7]"},
+		{"unknown {missing} stays", "unknown {missing} stays"},
+		{"literal {{braces}}", "literal {braces}"},
+		{"unterminated {user", "unterminated {user"},
+	}
+	for _, tt := range tests {
+		if got, _ := renderTemplate(tt.template, fields); got != tt.expected {
+			t.Errorf("renderTemplate(%q) = %q, expected %q", tt.template, got, tt.expected)
+		}
+	}
+}
+
+func TestLogfmtFormatter(t *testing.T) {
+	f := &LogfmtFormatter{DisableTimestamp: true}
+	out := f.Format(WARN, "disk almost full", map[string]interface{}{
+		"path":  "/var/log",
+		"note":  `say "hi"`,
+		"usage": 0.93,
+		"tags":  Array("a", "b"),
+		"empty": "",
+	})
+
+	expected := `level=warn msg="disk almost full" empty="" note="say \"hi\"" path=/var/log tags="[\"a\",\"b\"]" usage=0.93` + "\n"
+	if out != expected {
+		t.Errorf("Unexpected logfmt output:\n got: %q\nwant: %q", out, expected)
+	}
+}
+
+func TestTextFormatterQuoting(t *testing.T) {
+	f := &TextFormatter{DisableTimestamp: true}
+	out := f.Format(INFO, "a=b looks like a field", map[string]interface{}{
+		"note":  "two words",
+		"quote": `say "hi"`,
+		"lines": "one\ntwo",
+	})
+	expected := `INFO "a=b looks like a field" lines="one\ntwo" note="two words" quote="say \"hi\""` + "\n"
+	if out != expected {
+		t.Errorf("Unexpected text output:\n got: %q\nwant: %q", out, expected)
+	}
+
+	legacy := (&TextFormatter{DisableTimestamp: true, LegacyFields: true}).Format(INFO, "hello", map[string]interface{}{"user": "alice"})
+	if legacy != "INFO hello map[user:alice]\n" {
+		t.Errorf("Unexpected legacy output: %q", legacy)
+	}
+}
+
+func TestJSONFormatterCollisions(t *testing.T) {
+	fields := map[string]interface{}{"level": "user-level", "message": "user-message", "id": 1}
+	tests := []struct {
+		formatter *JSONFormatter
+		expected  string
+	}{
+		{&JSONFormatter{DisableTimestamp: true}, `{"id":1,"level":"user-level","message":"user-message"}`},
+		{&JSONFormatter{DisableTimestamp: true, Collisions: CollisionPrefix}, `{"fields.level":"user-level","fields.message":"user-message","id":1,"level":"INFO","message":"core"}`},
+		{&JSONFormatter{DisableTimestamp: true, Collisions: CollisionPrefix, CollisionKeyPrefix: "user_"}, `{"id":1,"level":"INFO","message":"core","user_level":"user-level","user_message":"user-message"}`},
+		{&JSONFormatter{DisableTimestamp: true, Collisions: CollisionNest}, `{"fields":{"id":1,"level":"user-level","message":"user-message"},"level":"INFO","message":"core"}`},
+		{&JSONFormatter{DisableTimestamp: true, Collisions: CollisionStrict}, `{"!ERROR":"reserved field names: level, message","id":1,"level":"INFO","message":"core"}`},
+	}
+	for _, tt := range tests {
+		if out := tt.formatter.Format(INFO, "core", fields); out != tt.expected+"\n" {
+			t.Errorf("Collisions %d: got %s, expected %s", tt.formatter.Collisions, out, tt.expected)
+		}
+	}
+}
+
+type testNode struct {
+	Name     string    `log:"name"`
+	Password string    `log:"-" json:"password"`
+	Note     string    `json:"note,omitempty"`
+	Next     *testNode `log:"next"`
+}
+
+type panicMarshaler struct{}
+
+func (panicMarshaler) MarshalLogObject(enc ObjectEncoder) error { panic("broken") }
+
+func TestReflectionEncoderLimits(t *testing.T) {
+	loop := &testNode{Name: "a", Password: "secret"}
+	loop.Next = &testNode{Name: "b", Next: loop}
+	got := normalizeValue(loop).(map[string]interface{})
+	next := got["next"].(map[string]interface{})
+	if got["name"] != "a" || next["name"] != "b" || next["next"] != "!CYCLE" {
+		t.Errorf("Unexpected cycle encoding: %v", got)
+	}
+	if _, ok := got["password"]; ok {
+		t.Errorf("log:\"-\" field was encoded: %v", got)
+	}
+	if _, ok := got["note"]; ok {
+		t.Errorf("omitempty field was encoded: %v", got)
+	}
+
+	previous := MaxFieldElements
+	MaxFieldElements = 2
+	defer func() { MaxFieldElements = previous }()
+	list := normalizeValue([]int{1, 2, 3, 4}).([]interface{})
+	if len(list) != 3 || list[2] != "!TRUNCATED: 2 more" {
+		t.Errorf("Unexpected truncated list: %v", list)
+	}
+	m := normalizeValue(map[string]int{"a": 1, "b": 2, "c": 3}).(map[string]interface{})
+	if m["a"] != 1 || m["b"] != 2 || m["!TRUNCATED"] != 1 {
+		t.Errorf("Unexpected truncated map: %v", m)
+	}
+
+	var deep interface{} = "leaf"
+	for i := 0; i < MaxFieldDepth+5; i++ {
+		deep = []interface{}{deep}
+	}
+	if !strings.Contains(fmt.Sprint(normalizeValue(deep)), "!MAXDEPTH") {
+		t.Errorf("Expected depth limit marker")
+	}
+
+	if v := normalizeValue(panicMarshaler{}); v != "!PANIC: broken" {
+		t.Errorf("Unexpected panicking marshaler value: %v", v)
+	}
+}
+
+func TestTheme(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	theme, err := ParseTheme([]byte(`{"warn": {"color": "yellow", "symbol": "⚠", "bold": true}, "error": {"color": "38;5;196", "underline": true}}`))
+	if err != nil {
+		t.Fatalf("Failed to parse theme: %v", err)
+	}
+	f := &TextFormatter{DisableTimestamp: true, Theme: theme}
+
+	if out := f.Format(WARN, "disk", nil); out != "⚠ \x1b[33;1mWARN\x1b[0m disk\n" {
+		t.Errorf("Unexpected WARN output: %q", out)
+	}
+	if out := f.Format(ERROR, "boom", nil); out != "\x1b[38;5;196;4mERROR\x1b[0m boom\n" {
+		t.Errorf("Unexpected ERROR output: %q", out)
+	}
+	if out := f.Format(INFO, "plain", nil); out != "INFO plain\n" {
+		t.Errorf("Unexpected unstyled output: %q", out)
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if out := f.Format(WARN, "disk", nil); out != "⚠ WARN disk\n" {
+		t.Errorf("Unexpected NO_COLOR output: %q", out)
+	}
+
+	if escape := DefaultTheme.Escape(FATAL); escape != "\x1b[35;1m" {
+		t.Errorf("Unexpected FATAL escape: %q", escape)
+	}
+
+	if _, err := ParseTheme([]byte(`{"warn": {"color": "chartreuse"}}`)); err == nil {
+		t.Errorf("Expected error for unknown color")
+	}
+	if _, err := ParseTheme([]byte(`{"loud": {}}`)); err == nil {
+		t.Errorf("Expected error for unknown level")
+	}
+}
+
+func TestTextFormatterLocale(t *testing.T) {
+	at := time.Date(2025, 3, 4, 15, 6, 7, 0, time.UTC) // a Tuesday in ISO week 10
+	tests := []struct {
+		formatter *TextFormatter
+		want      string
+	}{
+		{&TextFormatter{}, "[2025-03-04 15:06:07] "},
+		{&TextFormatter{Locale: LocaleEnUS}, "[03/04/2025 03:06:07 PM] "},
+		{&TextFormatter{Locale: LocaleDE, TimestampFormat: "Monday, 2. January 2006 15:04"}, "[Dienstag, 4. März 2025 15:06] "},
+		{&TextFormatter{Locale: LocaleFR, TimestampFormat: "Mon 2 Jan"}, "[mar. 4 mars] "},
+		{&TextFormatter{Locale: LocaleJA, TimestampFormat: "January2日(Mon) PM3:04"}, "[3月4日(火) 午後3:06] "},
+		{&TextFormatter{TimestampFormat: "{isoyear}-W{isoweek}-{weekday} 15:04"}, "[2025-W10-2 15:06] "},
+	}
+	for _, tt := range tests {
+		got := tt.formatter.FormatEntry(&Entry{Time: at, Level: INFO, Message: "m"})
+		if !strings.HasPrefix(got, tt.want) {
+			t.Errorf("Expected prefix %q, got %q", tt.want, got)
+		}
+	}
+}
+
+func TestNonFiniteAndNilPolicies(t *testing.T) {
+	defer func(floats NonFinitePolicy, nils NilPolicy) {
+		NonFiniteFloats, NilValues, OnCoercion = floats, nils, nil
+	}(NonFiniteFloats, NilValues)
+	var coerced []string
+	OnCoercion = func(err error) { coerced = append(coerced, err.Error()) }
+
+	var nilMap map[string]int
+	fields := map[string]interface{}{
+		"nan":    math.NaN(),
+		"inf":    []float32{1.5, float32(math.Inf(-1))},
+		"nil":    nilMap,
+		"nested": map[string]interface{}{"gone": nil, "kept": 1},
+	}
+	f := &JSONFormatter{}
+	tests := []struct {
+		floats NonFinitePolicy
+		nils   NilPolicy
+		want   string
+	}{
+		{NonFiniteString, NilNull, `"inf":[1.5,"-Inf"],"level":"INFO","message":"m","nan":"NaN","nested":{"gone":null,"kept":1},"nil":null`},
+		{NonFiniteNull, NilNull, `"inf":[1.5,null],"level":"INFO","message":"m","nan":null,`},
+		{NonFiniteZero, NilOmit, `"inf":[1.5,0],"level":"INFO","message":"m","nan":0,"nested":{"kept":1},"timestamp"`},
+	}
+	for _, tt := range tests {
+		NonFiniteFloats, NilValues = tt.floats, tt.nils
+		coerced = nil
+		out := f.FormatEntry(&Entry{Level: INFO, Message: "m", Fields: fields})
+		if !strings.Contains(out, tt.want) {
+			t.Errorf("Expected %s in %s", tt.want, out)
+		}
+		if len(coerced) != 2 {
+			t.Errorf("Expected 2 coercion reports, got %q", coerced)
+		}
+	}
+
+	// Values JSON rejects outright are replaced rather than losing the entry.
+	coerced = nil
+	data, err := marshalOrdered(map[string]interface{}{"ch": make(chan int), "message": "m"}, nil)
+	if err != nil || string(data) != `{"ch":"!ERROR: json: unsupported type: chan int","message":"m"}` {
+		t.Errorf("Unexpected encoding: %s, %v", data, err)
+	}
+	if len(coerced) != 1 || !strings.HasPrefix(coerced[0], "field ch: ") {
+		t.Errorf("Unexpected coercion reports: %q", coerced)
+	}
+}
@@ -0,0 +1,234 @@
+// Package formattest provides a corpus of hostile entries, conformance
+// checks and benchmarks for golog formatters, so the built-in formatters and
+// custom ones can be shown to survive whatever ends up in a log: invalid
+// UTF-8, control characters, huge fields, deep nesting and cycles.
+//
+//	func TestMyFormatter(t *testing.T) {
+//		formattest.Conformance(t, &MyFormatter{}, formattest.Options{})
+//	}
+//
+//	func BenchmarkMyFormatter(b *testing.B) {
+//		formattest.Benchmark(b, &MyFormatter{})
+//	}
+package formattest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/samiullahsaleem/golog"
+)
+
+// Options relaxes the checks for formatters that produce styled or
+// multi-line output by design.
+type Options struct {
+	MultiLine bool // Entries may continue on following lines
+	ANSI      bool // Output may contain ANSI escape sequences, as themes add
+}
+
+// Case is a named entry of the corpus.
+type Case struct {
+	Name  string
+	Entry *golog.Entry
+}
+
+// plainMessage and plainValue must appear in the output of the "plain"
+// case, whatever the layout.
+const (
+	plainMessage = "hello conformance"
+	plainValue   = "alice"
+)
+
+// at is the timestamp of the corpus entries.
+var at = time.Date(2025, 3, 4, 15, 6, 7, 123456789, time.UTC)
+
+// Cases returns the corpus: a plain entry and entries carrying invalid
+// UTF-8, control characters, line breaks, quoting and separator characters,
+// a 1 MB message and field, thousands of fields, deeply nested and cyclic
+// values, non-finite floats, nil values and types with no natural encoding.
+// Every call returns fresh entries.
+func Cases() []Case {
+	huge := strings.Repeat("x", 1<<20)
+
+	many := make(map[string]interface{}, 5000)
+	for i := 0; i < 5000; i++ {
+		many[fmt.Sprintf("field_%04d", i)] = i
+	}
+
+	deepMap := map[string]interface{}{"leaf": true}
+	var deepSlice interface{} = "leaf"
+	for i := 0; i < 200; i++ {
+		deepMap = map[string]interface{}{"d": deepMap}
+		deepSlice = []interface{}{deepSlice}
+	}
+
+	cyclic := map[string]interface{}{"name": "loop"}
+	cyclic["self"] = cyclic
+	type node struct {
+		Name string
+		Next *node
+	}
+	ring := &node{Name: "ring"}
+	ring.Next = ring
+
+	var nilPointer *node
+	var nilMap map[string]int
+	var nilError error
+
+	entry := func(level golog.LogLevel, msg string, fields map[string]interface{}) *golog.Entry {
+		return &golog.Entry{Time: at, Level: level, Message: msg, Fields: fields}
+	}
+	return []Case{
+		{"plain", entry(golog.INFO, plainMessage, map[string]interface{}{"user": plainValue, "count": 42})},
+		{"empty", entry(golog.DEBUG, "", nil)},
+		{"zero time", &golog.Entry{Level: golog.TRACE, Message: "no time", Fields: map[string]interface{}{}}},
+		{"invalid utf-8", entry(golog.WARN, "bad \xff\xfe bytes \xc3\x28", map[string]interface{}{"k\xff": "\xc3\x28", "bytes": []byte("\xff\x00")})},
+		{"control characters", entry(golog.ERROR, "nul\x00 bell\x07 esc\x1b[31mred\x1b[0m del\x7f c1\u0085 cr\rover", map[string]interface{}{"a\x00b": "\x1b]0;title\x07", "tab": "a\tb"})},
+		{"line breaks", entry(golog.ERROR, "line one\nline two\r\n", map[string]interface{}{"stack": "main.main()\n\tmain.go:10\n", "key\nbreak": "v"})},
+		{"quotes and separators", entry(golog.INFO, `say "hi" key=value \ back`, map[string]interface{}{`k=v "x"`: `a b=c "d" \e`, "  ": "", "": "empty key"})},
+		{"unicode", entry(golog.INFO, "emoji 🚀👩‍👩‍👧 rtl ‮evil‬ עברית combining é wide 日本語", map[string]interface{}{"名前": "値", "zwj": "👨‍💻"})},
+		{"huge message", entry(golog.INFO, huge, nil)},
+		{"huge field", entry(golog.INFO, "huge field", map[string]interface{}{"blob": huge, "list": make([]int, 100000)})},
+		{"many fields", entry(golog.INFO, "many fields", many)},
+		{"deep nesting", entry(golog.WARN, "deep nesting", map[string]interface{}{"map": deepMap, "slice": deepSlice})},
+		{"cycles", entry(golog.WARN, "cycles", map[string]interface{}{"map": cyclic, "struct": ring})},
+		{"special floats", entry(golog.INFO, "special floats", map[string]interface{}{"nan": math.NaN(), "inf": math.Inf(1), "ninf": float32(math.Inf(-1)), "max": math.MaxFloat64, "tiny": math.SmallestNonzeroFloat64})},
+		{"nil values", entry(golog.INFO, "nil values", map[string]interface{}{"pointer": nilPointer, "map": nilMap, "error": nilError, "nil": nil})},
+		{"odd types", entry(golog.INFO, "odd types", map[string]interface{}{
+			"chan": make(chan int), "func": func() {}, "complex": complex(1, -2),
+			"error": errors.New("boom\nsecond line"), "duration": 1500 * time.Millisecond,
+			"time": at, "struct": struct{ Exported, unexported int }{1, 2}, "uint64": uint64(math.MaxUint64),
+		})},
+	}
+}
+
+// Check formats entry with f and returns an error describing the first
+// contract violation: a panic, output without a final newline or with
+// invalid UTF-8, control characters (other than tabs, and newlines with
+// Options.MultiLine), or output that differs when the same entry is
+// formatted again by an EntryFormatter.
+func Check(f golog.Formatter, entry *golog.Entry, opts Options) error {
+	out, err := format(f, entry)
+	if err != nil {
+		return err
+	}
+	if err := checkOutput(out, opts); err != nil {
+		return err
+	}
+	if _, ok := f.(golog.EntryFormatter); ok {
+		again, err := format(f, entry)
+		if err != nil {
+			return err
+		}
+		if again != out {
+			return fmt.Errorf("formatting the same entry twice gave different output:\n%s\n%s", abbreviate(out), abbreviate(again))
+		}
+	}
+	return nil
+}
+
+// format renders entry, turning a panic into an error.
+func format(f golog.Formatter, entry *golog.Entry) (out string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("formatter panicked: %v", r)
+		}
+	}()
+	if ef, ok := f.(golog.EntryFormatter); ok {
+		return ef.FormatEntry(entry), nil
+	}
+	return f.Format(entry.Level, entry.Message, entry.Fields), nil
+}
+
+// checkOutput checks the encoding and line structure of formatted output.
+func checkOutput(out string, opts Options) error {
+	if !strings.HasSuffix(out, "\n") {
+		return fmt.Errorf("output does not end with a newline: %s", abbreviate(out))
+	}
+	for i := 0; i < len(out); {
+		r, size := utf8.DecodeRuneInString(out[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			return fmt.Errorf("output has invalid UTF-8 at byte %d: %s", i, abbreviate(out))
+		case r == '\n':
+			if !opts.MultiLine && i != len(out)-1 {
+				return fmt.Errorf("output spans several lines: %s", abbreviate(out))
+			}
+		case r == 0x1b && opts.ANSI:
+		case r == '\t':
+		case r < ' ' || (r >= 0x7f && r <= 0x9f):
+			return fmt.Errorf("output has control character %U at byte %d: %s", r, i, abbreviate(out))
+		}
+		i += size
+	}
+	return nil
+}
+
+// abbreviate quotes s, shortened to keep failure messages readable.
+func abbreviate(s string) string {
+	const max = 300
+	if len(s) > max {
+		return fmt.Sprintf("%q... (%d bytes)", s[:max], len(s))
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+// Conformance runs Check on every case of the corpus, checks that the
+// plain case's message and field value survive formatting, and formats
+// the corpus from several goroutines at once, which the race detector
+// turns into a check that f is safe for concurrent use.
+//
+// A formatter that recurses into cyclic values without a bound overflows
+// the stack, which ends the test binary rather than failing the test.
+func Conformance(t *testing.T, f golog.Formatter, opts Options) {
+	t.Helper()
+	for _, c := range Cases() {
+		if err := Check(f, c.Entry, opts); err != nil {
+			t.Errorf("Case %q: %v", c.Name, err)
+		}
+	}
+
+	plain := Cases()[0].Entry
+	if out, err := format(f, plain); err == nil && (!strings.Contains(out, plainMessage) || !strings.Contains(out, plainValue)) {
+		t.Errorf("Expected the message %q and field value %q in %q", plainMessage, plainValue, out)
+	}
+
+	cases := Cases()
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, c := range cases {
+				format(f, c.Entry)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Benchmark reports the time and allocations f takes to format each case
+// of the corpus, as sub-benchmarks named after the cases.
+func Benchmark(b *testing.B, f golog.Formatter) {
+	for _, c := range Cases() {
+		entry := c.Entry
+		b.Run(strings.ReplaceAll(c.Name, " ", "_"), func(b *testing.B) {
+			out, err := format(f, entry)
+			if err != nil {
+				b.Fatalf("Failed to format: %v", err)
+			}
+			b.SetBytes(int64(len(out)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				format(f, entry)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package formattest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samiullahsaleem/golog"
+)
+
+// builtin is a built-in formatter configuration under test.
+type builtin struct {
+	name      string
+	formatter golog.Formatter
+	options   Options
+}
+
+func builtins() []builtin {
+	return []builtin{
+		{"text", &golog.TextFormatter{}, Options{MultiLine: true}},
+		{"text multi-line", &golog.TextFormatter{MultiLine: true, ContinuationPrefix: "| "}, Options{MultiLine: true}},
+		{"text legacy", &golog.TextFormatter{LegacyFields: true}, Options{MultiLine: true}},
+		{"text themed", &golog.TextFormatter{Theme: golog.DefaultTheme, Locale: golog.LocaleJA}, Options{MultiLine: true, ANSI: true}},
+		{"json", &golog.JSONFormatter{}, Options{}},
+		{"json ordered", &golog.JSONFormatter{KeyOrder: []string{"message", "level"}, Collisions: golog.CollisionPrefix}, Options{}},
+		{"logfmt", &golog.LogfmtFormatter{}, Options{}},
+	}
+}
+
+func TestBuiltinFormattersConform(t *testing.T) {
+	for _, b := range builtins() {
+		t.Run(b.name, func(t *testing.T) {
+			Conformance(t, b.formatter, b.options)
+		})
+	}
+}
+
+// panicky is a formatter that cannot handle empty messages.
+type panicky struct{}
+
+func (panicky) Format(level golog.LogLevel, msg string, fields map[string]interface{}) string {
+	return string(msg[0]) + "\n"
+}
+
+func TestCheckReportsViolations(t *testing.T) {
+	entry := &golog.Entry{Time: at, Level: golog.INFO, Message: "two\nlines \x1b[31m"}
+	tests := []struct {
+		formatter golog.Formatter
+		options   Options
+		want      string
+	}{
+		{&golog.TextFormatter{}, Options{}, "spans several lines"},
+		{&golog.TextFormatter{}, Options{MultiLine: true}, ""},
+		{&golog.TextFormatter{Theme: golog.DefaultTheme}, Options{MultiLine: true}, "control character U+001B"},
+		{panicky{}, Options{}, ""},
+	}
+	for _, tt := range tests {
+		err := Check(tt.formatter, entry, tt.options)
+		if tt.want == "" && err != nil {
+			t.Errorf("Unexpected error for %T: %v", tt.formatter, err)
+		}
+		if tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("Expected error containing %q for %T, got %v", tt.want, tt.formatter, err)
+		}
+	}
+	if err := Check(panicky{}, &golog.Entry{}, Options{}); err == nil || !strings.Contains(err.Error(), "panicked") {
+		t.Errorf("Expected the panic to be reported, got %v", err)
+	}
+}
+
+func FuzzBuiltinFormatters(f *testing.F) {
+	for _, c := range Cases() {
+		if len(c.Entry.Message) < 1024 {
+			f.Add(c.Entry.Message, "key", "value")
+		}
+	}
+	f.Add("msg", "k\xff=\x00", "\x1b[2J\xc0")
+	f.Fuzz(func(t *testing.T, msg, key, value string) {
+		entry := &golog.Entry{
+			Time:    time.Unix(0, 0),
+			Level:   golog.WARN,
+			Message: msg,
+			Fields: map[string]interface{}{
+				key:      value,
+				"nested": map[string]interface{}{key: []interface{}{value, []byte(value)}},
+			},
+		}
+		for _, b := range builtins() {
+			if err := Check(b.formatter, entry, b.options); err != nil {
+				t.Errorf("%s: %v", b.name, err)
+			}
+		}
+	})
+}
+
+func BenchmarkBuiltinFormatters(b *testing.B) {
+	for _, builtin := range builtins() {
+		b.Run(strings.ReplaceAll(builtin.name, " ", "_"), func(b *testing.B) {
+			Benchmark(b, builtin.formatter)
+		})
+	}
+}
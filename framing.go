@@ -0,0 +1,114 @@
+package golog
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Framing selects how console output is encoded when stdout is not a
+// terminal, for piping large volumes of entries into another process.
+type Framing int
+
+const (
+	// FramingNone writes formatted lines as they are.
+	FramingNone Framing = iota
+	// FramingLength writes every entry as a 4-byte big-endian length
+	// followed by the formatted entry without its trailing newline, so
+	// readers never scan for line breaks. ReadFrame decodes one frame.
+	FramingLength
+	// FramingGzip writes a single gzip stream of formatted lines. The stream
+	// is flushed by Logger.Flush and finished by Logger.Close.
+	FramingGzip
+)
+
+// maxFrameSize bounds the frames ReadFrame accepts.
+const maxFrameSize = 64 * 1024 * 1024
+
+// consoleFramer encodes console writes as frames or gzip data.
+type consoleFramer struct {
+	w  io.Writer
+	gz *gzip.Writer
+}
+
+// frameConsole wraps w in the framing, unless w is a terminal, where
+// framed output would be unreadable.
+func frameConsole(w io.Writer, framing Framing) *consoleFramer {
+	if framing == FramingNone || isTerminal(w) {
+		return nil
+	}
+	f := &consoleFramer{w: w}
+	if framing == FramingGzip {
+		f.gz = gzip.NewWriter(w)
+	}
+	return f
+}
+
+// isTerminal reports whether w is a character device such as a terminal.
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Write encodes one formatted entry.
+func (f *consoleFramer) Write(p []byte) (int, error) {
+	if f.gz != nil {
+		return f.gz.Write(p)
+	}
+	record := p
+	if n := len(record); n > 0 && record[n-1] == '\n' {
+		record = record[:n-1]
+	}
+	frame := make([]byte, 4+len(record))
+	binary.BigEndian.PutUint32(frame, uint32(len(record)))
+	copy(frame[4:], record)
+	if _, err := f.w.Write(frame); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// flush pushes buffered gzip data to the console.
+func (f *consoleFramer) flush() error {
+	if f.gz == nil {
+		return nil
+	}
+	return f.gz.Flush()
+}
+
+// close finishes the gzip stream; later writes are discarded.
+func (f *consoleFramer) close() error {
+	if f.gz == nil {
+		return nil
+	}
+	err := f.gz.Close()
+	f.gz.Reset(io.Discard)
+	return err
+}
+
+// ReadFrame reads one entry written with FramingLength, returning io.EOF
+// at the end of the stream.
+func ReadFrame(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("failed to read frame header: %v", err)
+		}
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > maxFrameSize {
+		return nil, fmt.Errorf("failed to read frame: %d bytes exceeds the %d byte limit", size, maxFrameSize)
+	}
+	record := make([]byte, size)
+	if _, err := io.ReadFull(r, record); err != nil {
+		return nil, fmt.Errorf("failed to read frame: %v", err)
+	}
+	return record, nil
+}
@@ -0,0 +1,164 @@
+package golog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HoneycombConfig holds Honeycomb sink options.
+type HoneycombConfig struct {
+	APIKey  string       // Honeycomb API key
+	Dataset string       // Dataset receiving the events
+	APIHost string       // API endpoint, e.g. "https://api.eu1.honeycomb.io"; defaults to "https://api.honeycomb.io"
+	Service string       // service.name attribute, if set
+	Batch   BatchConfig  // Batching options
+	Client  *http.Client // Client sending batches; defaults to one with a 10s timeout
+}
+
+// HoneycombSink sends every entry as a Honeycomb event through the batch
+// API, so existing call sites produce wide events: each field becomes a
+// column, next to level and message. The sample rate of entries kept by the
+// logger's sampler, from their sample_rate field, is passed on so Honeycomb
+// weights them accordingly.
+type HoneycombSink struct {
+	config HoneycombConfig
+	url    string
+	batch  *batcher
+}
+
+// NewHoneycombSink creates a sink sending events to config.Dataset.
+func NewHoneycombSink(config HoneycombConfig) (*HoneycombSink, error) {
+	if config.APIKey == "" || config.Dataset == "" {
+		return nil, fmt.Errorf("Honeycomb sink requires an API key and a dataset")
+	}
+	if config.APIHost == "" {
+		config.APIHost = "https://api.honeycomb.io"
+	}
+	s := &HoneycombSink{
+		config: config,
+		url:    strings.TrimSuffix(config.APIHost, "/") + "/1/batch/" + url.PathEscape(config.Dataset),
+	}
+	s.batch = newBatcher(config.Batch, s.send)
+	return s, nil
+}
+
+// honeycombEvent is one event of a batch request.
+type honeycombEvent struct {
+	Time       string                 `json:"time"`
+	SampleRate int                    `json:"samplerate,omitempty"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// Write implements Sink.
+func (s *HoneycombSink) Write(entry *Entry) error {
+	return s.batch.add(entry)
+}
+
+// Flush implements Flusher.
+func (s *HoneycombSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close implements Sink, sending any queued entries.
+func (s *HoneycombSink) Close() error {
+	return s.batch.flush()
+}
+
+// send posts a batch and checks the status of each event.
+func (s *HoneycombSink) send(entries []*Entry) error {
+	events := make([]honeycombEvent, len(entries))
+	for i, entry := range entries {
+		data := make(map[string]interface{}, len(entry.Fields)+3)
+		rate := 0
+		for k, v := range entry.Fields {
+			if k == SampleRateKey {
+				if r, ok := sampleRate(v); ok {
+					rate = r
+					continue
+				}
+			}
+			data[k] = normalizeValue(v)
+		}
+		if s.config.Service != "" {
+			data["service.name"] = s.config.Service
+		}
+		data["level"] = entry.Level.String()
+		data["message"] = entry.Message
+		events[i] = honeycombEvent{Time: entry.Time.Format(time.RFC3339Nano), SampleRate: rate, Data: data}
+	}
+	body, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to encode Honeycomb batch: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Honeycomb request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Honeycomb-Team", s.config.APIKey)
+	resp, err := postRequest(s.config.Client, req, "Honeycomb")
+	if err != nil {
+		return err
+	}
+
+	var statuses []struct {
+		Status int    `json:"status"`
+		Error  string `json:"error"`
+	}
+	if json.Unmarshal(resp, &statuses) != nil {
+		return nil
+	}
+	failed := 0
+	var last string
+	for _, status := range statuses {
+		if status.Status < 200 || status.Status > 299 {
+			failed++
+			last = status.Error
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("Honeycomb rejected %d of %d events: %s", failed, len(events), last)
+	}
+	return nil
+}
+
+// sampleRate converts a sample_rate field to an int. Besides the int the
+// sampler sets, it accepts the json.Number and float64 values the field
+// becomes after a round trip through the spool or a log file.
+func sampleRate(v interface{}) (int, bool) {
+	switch r := v.(type) {
+	case int:
+		return r, true
+	case int8:
+		return int(r), true
+	case int16:
+		return int(r), true
+	case int32:
+		return int(r), true
+	case int64:
+		return int(r), true
+	case uint:
+		return int(r), true
+	case uint8:
+		return int(r), true
+	case uint16:
+		return int(r), true
+	case uint32:
+		return int(r), true
+	case uint64:
+		return int(r), true
+	case float64:
+		return int(r), r == math.Trunc(r)
+	case json.Number:
+		n, err := r.Int64()
+		return int(n), err == nil
+	}
+	return 0, false
+}
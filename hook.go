@@ -0,0 +1,69 @@
+package golog
+
+import (
+	"fmt"
+	"os"
+)
+
+// Hook is called for every entry at one of its levels before the entry is
+// written, and may add or change fields. Hooks of a named logger also fire
+// for the entries of its descendants.
+type Hook interface {
+	Levels() []LogLevel
+	Fire(entry *Entry) error
+}
+
+// AllLevels lists every level, for hooks that fire unconditionally.
+var AllLevels = []LogLevel{TRACE, DEBUG, INFO, WARN, ERROR, FATAL}
+
+// AddHook attaches a hook to the logger.
+func (l *Logger) AddHook(hook Hook) {
+	l.hooksMu.Lock()
+	defer l.hooksMu.Unlock()
+	var hooks []Hook
+	if current := l.hooks.Load(); current != nil {
+		hooks = append(hooks, *current...)
+	}
+	hooks = append(hooks, hook)
+	l.hooks.Store(&hooks)
+}
+
+// fireHooks calls the hooks of l and its ancestors that accept the entry's
+// level, reporting failures on stderr. It runs without holding the output
+// mutex, so hooks never wait on a slow console, file or sink write.
+func (l *Logger) fireHooks(entry *Entry) {
+	for cur := l; cur != nil; cur = cur.parent.Load() {
+		hooks := cur.hooks.Load()
+		if hooks == nil {
+			continue
+		}
+		for _, hook := range *hooks {
+			if !hookFires(hook, entry.Level) {
+				continue
+			}
+			if err := hook.Fire(entry); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to fire hook: %v\n", err)
+			}
+		}
+	}
+}
+
+// hasHooks reports whether l or one of its ancestors has hooks.
+func (l *Logger) hasHooks() bool {
+	for cur := l; cur != nil; cur = cur.parent.Load() {
+		if hooks := cur.hooks.Load(); hooks != nil && len(*hooks) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hookFires reports whether hook accepts level.
+func hookFires(hook Hook, level LogLevel) bool {
+	for _, l := range hook.Levels() {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package golog
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Redacted replaces the values of sensitive headers, arguments and fields.
+const Redacted = "[REDACTED]"
+
+// DefaultRedactedHeaders are the headers HTTPTransport redacts by default.
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// HTTPTransport is an http.RoundTripper that logs every outbound request
+// with its method, URL, status, latency and retry count. Requests that fail
+// are logged at ERROR, 4xx responses at WARN and the rest at INFO, subject
+// to the logger's SlowThresholds. Set the option fields before first use.
+type HTTPTransport struct {
+	Base          http.RoundTripper        // Transport that sends requests; nil means http.DefaultTransport
+	Retries       int                      // Retry network errors and 5xx responses this many times; bodies are replayed via GetBody
+	RetryBackoff  time.Duration            // Delay before the first retry, doubled for each retry; defaults to 100ms
+	LogHeaders    bool                     // Add request_headers and response_headers fields
+	RedactHeaders []string                 // Headers whose values are replaced with Redacted; defaults to DefaultRedactedHeaders
+	LogBodies     bool                     // Add request_body and response_body fields; the response prefix is read before RoundTrip returns
+	MaxBodyBytes  int                      // Body bytes to log; defaults to 4096
+	RedactBody    func(body []byte) []byte // Rewrites logged bodies, for example to mask tokens
+
+	logger *Logger
+	sleep  func(time.Duration)
+}
+
+// NewHTTPTransport returns a logging transport that sends requests through
+// base, or http.DefaultTransport if base is nil:
+//
+//	client := &http.Client{Transport: golog.NewHTTPTransport(logger, nil)}
+func NewHTTPTransport(l *Logger, base http.RoundTripper) *HTTPTransport {
+	return &HTTPTransport{Base: base, logger: l, sleep: time.Sleep}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *HTTPTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	backoff := t.RetryBackoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+
+	var reqBody *bodyCapture
+	sw := NewStopwatch()
+	retries := 0
+	var resp *http.Response
+	var err error
+	for {
+		attempt := req
+		if retries > 0 {
+			attempt = req.Clone(req.Context())
+			if req.GetBody != nil {
+				if attempt.Body, err = req.GetBody(); err != nil {
+					resp = nil
+					break
+				}
+			}
+		}
+		if t.LogBodies && attempt.Body != nil && attempt.Body != http.NoBody {
+			reqBody = &bodyCapture{ReadCloser: attempt.Body, limit: t.maxBodyBytes()}
+			if attempt == req {
+				attempt = req.Clone(req.Context())
+			}
+			attempt.Body = reqBody
+		}
+
+		resp, err = base.RoundTrip(attempt)
+		if retries >= t.Retries || !retryable(req, resp, err) || req.Context().Err() != nil {
+			break
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+		t.sleep(backoff)
+		backoff *= 2
+		retries++
+	}
+	elapsed := sw.Elapsed()
+
+	fields := map[string]interface{}{
+		"method":  req.Method,
+		"url":     req.URL.Redacted(),
+		"elapsed": Duration(elapsed),
+		"retries": retries,
+	}
+	level := INFO
+	if err != nil {
+		fields["error"] = err.Error()
+		level = ERROR
+	} else {
+		fields["status"] = resp.StatusCode
+		switch {
+		case resp.StatusCode >= 500:
+			level = ERROR
+		case resp.StatusCode >= 400:
+			level = WARN
+		}
+	}
+	if t.LogHeaders {
+		fields["request_headers"] = t.headers(req.Header)
+		if resp != nil {
+			fields["response_headers"] = t.headers(resp.Header)
+		}
+	}
+	if t.LogBodies {
+		if reqBody != nil {
+			fields["request_body"] = t.body(reqBody.bytes())
+		}
+		if resp != nil && resp.Body != nil {
+			prefix, readErr := io.ReadAll(io.LimitReader(resp.Body, int64(t.maxBodyBytes())))
+			resp.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(prefix), resp.Body), resp.Body}
+			if readErr == nil {
+				fields["response_body"] = t.body(prefix)
+			}
+		}
+	}
+
+	t.logger.log(escalate(level, elapsed, t.logger.root().thresholds), "http request", fields)
+	return resp, err
+}
+
+// retryable reports whether a failed attempt may be sent again.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	return err != nil || resp.StatusCode >= 500
+}
+
+// maxBodyBytes returns the body logging limit.
+func (t *HTTPTransport) maxBodyBytes() int {
+	if t.MaxBodyBytes <= 0 {
+		return 4096
+	}
+	return t.MaxBodyBytes
+}
+
+// headers flattens h into a field value, redacting sensitive headers.
+func (t *HTTPTransport) headers(h http.Header) map[string]interface{} {
+	redact := t.RedactHeaders
+	if redact == nil {
+		redact = DefaultRedactedHeaders
+	}
+	result := make(map[string]interface{}, len(h))
+	for name, values := range h {
+		value := strings.Join(values, ", ")
+		for _, r := range redact {
+			if strings.EqualFold(name, r) {
+				value = Redacted
+				break
+			}
+		}
+		result[name] = value
+	}
+	return result
+}
+
+// body renders a captured body as a field value.
+func (t *HTTPTransport) body(b []byte) string {
+	if t.RedactBody != nil {
+		b = t.RedactBody(b)
+	}
+	return string(b)
+}
+
+// bodyCapture records up to limit bytes of a request body as it is sent.
+// The transport may still be reading the body when RoundTrip returns.
+type bodyCapture struct {
+	io.ReadCloser
+	mutex sync.Mutex
+	buf   bytes.Buffer
+	limit int
+}
+
+// Read implements io.Reader.
+func (c *bodyCapture) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.mutex.Lock()
+	if room := c.limit - c.buf.Len(); room > 0 {
+		c.buf.Write(p[:min(n, room)])
+	}
+	c.mutex.Unlock()
+	return n, err
+}
+
+// bytes returns a copy of the captured prefix.
+func (c *bodyCapture) bytes() []byte {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return bytes.Clone(c.buf.Bytes())
+}
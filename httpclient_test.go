@@ -0,0 +1,73 @@
+package golog
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPTransport(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Write([]byte("echo " + string(body)))
+	}))
+	defer server.Close()
+
+	logger, buf := newBufferLogger(t, INFO)
+	transport := NewHTTPTransport(logger, nil)
+	transport.Retries = 2
+	transport.LogHeaders = true
+	transport.LogBodies = true
+	transport.RedactBody = func(b []byte) []byte { return []byte(strings.ReplaceAll(string(b), "hunter2", Redacted)) }
+	transport.sleep = func(time.Duration) {}
+	client := &http.Client{Transport: transport}
+
+	req, _ := http.NewRequest("POST", server.URL+"/login", strings.NewReader("password=hunter2"))
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "echo password=hunter2" {
+		t.Errorf("Response body was not passed through: %q", body)
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse entry %s: %v", buf.String(), err)
+	}
+	if entry["method"] != "POST" || entry["status"] != float64(200) || entry["retries"] != float64(1) || entry["level"] != "INFO" {
+		t.Errorf("Unexpected request entry: %s", buf.String())
+	}
+	if entry["request_body"] != "password=[REDACTED]" || entry["response_body"] != "echo password=[REDACTED]" {
+		t.Errorf("Unexpected logged bodies: %s", buf.String())
+	}
+	if strings.Contains(buf.String(), "Bearer") || strings.Contains(buf.String(), "session=secret") {
+		t.Errorf("Sensitive headers were logged: %s", buf.String())
+	}
+}
+
+func TestHTTPTransportError(t *testing.T) {
+	logger, buf := newBufferLogger(t, INFO)
+	client := &http.Client{Transport: NewHTTPTransport(logger, nil)}
+
+	if _, err := client.Get("http://127.0.0.1:1/unreachable"); err == nil {
+		t.Fatal("Expected request to an unreachable address to fail")
+	}
+	if !strings.Contains(buf.String(), `"level":"ERROR"`) || !strings.Contains(buf.String(), `"error":`) {
+		t.Errorf("Failed request was not logged as an error: %s", buf.String())
+	}
+}
@@ -0,0 +1,153 @@
+package golog
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"time"
+)
+
+// IndexSuffix is appended to a backup's path to name its index file.
+const IndexSuffix = ".idx"
+
+// bloomBits is the size of the bloom filter in each index file.
+const bloomBits = 1 << 17
+
+// bloomHashes is the number of hash functions used by the bloom filter.
+const bloomHashes = 4
+
+// FileIndex summarizes a rotated log file so searches can skip files that
+// cannot contain matching entries. It is written as JSON next to the backup.
+// A Partial index does not cover the whole file and cannot be used to skip it.
+type FileIndex struct {
+	Start   time.Time      `json:"start"`
+	End     time.Time      `json:"end"`
+	Entries int            `json:"entries"`
+	Levels  map[string]int `json:"levels"`
+	Bloom   []byte         `json:"bloom"`             // Bloom filter over key=value field pairs
+	Partial bool           `json:"partial,omitempty"` // The file held entries written before indexing began
+}
+
+// newFileIndex returns an empty index.
+func newFileIndex() *FileIndex {
+	return &FileIndex{Levels: make(map[string]int), Bloom: make([]byte, bloomBits/8)}
+}
+
+// LoadFileIndex reads the index written for the backup at path, if any.
+func LoadFileIndex(path string) (*FileIndex, error) {
+	data, err := os.ReadFile(path + IndexSuffix)
+	if err != nil {
+		return nil, err
+	}
+	var index FileIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("invalid index file: %v", err)
+	}
+	return &index, nil
+}
+
+// add records an entry in the index. Only scalar top-level field values
+// are added to the bloom filter. The time range is widened to whole seconds,
+// the precision the text and JSON formats store, so an entry read back with
+// a truncated timestamp still falls inside it.
+func (ix *FileIndex) add(entry *Entry) {
+	start := entry.Time.Truncate(time.Second)
+	end := start
+	if !end.Equal(entry.Time) {
+		end = end.Add(time.Second)
+	}
+	if ix.Entries == 0 || start.Before(ix.Start) {
+		ix.Start = start
+	}
+	if end.After(ix.End) {
+		ix.End = end
+	}
+	ix.Entries++
+	ix.Levels[entry.Level.String()]++
+
+	for k, v := range entry.Fields {
+		if value, ok := indexValue(normalizeValue(v)); ok {
+			ix.setBloom(k, value)
+		}
+	}
+}
+
+// write stores the index next to the backup at path.
+func (ix *FileIndex) write(path string) error {
+	data, err := json.Marshal(ix)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path+IndexSuffix, data, 0644)
+}
+
+// MayContainField reports whether the indexed file may contain an entry
+// whose field key renders as value. False positives are possible; false
+// negatives are not.
+func (ix *FileIndex) MayContainField(key string, value interface{}) bool {
+	rendered, ok := indexValue(value)
+	if !ok || len(ix.Bloom) == 0 {
+		return true
+	}
+	for _, bit := range bloomPositions(key, rendered, len(ix.Bloom)*8) {
+		if ix.Bloom[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MayContainLevel reports whether the indexed file has entries at or above level.
+func (ix *FileIndex) MayContainLevel(level LogLevel) bool {
+	for l := level; l <= FATAL; l++ {
+		if ix.Levels[l.String()] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Overlaps reports whether the indexed time range intersects [since, until).
+// Zero bounds are open.
+func (ix *FileIndex) Overlaps(since, until time.Time) bool {
+	if !since.IsZero() && ix.End.Before(since) {
+		return false
+	}
+	if !until.IsZero() && !ix.Start.Before(until) {
+		return false
+	}
+	return true
+}
+
+// setBloom adds a key=value pair to the bloom filter.
+func (ix *FileIndex) setBloom(key, value string) {
+	for _, bit := range bloomPositions(key, value, len(ix.Bloom)*8) {
+		ix.Bloom[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// bloomPositions returns the filter bits for a key=value pair using double
+// hashing over two FNV variants.
+func bloomPositions(key, value string, bits int) []uint32 {
+	h1 := fnv.New32a()
+	h1.Write([]byte(key + "=" + value))
+	h2 := fnv.New32()
+	h2.Write([]byte(key + "=" + value))
+	a, b := h1.Sum32(), h2.Sum32()|1
+
+	positions := make([]uint32, bloomHashes)
+	for i := range positions {
+		positions[i] = (a + uint32(i)*b) % uint32(bits)
+	}
+	return positions
+}
+
+// indexValue renders a scalar value for the bloom filter.
+func indexValue(v interface{}) (string, bool) {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}, nil:
+		return "", false
+	}
+	return fmt.Sprint(v), true
+}
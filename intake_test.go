@@ -0,0 +1,269 @@
+package golog
+
+import (
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// intakeRequest is a request received by a fake log intake.
+type intakeRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newIntake starts a fake intake answering with status and reply and
+// recording the requests it receives.
+func newIntake(t *testing.T, status int, reply string) (*httptest.Server, func() []intakeRequest) {
+	t.Helper()
+	var mutex sync.Mutex
+	var requests []intakeRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("Invalid gzip body: %v", err)
+				return
+			}
+			body = gz
+		}
+		data, _ := io.ReadAll(body)
+		mutex.Lock()
+		requests = append(requests, intakeRequest{header: r.Header.Clone(), body: data})
+		mutex.Unlock()
+		w.WriteHeader(status)
+		io.WriteString(w, reply)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []intakeRequest {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]intakeRequest(nil), requests...)
+	}
+}
+
+func TestDatadogSink(t *testing.T) {
+	server, requests := newIntake(t, http.StatusAccepted, `{}`)
+	sink, err := NewDatadogSink(DatadogConfig{
+		APIKey:   "key",
+		URL:      server.URL,
+		Service:  "checkout",
+		Hostname: "web-1",
+		Tags:     []string{"env:prod", "team:payments"},
+		Compress: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	ts := time.UnixMilli(1700000000123)
+	sink.Write(&Entry{Time: ts, Level: WARN, Message: "slow payment", Fields: map[string]interface{}{"elapsed": Duration(2 * time.Second)}})
+	sink.Write(&Entry{Time: ts, Level: FATAL, Message: "out of memory", Fields: map[string]interface{}{"service": "checkout-worker"}})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Failed to send batch: %v", err)
+	}
+
+	got := requests()
+	if len(got) != 1 || got[0].header.Get("DD-API-KEY") != "key" {
+		t.Fatalf("Expected one authenticated request, got %+v", got)
+	}
+	var logs []map[string]interface{}
+	if err := json.Unmarshal(got[0].body, &logs); err != nil || len(logs) != 2 {
+		t.Fatalf("Unexpected body %s: %v", got[0].body, err)
+	}
+	first, second := logs[0], logs[1]
+	if first["status"] != "warning" || first["message"] != "slow payment" || first["elapsed"] != "2s" || first["timestamp"] != float64(1700000000123) {
+		t.Errorf("Unexpected first log: %v", first)
+	}
+	if first["service"] != "checkout" || first["hostname"] != "web-1" || first["ddsource"] != "go" || first["ddtags"] != "env:prod,team:payments" {
+		t.Errorf("Unexpected reserved attributes: %v", first)
+	}
+	if second["status"] != "critical" || second["service"] != "checkout-worker" {
+		t.Errorf("Expected FATAL to map to critical and the service field to win: %v", second)
+	}
+
+	rejecting, _ := newIntake(t, http.StatusForbidden, `{"errors":["Forbidden"]}`)
+	sink, _ = NewDatadogSink(DatadogConfig{APIKey: "bad", URL: rejecting.URL})
+	sink.Write(&Entry{Time: ts, Level: INFO, Message: "denied"})
+	if err := sink.Flush(); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected a rejection error, got %v", err)
+	}
+}
+
+func TestAzureMonitorSink(t *testing.T) {
+	server, requests := newIntake(t, http.StatusOK, ``)
+	key := base64.StdEncoding.EncodeToString([]byte("workspace-secret"))
+	if _, err := NewAzureMonitorSink(AzureMonitorConfig{WorkspaceID: "ws", SharedKey: key, LogType: "app-logs"}); err == nil {
+		t.Error("Expected an invalid log type to be rejected")
+	}
+	sink, err := NewAzureMonitorSink(AzureMonitorConfig{WorkspaceID: "ws", SharedKey: key, LogType: "AppLogs", URL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	sink.Write(&Entry{Time: ts, Level: ERROR, Message: "payment failed", Fields: map[string]interface{}{"order_id": 42}})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Failed to send batch: %v", err)
+	}
+
+	got := requests()
+	if len(got) != 1 {
+		t.Fatalf("Expected one request, got %d", len(got))
+	}
+	header := got[0].header
+	if header.Get("Log-Type") != "AppLogs" || header.Get("time-generated-field") != "TimeGenerated" {
+		t.Errorf("Unexpected headers: %v", header)
+	}
+	mac := hmac.New(sha256.New, []byte("workspace-secret"))
+	fmt.Fprintf(mac, "POST\n%d\napplication/json\nx-ms-date:%s\n/api/logs", len(got[0].body), header.Get("x-ms-date"))
+	if want := "SharedKey ws:" + base64.StdEncoding.EncodeToString(mac.Sum(nil)); header.Get("Authorization") != want {
+		t.Errorf("Unexpected signature %q, want %q", header.Get("Authorization"), want)
+	}
+	if body := string(got[0].body); body != `[{"Level":"ERROR","Message":"payment failed","TimeGenerated":"2024-01-02T03:04:05Z","order_id":42}]` {
+		t.Errorf("Unexpected body: %s", body)
+	}
+}
+
+func TestHoneycombSink(t *testing.T) {
+	server, requests := newIntake(t, http.StatusOK, `[{"status":202},{"status":400,"error":"event too large"}]`)
+	logger, err := NewLogger(Config{Level: INFO, SampleInitial: 1, SampleThereafter: 2})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	sink, err := NewHoneycombSink(HoneycombConfig{APIKey: "key", Dataset: "checkout", APIHost: server.URL + "/", Service: "checkout"})
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	logger.AddSink(sink)
+
+	now := time.Now().Truncate(time.Second)
+	logger.sampler.tick = now
+	for i := 0; i < 3; i++ {
+		logger.Info("cache miss", map[string]interface{}{"key": i})
+	}
+	flushErr := sink.Flush()
+	// A second boundary during the loop resets the sampler; retry rather
+	// than flake.
+	if !time.Now().Truncate(time.Second).Equal(now) {
+		return
+	}
+	if flushErr == nil || !strings.Contains(flushErr.Error(), "1 of 2 events: event too large") {
+		t.Errorf("Expected the rejected event to be reported, got %v", flushErr)
+	}
+
+	got := requests()
+	if len(got) != 1 || got[0].header.Get("X-Honeycomb-Team") != "key" {
+		t.Fatalf("Expected one authenticated request, got %+v", got)
+	}
+	var events []map[string]interface{}
+	if err := json.Unmarshal(got[0].body, &events); err != nil || len(events) != 2 {
+		t.Fatalf("Unexpected body %s: %v", got[0].body, err)
+	}
+	data := events[0]["data"].(map[string]interface{})
+	if data["message"] != "cache miss" || data["level"] != "INFO" || data["service.name"] != "checkout" || data["key"] != float64(0) {
+		t.Errorf("Unexpected event data: %v", data)
+	}
+	if _, ok := events[0]["samplerate"]; ok {
+		t.Errorf("Expected no sample rate on an unsampled event: %v", events[0])
+	}
+	data = events[1]["data"].(map[string]interface{})
+	if events[1]["samplerate"] != float64(2) || data["key"] != float64(2) || data[SampleRateKey] != nil {
+		t.Errorf("Expected the sampler's rate to be passed through: %v", events[1])
+	}
+}
+
+func TestHoneycombSinkSpooled(t *testing.T) {
+	server, requests := newIntake(t, http.StatusOK, `[{"status":202}]`)
+	sink, err := NewHoneycombSink(HoneycombConfig{APIKey: "key", Dataset: "checkout", APIHost: server.URL + "/"})
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	spool, err := NewSpoolSink(sink, SpoolConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create spool: %v", err)
+	}
+	entry := &Entry{Time: time.Now(), Level: INFO, Message: "cache miss", Fields: map[string]interface{}{SampleRateKey: 4}}
+	if err := spool.Write(entry); err != nil {
+		t.Fatalf("Failed to spool entry: %v", err)
+	}
+	if err := spool.Close(); err != nil {
+		t.Fatalf("Failed to close spool: %v", err)
+	}
+
+	got := requests()
+	if len(got) != 1 {
+		t.Fatalf("Expected one request, got %d", len(got))
+	}
+	var events []map[string]interface{}
+	if err := json.Unmarshal(got[0].body, &events); err != nil || len(events) != 1 {
+		t.Fatalf("Unexpected body %s: %v", got[0].body, err)
+	}
+	if data := events[0]["data"].(map[string]interface{}); events[0]["samplerate"] != float64(4) || data[SampleRateKey] != nil {
+		t.Errorf("Expected the spooled sample rate to be passed through: %v", events[0])
+	}
+}
+
+func TestAlertHooks(t *testing.T) {
+	pd, pdRequests := newIntake(t, http.StatusAccepted, `{"status":"success"}`)
+	og, ogRequests := newIntake(t, http.StatusAccepted, `{"result":"Request will be processed"}`)
+	logger, err := NewLogger(Config{Level: INFO, MessageTemplates: true})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	pagerDuty, err := NewPagerDutyHook("routing", AlertConfig{
+		Level:       ERROR,
+		Match:       func(entry *Entry) bool { return entry.Fields["page"] == true },
+		DedupFields: []string{"region"},
+		Source:      "api-1",
+		URL:         pd.URL,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create PagerDuty hook: %v", err)
+	}
+	opsgenie, _ := NewOpsgenieHook("genie", AlertConfig{Cooldown: time.Hour, URL: og.URL})
+	logger.AddHook(pagerDuty)
+	logger.AddHook(opsgenie)
+
+	logger.Error("payment provider {provider} down", map[string]interface{}{"provider": "acme", "region": "eu", "page": true})
+	logger.Error("payment provider {provider} down", map[string]interface{}{"provider": "other", "region": "eu", "page": true})
+	logger.Error("payment provider {provider} down", map[string]interface{}{"provider": "acme", "region": "us", "page": true})
+	logger.Error("not paged", map[string]interface{}{"page": false})
+	logger.Warn("below the level", map[string]interface{}{"page": true})
+	diskFull := "disk full! " + strings.Repeat("é", 100)
+	opsgenie.Fire(&Entry{Time: time.Now(), Level: FATAL, Message: diskFull})
+	opsgenie.Fire(&Entry{Time: time.Now(), Level: FATAL, Message: diskFull})
+
+	var keys []string
+	for _, req := range pdRequests() {
+		var event map[string]interface{}
+		json.Unmarshal(req.body, &event)
+		payload := event["payload"].(map[string]interface{})
+		if event["routing_key"] != "routing" || event["event_action"] != "trigger" || payload["severity"] != "error" || payload["source"] != "api-1" {
+			t.Errorf("Unexpected PagerDuty event: %s", req.body)
+		}
+		keys = append(keys, event["dedup_key"].(string))
+	}
+	if len(keys) != 3 || keys[0] != keys[1] || keys[0] == keys[2] {
+		t.Errorf("Expected 3 events, the first two sharing a dedup key: %v", keys)
+	}
+
+	got := ogRequests()
+	if len(got) != 1 || got[0].header.Get("Authorization") != "GenieKey genie" {
+		t.Fatalf("Expected one Opsgenie alert within the cooldown, got %d", len(got))
+	}
+	var body map[string]interface{}
+	json.Unmarshal(got[0].body, &body)
+	if body["message"] != diskFull[:129] || body["description"] != diskFull || body["priority"] != "P1" || !strings.HasPrefix(body["alias"].(string), "golog-") {
+		t.Errorf("Unexpected Opsgenie alert: %s", got[0].body)
+	}
+}
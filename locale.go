@@ -0,0 +1,129 @@
+package golog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Locale holds the regional conventions of text timestamps: month and day
+// names, the 12-hour clock markers and a default layout. Names left empty
+// fall back to English.
+type Locale struct {
+	Months      [12]string // Full month names, January first
+	ShortMonths [12]string // Abbreviated month names
+	Days        [7]string  // Full day names, Sunday first
+	ShortDays   [7]string  // Abbreviated day names
+	AM, PM      string     // 12-hour clock markers, for the "PM" layout element
+	Layout      string     // Timestamp layout used when the formatter sets none
+}
+
+// Built-in locales.
+var (
+	// LocaleEnUS uses English names and a 12-hour clock.
+	LocaleEnUS = &Locale{Layout: "01/02/2006 03:04:05 PM"}
+	// LocaleDE is German.
+	LocaleDE = &Locale{
+		Months:      [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		ShortMonths: [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		Days:        [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		ShortDays:   [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+		Layout:      "02.01.2006 15:04:05",
+	}
+	// LocaleFR is French.
+	LocaleFR = &Locale{
+		Months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		ShortMonths: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		Days:        [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		ShortDays:   [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		Layout:      "02/01/2006 15:04:05",
+	}
+	// LocaleES is Spanish.
+	LocaleES = &Locale{
+		Months:      [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		ShortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		Days:        [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		ShortDays:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		AM:          "a. m.",
+		PM:          "p. m.",
+		Layout:      "02/01/2006 15:04:05",
+	}
+	// LocaleJA is Japanese.
+	LocaleJA = &Locale{
+		Months:      [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		ShortMonths: [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		Days:        [7]string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"},
+		ShortDays:   [7]string{"日", "月", "火", "水", "木", "金", "土"},
+		AM:          "午前",
+		PM:          "午後",
+		Layout:      "2006/01/02 15:04:05",
+	}
+)
+
+// layoutTokens are the layout elements formatTime handles itself, longest
+// first so "January" is not read as "Jan".
+var layoutTokens = []string{"{isoweek}", "{isoyear}", "{weekday}", "January", "Monday", "Jan", "Mon", "PM", "pm"}
+
+// formatTime formats t with a time.Format layout, using locale's names and
+// clock markers. Layouts may also contain {isoweek} (the two-digit ISO 8601
+// week), {isoyear} (its year) and {weekday} (1 for Monday to 7 for Sunday).
+func formatTime(t time.Time, layout string, locale *Locale) string {
+	if locale == nil && !strings.Contains(layout, "{") {
+		return t.Format(layout)
+	}
+	var sb strings.Builder
+	start := 0
+	for i := 0; i < len(layout); {
+		token := ""
+		for _, candidate := range layoutTokens {
+			if strings.HasPrefix(layout[i:], candidate) {
+				token = candidate
+				break
+			}
+		}
+		if token == "" {
+			i++
+			continue
+		}
+		sb.WriteString(t.Format(layout[start:i]))
+		sb.WriteString(formatToken(t, token, locale))
+		i += len(token)
+		start = i
+	}
+	sb.WriteString(t.Format(layout[start:]))
+	return sb.String()
+}
+
+// formatToken renders one of the layoutTokens.
+func formatToken(t time.Time, token string, locale *Locale) string {
+	year, week := t.ISOWeek()
+	if locale == nil {
+		locale = &Locale{}
+	}
+	var name string
+	switch token {
+	case "{isoweek}":
+		return fmt.Sprintf("%02d", week)
+	case "{isoyear}":
+		return fmt.Sprint(year)
+	case "{weekday}":
+		return fmt.Sprint((int(t.Weekday())+6)%7 + 1)
+	case "January":
+		name = locale.Months[t.Month()-1]
+	case "Jan":
+		name = locale.ShortMonths[t.Month()-1]
+	case "Monday":
+		name = locale.Days[t.Weekday()]
+	case "Mon":
+		name = locale.ShortDays[t.Weekday()]
+	case "PM", "pm":
+		name = locale.PM
+		if t.Hour() < 12 {
+			name = locale.AM
+		}
+	}
+	if name == "" {
+		return t.Format(token)
+	}
+	return name
+}
@@ -2,8 +2,11 @@ package golog
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // LogLevel represents the severity of a log message.
@@ -23,42 +26,165 @@ func (l LogLevel) String() string {
 	return [...]string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}[l]
 }
 
+// Entry is a single log record as delivered to formatters and sinks.
+type Entry struct {
+	Time    time.Time
+	Level   LogLevel
+	Message string
+	Fields  map[string]interface{}
+}
+
 // Logger represents a logging instance.
 type Logger struct {
-	level        LogLevel
-	formatter    Formatter
-	file         *os.File
-	filePath     string
-	mutex        sync.Mutex
-	logToFile    bool
-	logToConsole bool
-	rotator      *Rotator
+	level         atomic.Int32 // LogLevel, or levelInherit for named loggers
+	overrideMu    sync.Mutex
+	override      *levelOverride
+	name          string
+	registry      atomic.Pointer[Registry] // resolves Named: owner of a named logger, or the registry of a root
+	parent        atomic.Pointer[Logger]
+	fields        map[string]interface{} // added to every entry; set by Scope and, on the root, BuildInfo
+	formatter     Formatter
+	console       Formatter
+	sharedConsole bool // console and file use the same formatter, so the file message is reused
+	stdout        io.Writer
+	framer        *consoleFramer // encodes stdout when ConsoleFraming is set
+	stderr        io.Writer
+	splitConsole  bool
+	file          *os.File
+	filePath      string
+	mutex         sync.Mutex
+	logToFile     bool
+	logToConsole  bool
+	rotator       *Rotator
+	fileSize      int64     // bytes in file, tracked per write; -1 when it must be stat'ed
+	reopenAt      time.Time // earliest retry after the log path could not be reopened
+	thresholds    []Threshold
+	sinks         []Sink
+	namedSinks    map[string]Sink // receive only entries sent To them
+	targets       []string        // named sinks for this logger's entries; set by To
+	hooksMu       sync.Mutex
+	hooks         atomic.Pointer[[]Hook] // copied on AddHook so firing never takes mutex
+	middlewareMu  sync.Mutex
+	middleware    atomic.Pointer[[]Middleware] // copied on Use, like hooks
+	dedup         *deduper
+	templates     bool
+	index         *FileIndex
+	stacks        *stackSampler
+	crash         *crashReporter
+	summary       *summary
+	async         *asyncQueue
+	lock          *fileLock
+	caller        bool
+	sampler       *sampler
+	maxLine       int
+	schema        *Schema
+	sinkOwner     atomic.Uint64  // goroutine dispatching to sinks, or 0
+	traceSeq      atomic.Uint64  // TraceFn calls so far
+	pending       []pendingEntry // entries logged by sinks during a dispatch; l.mutex must be held
+	skipped       int            // re-entrant entries dropped beyond maxReentrantEntries
+	draining      bool
 }
 
+// StartPolicy selects what a new logger does with an existing log file.
+type StartPolicy int
+
+const (
+	// StartAppend appends to the existing file.
+	StartAppend StartPolicy = iota
+	// StartRotate rotates the existing file into a backup, so each run
+	// begins a fresh file and earlier runs are kept as MaxBackups allows.
+	StartRotate
+	// StartTruncate discards the existing file's contents.
+	StartTruncate
+)
+
 // Config holds logger configuration options.
 type Config struct {
-	Level        LogLevel
-	FilePath     string
-	LogToConsole bool
-	Format       string // "text" or "json"
-	MaxSizeMB    int    // Max file size in MB before rotation
-	MaxBackups   int    // Max number of backup files
-	Compress     bool   // Compress rotated files
+	Level                   LogLevel
+	FilePath                string
+	LogToConsole            bool
+	Format                  string        // "text", "json" or "logfmt"
+	MaxSizeMB               int           // Max file size in MB before rotation
+	MaxBackups              int           // Max number of backup files
+	Compress                bool          // Compress rotated files
+	KeyOrder                []string      // Keys emitted first; the rest are sorted
+	Formatter               Formatter     // Custom formatter; overrides Format and KeyOrder
+	FileFormat              string        // Format of the file output; defaults to Format
+	FileFormatter           Formatter     // Custom formatter for the file output; overrides FileFormat
+	ConsoleFormat           string        // Format of the console output; defaults to Format
+	ConsoleFormatter        Formatter     // Custom formatter for the console output; overrides ConsoleFormat
+	ConsoleTheme            Theme         // Level colors and symbols for text console output
+	FileLock                bool          // Lock the file around writes and rotation for multi-process writers
+	SlowThresholds          []Threshold   // Level escalation for Timer entries
+	DedupWindow             time.Duration // Collapse identical consecutive entries; 0 disables
+	DedupKey                DedupKey      // What makes entries identical for DedupWindow
+	SplitConsole            bool          // Console TRACE-INFO to stdout, WARN and above to stderr
+	DisableConsoleTimestamp bool          // Omit timestamps from console output
+	MessageTemplates        bool          // Render "{field}" placeholders in messages
+	IndexBackups            bool          // Write a search index next to each rotated file
+	StackSampleWindow       time.Duration // Attach each distinct stack trace once per window; 0 disables
+	AsyncBuffer             int           // Queue up to this many entries for a background writer; 0 writes synchronously
+	LoadShedding            bool          // Drop TRACE, DEBUG, then INFO entries as the async queue fills
+	MaxLineBytes            int           // Split console and file lines longer than this into "split entry" records; 0 disables
+	OnStart                 StartPolicy   // What to do with a non-empty log file when the logger is created
+	BuildInfo               bool          // Add build_version, build_revision, build_modified and build_time fields to every entry
+	Caller                  bool          // Add a caller field with the package/file:line of the logging call
+	SampleInitial           int           // Per second, log the first N entries with the same level and message; 0 disables sampling
+	SampleThereafter        int           // After SampleInitial, log every Nth such entry that second; 0 drops them
+	Outputs                 []string      // Sink URLs opened with OpenSink, e.g. "tcp://collector:514"
+	Middleware              []Middleware  // Entry transforms run in order before hooks, formatters and sinks
+	Schema                  *Schema       // Check entries against declared fields after Middleware; nil disables
+	ConsoleFraming          Framing       // Length-prefix or gzip stdout when it is not a terminal
+	CrashReportDir          string        // Write a JSON crash report here for FATAL entries and recovered panics; "" disables
+	CrashReportRecent       int           // Recent entries included in crash reports; defaults to 100
+	Summary                 bool          // Log a summary entry with uptime, per-level counts and drops on Close
 }
 
 // NewLogger creates a new logger with the given configuration.
 func NewLogger(config Config) (*Logger, error) {
 	logger := &Logger{
-		level:        config.Level,
 		logToFile:    config.FilePath != "",
 		logToConsole: config.LogToConsole,
+		stdout:       os.Stdout,
+		stderr:       os.Stderr,
+		splitConsole: config.SplitConsole,
+		thresholds:   config.SlowThresholds,
+		dedup:        newDeduper(config.DedupWindow, config.DedupKey),
+		templates:    config.MessageTemplates,
+		stacks:       newStackSampler(config.StackSampleWindow),
+		caller:       config.Caller,
+		maxLine:      config.MaxLineBytes,
+		sampler:      newSampler(config.SampleInitial, config.SampleThereafter),
+		schema:       config.Schema,
+		crash:        newCrashReporter(config.CrashReportDir, config.CrashReportRecent),
+		summary:      newSummary(config.Summary),
+	}
+	logger.level.Store(int32(config.Level))
+	if logger.framer = frameConsole(logger.stdout, config.ConsoleFraming); logger.framer != nil {
+		logger.stdout = logger.framer
+	}
+	if config.BuildInfo {
+		logger.fields = buildInfoFields()
+	}
+	if len(config.Middleware) > 0 {
+		logger.Use(config.Middleware...)
 	}
 
-	if config.Format == "json" {
-		logger.formatter = &JSONFormatter{}
-	} else {
-		logger.formatter = &TextFormatter{}
+	shared := config.Formatter
+	if shared == nil {
+		shared = newFormatter(config.Format, config.KeyOrder)
+	}
+	logger.formatter = outputFormatter(config.FileFormatter, config.FileFormat, config.KeyOrder, shared)
+	logger.console = outputFormatter(config.ConsoleFormatter, config.ConsoleFormat, config.KeyOrder, shared)
+	if config.DisableConsoleTimestamp {
+		logger.console = withoutTimestamp(logger.console)
+	}
+	if config.ConsoleTheme != nil {
+		logger.console = withTheme(logger.console, config.ConsoleTheme)
 	}
+	logger.sharedConsole = config.FileFormatter == nil && config.FileFormat == "" &&
+		config.ConsoleFormatter == nil && config.ConsoleFormat == "" &&
+		!config.DisableConsoleTimestamp && config.ConsoleTheme == nil
 
 	if logger.logToFile {
 		var err error
@@ -67,35 +193,401 @@ func NewLogger(config Config) (*Logger, error) {
 			return nil, fmt.Errorf("failed to open log file: %v", err)
 		}
 		logger.filePath = config.FilePath
+		if config.FileLock {
+			if logger.lock, err = newFileLock(config.FilePath); err != nil {
+				logger.file.Close()
+				return nil, err
+			}
+		}
 		logger.rotator = NewRotator(config.FilePath, config.MaxSizeMB, config.MaxBackups, config.Compress)
+		if err := logger.applyStartPolicy(config.OnStart); err != nil {
+			if logger.file != nil {
+				logger.file.Close()
+			}
+			if logger.lock != nil {
+				logger.lock.close()
+			}
+			return nil, err
+		}
+		logger.statFile()
+		if config.IndexBackups {
+			logger.index = newFileIndex()
+			logger.index.Partial = logger.fileSize != 0 || logger.lock != nil
+		}
+	}
+
+	for _, output := range config.Outputs {
+		sink, err := OpenSink(output)
+		if err != nil {
+			logger.Close()
+			return nil, err
+		}
+		logger.sinks = append(logger.sinks, sink)
+	}
+
+	if config.AsyncBuffer > 0 {
+		logger.async = newAsyncQueue(logger, config.AsyncBuffer, config.LoadShedding)
 	}
 
 	return logger, nil
 }
 
-// log writes a log message if the level is sufficient.
-func (l *Logger) log(level LogLevel, msg string, fields map[string]interface{}) {
-	if level < l.level {
+// newFormatter returns the built-in formatter for a Format name; anything
+// other than "json" or "logfmt" selects text.
+func newFormatter(format string, keyOrder []string) Formatter {
+	switch format {
+	case "json":
+		return &JSONFormatter{KeyOrder: keyOrder}
+	case "logfmt":
+		return &LogfmtFormatter{KeyOrder: keyOrder}
+	}
+	return &TextFormatter{KeyOrder: keyOrder}
+}
+
+// outputFormatter picks the formatter for one output: its own formatter,
+// then its own format name, then the formatter shared by all outputs.
+func outputFormatter(formatter Formatter, format string, keyOrder []string, shared Formatter) Formatter {
+	if formatter != nil {
+		return formatter
+	}
+	if format != "" {
+		return newFormatter(format, keyOrder)
+	}
+	return shared
+}
+
+// log writes a log message if the level is sufficient, returning the entry
+// or nil if it was not logged.
+func (l *Logger) log(level LogLevel, msg string, fields map[string]interface{}) *Entry {
+	if level < l.Level() {
+		return nil
+	}
+	root := l.root()
+	now := time.Now()
+	if root.sampler != nil {
+		rate := root.sampler.rate(level, msg, now)
+		if rate == 0 {
+			if root.summary != nil {
+				root.summary.sampled.Add(1)
+			}
+			return nil
+		}
+		if rate > 1 {
+			fields[SampleRateKey] = rate
+		}
+	}
+	if root.caller {
+		if _, ok := fields["caller"]; !ok {
+			fields["caller"] = callerLocation()
+		}
+	}
+
+	if l.name != "" {
+		if _, ok := fields["logger"]; !ok {
+			fields["logger"] = l.name
+		}
+	}
+	for k, v := range l.fields {
+		if _, ok := fields[k]; !ok {
+			fields[k] = v
+		}
+	}
+	if root != l {
+		for k, v := range root.fields {
+			if _, ok := fields[k]; !ok {
+				fields[k] = v
+			}
+		}
+	}
+	if root.templates {
+		rendered, templated := renderTemplate(msg, fields)
+		if templated {
+			fields[TemplateKey] = msg
+		}
+		msg = rendered
+	}
+	entry := &Entry{Time: now, Level: level, Message: msg, Fields: fields}
+	if stacks := root.stacks; stacks != nil {
+		stacks.sample(entry)
+	}
+	l.applyMiddleware(entry)
+	if root.schema != nil && !root.schema.enforce(entry) {
+		if root.summary != nil {
+			root.summary.rejected.Add(1)
+		}
+		return nil
+	}
+	if root.summary != nil {
+		root.summary.count(entry)
+	}
+	if root.crash != nil {
+		root.crash.record(entry)
+		if level >= FATAL {
+			root.crash.report(entry)
+		}
+	}
+	l.deliver(entry)
+	return entry
+}
+
+// deliver fires the hooks for a complete entry and writes it to the outputs
+// of l and its ancestors.
+func (l *Logger) deliver(entry *Entry) {
+	if l.hasHooks() {
+		l.fireHooksOnce(entry)
+	}
+	if targets := l.routedTargets(); targets != nil && l.deliverTo(entry, targets) {
 		return
 	}
 
+	// Named loggers deliver to their own sinks and then to each ancestor's,
+	// ending with the root, which also owns the console and file outputs.
+	cur := l
+	for parent := cur.parent.Load(); parent != nil; parent = cur.parent.Load() {
+		cur.writeSinks(entry)
+		cur = parent
+	}
+	if cur.async != nil && !cur.reentrant() {
+		cur.async.push(cur, entry)
+		return
+	}
+	cur.write(entry)
+}
+
+// write sends an entry to the logger's outputs unless it is suppressed as
+// a duplicate.
+func (l *Logger) write(entry *Entry) {
+	if l.reentrant() {
+		l.deferEntry(entry, "")
+		return
+	}
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
-	message := l.formatter.Format(level, msg, fields)
+	if l.dedup != nil && l.dedup.suppress(l, entry) {
+		return
+	}
+	l.output(entry)
+}
+
+// output formats an entry and writes it to the console, file and sinks;
+// l.mutex must be held.
+func (l *Logger) output(entry *Entry) {
+	l.writeLocal(entry, formatEntry(l.formatter, entry))
+	l.dispatchSinks(entry)
+}
 
+// writeLocal writes a formatted entry to the console and file, split into
+// records if it is longer than MaxLineBytes; l.mutex must be held.
+func (l *Logger) writeLocal(entry *Entry, message string) {
+	if l.maxLine > 0 {
+		message = splitLine(l.formatter, entry, message, l.maxLine)
+	}
 	if l.logToConsole {
-		fmt.Print(message)
+		console := message
+		if !l.sharedConsole {
+			console = formatEntry(l.console, entry)
+			if l.maxLine > 0 {
+				console = splitLine(l.console, entry, console, l.maxLine)
+			}
+		}
+		w := l.stdout
+		if l.splitConsole && entry.Level >= WARN {
+			w = l.stderr
+		}
+		io.WriteString(w, console)
 	}
 
+	if l.logToFile && l.file == nil {
+		l.retryOpen()
+	}
 	if l.logToFile && l.file != nil {
-		if l.rotator != nil {
-			if err := l.rotator.RotateIfNeeded(l.file); err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to rotate log: %v\n", err)
+		l.writeFile(entry, message)
+	}
+}
+
+// writeFile appends a formatted entry to the log file, rotating it first
+// if needed; l.mutex must be held. With FileLock the whole step runs under
+// the inter-process lock, and a file rotated by another process is
+// reopened first.
+func (l *Logger) writeFile(entry *Entry, message string) {
+	if l.lock != nil {
+		if err := l.lock.lock(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to lock log file: %v\n", err)
+		} else {
+			defer l.lock.unlock()
+		}
+		l.reopenIfMoved()
+	}
+	if l.rotator != nil && l.file != nil {
+		l.rotateIfNeeded()
+	}
+	if l.file != nil {
+		n, _ := l.file.WriteString(message)
+		if l.fileSize >= 0 {
+			l.fileSize += int64(n)
+		}
+		if l.index != nil {
+			l.index.add(entry)
+		}
+	}
+}
+
+// retryOpen opens the log path again after a rotation could not reopen
+// it, at most once a second; l.mutex must be held.
+func (l *Logger) retryOpen() {
+	now := time.Now()
+	if now.Before(l.reopenAt) {
+		return
+	}
+	file, err := os.OpenFile(l.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		l.reopenAt = now.Add(time.Second)
+		return
+	}
+	l.file = file
+	l.statFile()
+}
+
+// reopenIfMoved reopens the log path when the open file is no longer the
+// file at that path, because another process rotated it; l.mutex must be
+// held.
+func (l *Logger) reopenIfMoved() {
+	current, err := l.file.Stat()
+	if err != nil {
+		return
+	}
+	if onDisk, err := os.Stat(l.filePath); err == nil && os.SameFile(current, onDisk) {
+		return
+	}
+	file, err := os.OpenFile(l.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to reopen log file: %v\n", err)
+		return
+	}
+	l.file.Close()
+	l.file = file
+	l.statFile()
+	if l.index != nil {
+		l.index = newFileIndex()
+		l.index.Partial = true
+	}
+}
+
+// applyStartPolicy rotates or truncates a non-empty log file as the logger
+// is created.
+func (l *Logger) applyStartPolicy(policy StartPolicy) error {
+	if policy == StartAppend {
+		return nil
+	}
+	if l.lock != nil {
+		if err := l.lock.lock(); err != nil {
+			return fmt.Errorf("failed to lock log file: %v", err)
+		}
+		defer l.lock.unlock()
+	}
+	info, err := l.file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat log file: %v", err)
+	}
+	if info.Size() == 0 {
+		return nil
+	}
+
+	switch policy {
+	case StartRotate:
+		file, _, err := l.rotator.Rotate(l.file)
+		l.file = file
+		if file == nil {
+			return err
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to rotate log: %v\n", err)
+		}
+	case StartTruncate:
+		if err := l.file.Truncate(0); err != nil {
+			return fmt.Errorf("failed to truncate log file: %v", err)
+		}
+	}
+	return nil
+}
+
+// statFile initializes the tracked file size. Other processes append to a
+// shared file, so with FileLock the size is left unknown and every rotation
+// check stats the file; l.mutex must be held unless l is being created.
+func (l *Logger) statFile() {
+	l.fileSize = -1
+	if l.file == nil || l.lock != nil {
+		return
+	}
+	if info, err := l.file.Stat(); err == nil {
+		l.fileSize = info.Size()
+	}
+}
+
+// rotateIfNeeded rotates the log file once it reaches the size limit and
+// writes the index of the rotated file; l.mutex must be held. The tracked
+// size is used when known, so the file is only stat'ed after it is opened.
+func (l *Logger) rotateIfNeeded() {
+	if l.fileSize >= 0 {
+		if !l.rotator.exceeds(l.fileSize) {
+			return
+		}
+	} else {
+		rotate, err := l.rotator.ShouldRotate(l.file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to rotate log: %v\n", err)
+			return
+		}
+		if !rotate {
+			return
+		}
+	}
+
+	file, backup, err := l.rotator.Rotate(l.file)
+	l.file = file
+	l.statFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to rotate log: %v\n", err)
+	}
+
+	if l.index != nil && backup != "" {
+		if _, statErr := os.Stat(backup); statErr == nil {
+			if err := l.index.write(backup); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write log index: %v\n", err)
 			}
 		}
-		l.file.WriteString(message)
+		l.index = newFileIndex()
+		// Other processes sharing the file write entries this index misses.
+		l.index.Partial = l.lock != nil
+	}
+}
+
+// writeSinks sends an entry to the logger's sinks only.
+func (l *Logger) writeSinks(entry *Entry) {
+	if l.reentrant() {
+		l.deferEntry(entry, "")
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.dispatchSinks(entry)
+}
+
+// dispatchSinks sends an entry to every sink; l.mutex must be held.
+// Entries the sinks log through l meanwhile are written afterwards.
+func (l *Logger) dispatchSinks(entry *Entry) {
+	if len(l.sinks) == 0 {
+		return
+	}
+	l.sinkOwner.Store(currentGoroutineID())
+	for _, sink := range l.sinks {
+		if err := sink.Write(entry); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write to sink: %v\n", err)
+		}
 	}
+	l.sinkOwner.Store(0)
+	l.drainPending()
 }
 
 // Trace logs a trace message.
@@ -123,21 +615,118 @@ func (l *Logger) Error(msg string, fields ...map[string]interface{}) {
 	l.log(ERROR, msg, mergeFields(fields))
 }
 
-// Fatal logs a fatal message and exits the program.
+// Fatal logs a fatal message, flushes the logger, runs the registered exit
+// handlers and exits the program.
 func (l *Logger) Fatal(msg string, fields ...map[string]interface{}) {
 	l.log(FATAL, msg, mergeFields(fields))
-	os.Exit(1)
+	l.root().logSummary(1)
+	if err := l.root().Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to flush log: %v\n", err)
+	}
+	Exit(1)
+}
+
+// Log logs a message at the given level. Unlike Fatal, logging at FATAL
+// through Log does not flush or exit, which suits adapters for other
+// logging APIs that handle termination themselves.
+func (l *Logger) Log(level LogLevel, msg string, fields ...map[string]interface{}) {
+	l.log(level, msg, mergeFields(fields))
+}
+
+// AddSink attaches an additional output to the logger. Sinks of a named
+// logger also receive the entries of its descendants. The logger takes
+// ownership of the sink and closes it on Close.
+func (l *Logger) AddSink(sink Sink) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.sinks = append(l.sinks, sink)
 }
 
-// Close closes the log file.
+// Flush writes any queued entries, commits the log file to stable storage
+// and flushes any buffering sinks.
+func (l *Logger) Flush() error {
+	if l.reentrant() {
+		return l.flushReentrant()
+	}
+	if l.async != nil {
+		l.async.flush()
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.dedup != nil {
+		l.dedup.flush(l)
+	}
+
+	var firstErr error
+	if l.framer != nil {
+		if err := l.framer.flush(); err != nil {
+			firstErr = fmt.Errorf("failed to flush console: %v", err)
+		}
+	}
+	if l.file != nil {
+		if err := l.file.Sync(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to sync log file: %v", err)
+		}
+	}
+	for _, sink := range l.allSinks() {
+		if f, ok := sink.(Flusher); ok {
+			if err := f.Flush(); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("failed to flush sink: %v", err)
+			}
+		}
+	}
+	return firstErr
+}
+
+// Close writes any queued entries and closes the log file and any
+// attached sinks.
 func (l *Logger) Close() error {
+	if l.reentrant() {
+		return fmt.Errorf("failed to close logger: called from one of its sinks")
+	}
+	if l.summary != nil {
+		code := int(l.summary.exitCode.Load())
+		if pending, exiting := pendingExitCode(); exiting {
+			code = pending
+		}
+		l.logSummary(code)
+	}
+	if l.async != nil {
+		l.async.close()
+	}
+
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
+	if l.dedup != nil {
+		l.dedup.flush(l)
+	}
+
+	var firstErr error
+	for _, sink := range l.allSinks() {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close sink: %v", err)
+		}
+	}
+	l.sinks, l.namedSinks = nil, nil
+
+	if l.framer != nil {
+		if err := l.framer.close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close console stream: %v", err)
+		}
+	}
+	if l.lock != nil {
+		l.lock.close()
+	}
+	l.logToFile = false
 	if l.file != nil {
-		return l.file.Close()
+		if err := l.file.Close(); err != nil {
+			return err
+		}
 	}
-	return nil
+	return firstErr
 }
 
 // mergeFields combines multiple field maps into one.
@@ -1,10 +1,20 @@
 package golog
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestLoggerTextOutput(t *testing.T) {
@@ -32,7 +42,7 @@ func TestLoggerTextOutput(t *testing.T) {
 		t.Fatalf("Failed to read log file: %v", err)
 	}
 
-	if !strings.Contains(string(content), "INFO Test message map[key:value]") {
+	if !strings.Contains(string(content), "INFO Test message key=value") {
 		t.Errorf("Log file does not contain expected message")
 	}
 }
@@ -100,6 +110,47 @@ func TestLogRotation(t *testing.T) {
 	}
 }
 
+func TestRotationCopyTruncate(t *testing.T) {
+	renameFile = func(from, to string) error {
+		return &os.LinkError{Op: "rename", Old: from, New: to, Err: errors.New("the process cannot access the file")}
+	}
+	defer func() { renameFile = os.Rename }()
+
+	logFile := filepath.Join(t.TempDir(), "test.log")
+	logger, err := NewLogger(Config{Level: INFO, FilePath: logFile, MaxSizeMB: 1, MaxBackups: 1})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+	// A second handle, like a log shipper's, must keep seeing the live file.
+	other, err := os.Open(logFile)
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+	defer other.Close()
+
+	for i := 0; i < 1030; i++ {
+		logger.Info(fmt.Sprintf("%04d %s", i, strings.Repeat("x", 1024)))
+	}
+
+	backups, _ := filepath.Glob(logFile + ".*")
+	if len(backups) != 1 {
+		t.Fatalf("Expected one backup, got %v", backups)
+	}
+	backup, _ := os.ReadFile(backups[0])
+	current, _ := os.ReadFile(logFile)
+	if !strings.Contains(string(backup), " 0000 ") || strings.Contains(string(current), " 0000 ") || !strings.Contains(string(current), " 1029 ") {
+		t.Errorf("Expected early entries in the backup and the latest in the log file")
+	}
+	if len(current) >= 1024*1024 {
+		t.Errorf("Expected the log file to be truncated, it has %d bytes", len(current))
+	}
+	info, _ := other.Stat()
+	if onDisk, _ := os.Stat(logFile); !os.SameFile(info, onDisk) {
+		t.Errorf("Expected the log path to remain the same file")
+	}
+}
+
 func TestLogLevels(t *testing.T) {
 	logger, err := NewLogger(Config{
 		Level:        WARN,
@@ -114,5 +165,1032 @@ func TestLogLevels(t *testing.T) {
 	logger.Info("This should not be logged")
 	logger.Warn("This should be logged")
 	logger.Error("This should be logged")
-	// Fatal is not tested as it exits the program
+}
+
+func TestFatalRunsExitHandlers(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(Config{Level: INFO})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.AddSink(NewWriterSink(&buf, nil))
+
+	var calls []string
+	exitCode := -1
+	SetExitFunc(func(code int) { exitCode = code })
+	defer SetExitFunc(nil)
+	RegisterExitHandler(func() { calls = append(calls, "first") })
+	RegisterExitHandler(func() { panic("broken handler") })
+	RegisterExitHandler(func() { calls = append(calls, "third") })
+
+	logger.Fatal("shutting down")
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if strings.Join(calls, ",") != "first,third" {
+		t.Errorf("Unexpected exit handler calls: %v", calls)
+	}
+	if !strings.Contains(buf.String(), "FATAL shutting down") {
+		t.Errorf("Fatal entry was not written: %s", buf.String())
+	}
+
+	calls = nil
+	logger.Fatal("again")
+	if len(calls) != 0 {
+		t.Errorf("Exit handlers ran twice: %v", calls)
+	}
+}
+
+func TestConcurrentExitWaitsForHandlers(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	SetExitFunc(func(code int) { record("exit") })
+	defer SetExitFunc(nil)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	RegisterExitHandler(func() {
+		close(started)
+		<-release
+		record("handler")
+	})
+
+	go Exit(1)
+	<-started
+	second := make(chan struct{})
+	go func() {
+		Exit(1)
+		close(second)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	<-second
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) < 2 || events[0] != "handler" {
+		t.Errorf("Exit did not wait for running handlers: %v", events)
+	}
+}
+
+func TestTimerEscalation(t *testing.T) {
+	tempDir := t.TempDir()
+	logFile := filepath.Join(tempDir, "test.log")
+
+	logger, err := NewLogger(Config{
+		Level:          TRACE,
+		FilePath:       logFile,
+		Format:         "json",
+		MaxSizeMB:      1,
+		SlowThresholds: []Threshold{{After: 0, Level: WARN}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	done := logger.Timer("load users", map[string]interface{}{"count": 3})
+	done()
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+
+	if !strings.Contains(string(content), `"level":"WARN"`) || !strings.Contains(string(content), `"elapsed":"`) {
+		t.Errorf("Timer entry was not escalated or lacks elapsed field: %s", content)
+	}
+}
+
+func TestStopwatchLaps(t *testing.T) {
+	sw := NewStopwatch()
+	sw.Lap("parse")
+	sw.Lap("store")
+	sw.Lap("parse")
+
+	if laps := sw.Laps(); len(laps) != 2 || laps[0] != "parse" || laps[1] != "store" {
+		t.Errorf("Unexpected laps: %v", laps)
+	}
+	if _, ok := sw.Fields()["laps"].(map[string]interface{})["store"]; !ok {
+		t.Errorf("Stopwatch fields do not contain lap durations")
+	}
+}
+
+func TestDedupRepeatedMessages(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(Config{Level: INFO, DedupWindow: time.Minute})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.AddSink(NewWriterSink(&buf, nil))
+
+	for i := 0; i < 5; i++ {
+		logger.Warn("disk almost full", map[string]interface{}{"attempt": i})
+	}
+	logger.Info("recovered")
+	logger.Close()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d: %s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[1], "WARN last message repeated 4 times") {
+		t.Errorf("Expected repeat summary, got: %s", lines[1])
+	}
+	if !strings.Contains(lines[2], "INFO recovered") {
+		t.Errorf("Expected next entry after summary, got: %s", lines[2])
+	}
+}
+
+func TestDedupStaleTimer(t *testing.T) {
+	logger, err := NewLogger(Config{Level: INFO, DedupWindow: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+	start := time.Now()
+	entry := func(msg string, at time.Duration) *Entry {
+		return &Entry{Time: start.Add(at), Level: WARN, Message: msg}
+	}
+
+	// The first burst's timer fires while the mutex is held and waits for it.
+	logger.write(entry("disk almost full", 0))
+	logger.write(entry("disk almost full", time.Hour-time.Millisecond))
+	logger.mutex.Lock()
+	time.Sleep(50 * time.Millisecond)
+	// A new entry ends the burst, and a new burst starts, before it runs.
+	logger.dedup.suppress(logger, entry("cpu hot", time.Hour))
+	logger.dedup.suppress(logger, entry("cpu hot", time.Hour+time.Second))
+	logger.mutex.Unlock()
+	time.Sleep(50 * time.Millisecond)
+
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	if logger.dedup.count != 1 || logger.dedup.entry == nil || logger.dedup.entry.Message != "cpu hot" {
+		t.Errorf("Expected the stale timer to leave the new burst alone, got count %d", logger.dedup.count)
+	}
+}
+
+func TestSplitConsole(t *testing.T) {
+	logger, err := NewLogger(Config{
+		Level:                   DEBUG,
+		LogToConsole:            true,
+		SplitConsole:            true,
+		DisableConsoleTimestamp: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	var stdout, stderr bytes.Buffer
+	logger.stdout = &stdout
+	logger.stderr = &stderr
+
+	logger.Debug("starting")
+	logger.Info("ready")
+	logger.Warn("slow")
+	logger.Error("failed")
+
+	if stdout.String() != "DEBUG starting\nINFO ready\n" {
+		t.Errorf("Unexpected stdout output: %q", stdout.String())
+	}
+	if stderr.String() != "WARN slow\nERROR failed\n" {
+		t.Errorf("Unexpected stderr output: %q", stderr.String())
+	}
+}
+
+func TestConsoleFraming(t *testing.T) {
+	if frameConsole(&bytes.Buffer{}, FramingNone) != nil {
+		t.Errorf("Expected FramingNone to leave the console alone")
+	}
+	newFramed := func(framing Framing) (*Logger, *bytes.Buffer) {
+		logger, err := NewLogger(Config{Level: INFO, LogToConsole: true, DisableConsoleTimestamp: true})
+		if err != nil {
+			t.Fatalf("Failed to create logger: %v", err)
+		}
+		var stdout bytes.Buffer
+		logger.framer = frameConsole(&stdout, framing)
+		logger.stdout = logger.framer
+		return logger, &stdout
+	}
+
+	logger, stdout := newFramed(FramingLength)
+	logger.Info("first")
+	logger.Info("second\nline")
+	var records []string
+	for {
+		record, err := ReadFrame(stdout)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read frame: %v", err)
+		}
+		records = append(records, string(record))
+	}
+	if len(records) != 2 || records[0] != "INFO first" || records[1] != "INFO second\nline" {
+		t.Errorf("Unexpected frames: %q", records)
+	}
+	logger.Close()
+
+	logger, stdout = newFramed(FramingGzip)
+	logger.Info("compressed")
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	logger.Info("last")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+	zr, err := gzip.NewReader(stdout)
+	if err != nil {
+		t.Fatalf("Failed to open gzip stream: %v", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil || string(data) != "INFO compressed\nINFO last\n" {
+		t.Errorf("Unexpected gzip contents: %q, %v", data, err)
+	}
+}
+
+func TestMessageTemplates(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(Config{Level: INFO, MessageTemplates: true})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.AddSink(NewWriterSink(&buf, &JSONFormatter{}))
+
+	logger.Info("user {user} logged in from {ip}", map[string]interface{}{"user": "alice", "ip": "10.0.0.1"})
+
+	output := buf.String()
+	if !strings.Contains(output, `"message":"user alice logged in from 10.0.0.1"`) {
+		t.Errorf("Message was not rendered: %s", output)
+	}
+	if !strings.Contains(output, `"message_template":"user {user} logged in from {ip}"`) || !strings.Contains(output, `"user":"alice"`) {
+		t.Errorf("Template or parameters were not kept as fields: %s", output)
+	}
+
+	buf.Reset()
+	logger.Info("use {{ to escape {missing}")
+	if output := buf.String(); !strings.Contains(output, `"message":"use { to escape {missing}"`) || strings.Contains(output, TemplateKey) {
+		t.Errorf("Expected escapes without placeholders to keep no template: %s", output)
+	}
+}
+
+func TestEventCatalog(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(Config{Level: INFO})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.AddSink(NewWriterSink(&buf, &JSONFormatter{}))
+
+	if err := RegisterEvent(EventDef{ID: 9001, Name: "payment_declined", Description: "Payment was declined", Level: ERROR}); err != nil {
+		t.Fatalf("Failed to register event: %v", err)
+	}
+	if err := RegisterEvent(EventDef{ID: 9001, Name: "duplicate"}); err == nil {
+		t.Errorf("Expected error for duplicate event ID")
+	}
+	if def := MustRegisterEvent(EventDef{ID: 9002, Name: "cache_cold"}); def.Level != INFO {
+		t.Errorf("Expected an unset event level to default to INFO, got %s", def.Level)
+	}
+
+	logger.Event(9001, "", map[string]interface{}{"order": 42})
+
+	output := buf.String()
+	for _, expected := range []string{`"event_id":9001`, `"event":"payment_declined"`, `"level":"ERROR"`, `"message":"Payment was declined"`} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Event entry %s does not contain %s", output, expected)
+		}
+	}
+}
+
+func TestScope(t *testing.T) {
+	logger, buf := newBufferLogger(t, INFO)
+
+	batch := logger.Scope("import batch", map[string]interface{}{"rows": 10})
+	row := batch.Scope("row")
+	row.Info("parsed")
+	row.Close()
+	batch.Close()
+	batch.Close()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 entries, got %d: %s", len(lines), buf.String())
+	}
+	batchID := `"scope_id":"` + batch.ID() + `"`
+	checks := []struct {
+		line     string
+		expected []string
+	}{
+		{lines[0], []string{`"message":"begin import batch"`, batchID, `"scope_depth":0`, `"rows":10`}},
+		{lines[2], []string{`"message":"parsed"`, `"parent_scope_id":"` + batch.ID() + `"`, `"scope_depth":1`, `"scope":"row"`}},
+		{lines[4], []string{`"message":"end import batch"`, batchID, `"elapsed":"`}},
+	}
+	for _, c := range checks {
+		for _, expected := range c.expected {
+			if !strings.Contains(c.line, expected) {
+				t.Errorf("Entry %s does not contain %s", c.line, expected)
+			}
+		}
+	}
+}
+
+// blockingSink holds the first entry it receives until released.
+type blockingSink struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+	buf     bytes.Buffer
+}
+
+func (s *blockingSink) Write(entry *Entry) error {
+	s.once.Do(func() {
+		close(s.started)
+		<-s.release
+	})
+	s.buf.WriteString(formatEntry(&JSONFormatter{}, entry))
+	return nil
+}
+
+func (s *blockingSink) Close() error { return nil }
+
+func TestLoadShedding(t *testing.T) {
+	logger, err := NewLogger(Config{Level: TRACE, AsyncBuffer: 10, LoadShedding: true})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	sink := &blockingSink{started: make(chan struct{}), release: make(chan struct{})}
+	logger.AddSink(sink)
+
+	logger.Info("first")
+	<-sink.started
+	for i := 0; i < 9; i++ {
+		logger.Warn("storm")
+	}
+	logger.Trace("dropped trace")
+	logger.Debug("dropped debug")
+	logger.Info("dropped info")
+	close(sink.release)
+
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Failed to close logger: %v", err)
+	}
+	output := sink.buf.String()
+	if strings.Count(output, `"message":"storm"`) != 9 || strings.Contains(output, "dropped trace") {
+		t.Errorf("Unexpected entries under load: %s", output)
+	}
+	for _, expected := range []string{`"message":"dropped 3 entries under load"`, `"dropped_trace":1`, `"dropped_debug":1`, `"dropped_info":1`} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Shedding summary does not contain %s: %s", expected, output)
+		}
+	}
+}
+
+// countingHook counts the entries it sees and tags them.
+type countingHook struct {
+	fired atomic.Int32
+}
+
+func (h *countingHook) Levels() []LogLevel { return AllLevels }
+
+func (h *countingHook) Fire(entry *Entry) error {
+	h.fired.Add(1)
+	entry.Fields["hooked"] = true
+	return nil
+}
+
+func TestHooksWhileSinkBlocked(t *testing.T) {
+	logger, err := NewLogger(Config{Level: INFO, AsyncBuffer: 10})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	sink := &blockingSink{started: make(chan struct{}), release: make(chan struct{})}
+	logger.AddSink(sink)
+	hook := &countingHook{}
+	logger.AddHook(hook)
+
+	logger.Info("first")
+	<-sink.started
+	done := make(chan struct{})
+	go func() {
+		logger.Info("second", map[string]interface{}{"id": 2})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Logging with a hook blocked on a stalled sink")
+	}
+	close(sink.release)
+
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Failed to close logger: %v", err)
+	}
+	if hook.fired.Load() != 2 || strings.Count(sink.buf.String(), `"hooked":true`) != 2 {
+		t.Errorf("Unexpected hook output (%d calls): %s", hook.fired.Load(), sink.buf.String())
+	}
+}
+
+func TestPerOutputFormatters(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "test.log")
+	logger, err := NewLogger(Config{Level: INFO, FilePath: logFile, LogToConsole: true, FileFormat: "json", ConsoleFormat: "text"})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	var stdout bytes.Buffer
+	logger.stdout = &stdout
+
+	logger.Info("hello", map[string]interface{}{"user": "alice"})
+	logger.Close()
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if !strings.Contains(string(content), `"message":"hello"`) {
+		t.Errorf("Expected JSON file output, got: %s", content)
+	}
+	if !strings.Contains(stdout.String(), "INFO hello user=alice") {
+		t.Errorf("Expected text console output, got: %s", stdout.String())
+	}
+}
+
+func TestFileLockSharedPath(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "shared.log")
+	var loggers []*Logger
+	for i := 0; i < 2; i++ {
+		logger, err := NewLogger(Config{Level: INFO, FilePath: logFile, MaxSizeMB: 1, MaxBackups: 10, FileLock: true, Format: "json"})
+		if err != nil {
+			t.Fatalf("Failed to create logger: %v", err)
+		}
+		loggers = append(loggers, logger)
+	}
+
+	padding := strings.Repeat("x", 1024)
+	const perLogger = 1500
+	var wg sync.WaitGroup
+	for _, logger := range loggers {
+		wg.Add(1)
+		go func(logger *Logger) {
+			defer wg.Done()
+			for i := 0; i < perLogger; i++ {
+				logger.Info("shared", map[string]interface{}{"padding": padding})
+			}
+		}(logger)
+	}
+	wg.Wait()
+	for _, logger := range loggers {
+		logger.Close()
+	}
+
+	files, _ := filepath.Glob(logFile + "*")
+	lines := 0
+	for _, f := range files {
+		if strings.HasSuffix(f, LockSuffix) {
+			continue
+		}
+		content, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", f, err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			if !strings.HasPrefix(line, "{") || !strings.HasSuffix(line, "}") {
+				t.Fatalf("Interleaved line in %s: %.80q", f, line)
+			}
+			lines++
+		}
+		if info, _ := os.Stat(f); info.Size() > 2*1024*1024 {
+			t.Errorf("File %s grew past the rotation limit: %d bytes", f, info.Size())
+		}
+	}
+	if lines != 2*perLogger {
+		t.Errorf("Expected %d lines, got %d", 2*perLogger, lines)
+	}
+}
+
+func TestFileIndexSecondPrecision(t *testing.T) {
+	base := time.Date(2025, 7, 18, 10, 0, 0, 0, time.UTC)
+	index := newFileIndex()
+	index.add(&Entry{Time: base.Add(700 * time.Millisecond), Level: INFO})
+
+	// The entry is stored as 10:00:00, which a reader matches for both ranges.
+	if !index.Overlaps(time.Time{}, base.Add(500*time.Millisecond)) {
+		t.Errorf("Index skipped a file whose stored entry is before until")
+	}
+	if !index.Overlaps(base.Add(500*time.Millisecond), time.Time{}) {
+		t.Errorf("Index skipped a file whose entry is after since")
+	}
+	if index.Overlaps(base.Add(2*time.Second), time.Time{}) {
+		t.Errorf("Index did not skip a file that ended before since")
+	}
+}
+
+// keysFormatter is a value-type formatter holding a slice, which makes it
+// uncomparable as an interface value.
+type keysFormatter struct {
+	keys []string
+}
+
+func (f keysFormatter) Format(level LogLevel, msg string, fields map[string]interface{}) string {
+	return msg + " " + strings.Join(f.keys, ",")
+}
+
+func TestUncomparableFormatter(t *testing.T) {
+	logger, err := NewLogger(Config{Level: INFO, LogToConsole: true, Formatter: keysFormatter{keys: []string{"a", "b"}}})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	var stdout bytes.Buffer
+	logger.stdout = &stdout
+
+	logger.Info("hello")
+
+	if !strings.Contains(stdout.String(), "hello a,b") {
+		t.Errorf("Unexpected console output: %s", stdout.String())
+	}
+}
+
+func TestBuildInfoFields(t *testing.T) {
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			Main: debug.Module{Path: "example.com/app", Version: "v1.4.2"},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "8f3c2a1"},
+				{Key: "vcs.modified", Value: "true"},
+				{Key: "vcs.time", Value: "2025-07-18T21:48:00Z"},
+			},
+		}, true
+	}
+	defer func() { readBuildInfo = debug.ReadBuildInfo }()
+
+	logger, err := NewLogger(Config{Level: INFO, BuildInfo: true})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	var buf bytes.Buffer
+	logger.AddSink(NewWriterSink(&buf, &JSONFormatter{}))
+
+	logger.Info("started")
+	NewRegistry(logger).Get("db").Info("connected")
+
+	expected := `"build_modified":true,"build_revision":"8f3c2a1","build_time":"2025-07-18T21:48:00Z","build_version":"v1.4.2"`
+	if strings.Count(buf.String(), expected) != 2 {
+		t.Errorf("Build fields missing from entries: %s", buf.String())
+	}
+}
+
+func TestCallerField(t *testing.T) {
+	logger, err := NewLogger(Config{Level: INFO, Caller: true})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	var buf bytes.Buffer
+	logger.AddSink(NewWriterSink(&buf, &JSONFormatter{}))
+
+	logger.Info("direct")
+	logger.Timer("timed")()
+	logger.Scope("work").Close()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 entries, got: %s", buf.String())
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, `"caller":"`+filepath.Base(filepath.Dir(packageDir))+`/logger_test.go:`) {
+			t.Errorf("Entry does not point at the test: %s", line)
+		}
+	}
+}
+
+func TestSampling(t *testing.T) {
+	logger, err := NewLogger(Config{Level: INFO, SampleInitial: 2, SampleThereafter: 3})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	var buf bytes.Buffer
+	logger.AddSink(NewWriterSink(&buf, &JSONFormatter{}))
+
+	now := time.Now().Truncate(time.Second)
+	logger.sampler.tick = now
+	for i := 0; i < 8; i++ {
+		logger.Info("request")
+		logger.Warn("slow")
+	}
+
+	// A second boundary during the loop resets the counters; retry rather
+	// than flake.
+	if time.Now().Truncate(time.Second).Equal(now) {
+		if n := strings.Count(buf.String(), `"message":"request"`); n != 4 {
+			t.Errorf("Expected 4 sampled INFO entries (1, 2, 5, 8), got %d", n)
+		}
+		if n := strings.Count(buf.String(), `"sample_rate":3`); n != 2 {
+			t.Errorf("Expected entries 5 and 8 to carry the sample rate, got %d", n)
+		}
+	}
+	if n := strings.Count(buf.String(), `"message":"slow"`); n != 8 {
+		t.Errorf("Expected all WARN entries, got %d", n)
+	}
+}
+
+func TestPresets(t *testing.T) {
+	dev := DevelopmentConfig()
+	if dev.Level != DEBUG || !dev.Caller || dev.ConsoleTheme == nil {
+		t.Errorf("Unexpected development config: %+v", dev)
+	}
+	prod := ProductionConfig("app.log")
+	if prod.Level != INFO || prod.Format != "json" || prod.LogToConsole || prod.MaxSizeMB == 0 || prod.SampleInitial == 0 {
+		t.Errorf("Unexpected production config: %+v", prod)
+	}
+
+	logger, err := NewProductionLogger(filepath.Join(t.TempDir(), "app.log"))
+	if err != nil {
+		t.Fatalf("Failed to create production logger: %v", err)
+	}
+	logger.Close()
+
+	logger, err = NewDevelopmentLogger()
+	if err != nil {
+		t.Fatalf("Failed to create development logger: %v", err)
+	}
+	var stdout bytes.Buffer
+	logger.stdout = &stdout
+	logger.Debug("debugging")
+	logger.Scope("work").Close()
+	logger.Close()
+	if !strings.Contains(stdout.String(), "debugging") || strings.Count(stdout.String(), "caller="+filepath.Base(filepath.Dir(packageDir))+"/logger_test.go:") != 3 {
+		t.Errorf("Expected DEBUG entries with the test as the caller, got: %s", stdout.String())
+	}
+}
+
+func TestStdlibFrame(t *testing.T) {
+	for function, want := range map[string]bool{
+		"sync.(*Once).doSlow":   true,
+		"net/http.(*Client).do": true,
+		"main.main":             false,
+		"github.com/samiullahsaleem/golog.TestStdlibFrame": false,
+		"example.com/app/internal/db.(*Pool).Get":          false,
+	} {
+		if got := stdlibFrame(function); got != want {
+			t.Errorf("stdlibFrame(%q) = %v, expected %v", function, got, want)
+		}
+	}
+}
+
+func TestTrackedFileSize(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "test.log")
+	os.WriteFile(logFile, []byte("existing line\n"), 0644)
+	logger, err := NewLogger(Config{Level: INFO, FilePath: logFile, MaxSizeMB: 1, MaxBackups: 1})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Info("hello")
+	info, err := os.Stat(logFile)
+	if err != nil {
+		t.Fatalf("Failed to stat log file: %v", err)
+	}
+	if logger.fileSize != info.Size() {
+		t.Errorf("Tracked size %d does not match file size %d", logger.fileSize, info.Size())
+	}
+
+	logger.fileSize = 1024 * 1024
+	logger.Info("after rotation")
+	info, _ = os.Stat(logFile)
+	if logger.fileSize != info.Size() || info.Size() > 100 {
+		t.Errorf("Expected a fresh tracked file after rotation, tracked %d, on disk %d", logger.fileSize, info.Size())
+	}
+}
+
+func BenchmarkRotationCheck(b *testing.B) {
+	logger, err := NewLogger(Config{Level: INFO, FilePath: filepath.Join(b.TempDir(), "bench.log"), MaxSizeMB: 100})
+	if err != nil {
+		b.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	b.Run("tracked", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			logger.rotateIfNeeded()
+		}
+	})
+	b.Run("stat", func(b *testing.B) {
+		size := logger.fileSize
+		logger.fileSize = -1
+		defer func() { logger.fileSize = size }()
+		for i := 0; i < b.N; i++ {
+			logger.rotateIfNeeded()
+		}
+	})
+}
+
+func BenchmarkFileLogging(b *testing.B) {
+	logger, err := NewLogger(Config{Level: INFO, FilePath: filepath.Join(b.TempDir(), "bench.log"), MaxSizeMB: 100, MaxBackups: 1})
+	if err != nil {
+		b.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	fields := map[string]interface{}{"user": "alice", "attempt": 3}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info("request handled", fields)
+	}
+}
+
+func TestStartPolicy(t *testing.T) {
+	tempDir := t.TempDir()
+	logFile := filepath.Join(tempDir, "run.log")
+
+	for run, policy := range []StartPolicy{StartRotate, StartRotate, StartTruncate} {
+		os.WriteFile(logFile, []byte("previous run\n"), 0644)
+		logger, err := NewLogger(Config{Level: INFO, FilePath: logFile, MaxBackups: 5, OnStart: policy})
+		if err != nil {
+			t.Fatalf("Failed to create logger: %v", err)
+		}
+		logger.Info("this run")
+		logger.Close()
+
+		content, _ := os.ReadFile(logFile)
+		if strings.Contains(string(content), "previous run") || !strings.Contains(string(content), "this run") {
+			t.Errorf("Run %d: unexpected log content: %s", run, content)
+		}
+	}
+
+	backups, _ := filepath.Glob(logFile + ".*")
+	if len(backups) != 2 {
+		t.Errorf("Expected one backup per rotated run, got %v", backups)
+	}
+	for _, backup := range backups {
+		if content, _ := os.ReadFile(backup); string(content) != "previous run\n" {
+			t.Errorf("Unexpected backup content in %s: %s", backup, content)
+		}
+	}
+}
+
+func TestMaxLineBytes(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "split.log")
+	logger, err := NewLogger(Config{Level: INFO, FilePath: logFile, Formatter: &JSONFormatter{}, MaxLineBytes: 200})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	payload := strings.Repeat("héllo \"world\" ", 60)
+	logger.Info("short")
+	logger.Info("big", map[string]interface{}{"payload": payload})
+	logger.Close()
+
+	content, _ := os.ReadFile(logFile)
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	if len(lines) < 3 || !strings.Contains(lines[0], `"short"`) {
+		t.Fatalf("Unexpected log content: %s", content)
+	}
+	var data strings.Builder
+	var id interface{}
+	for i, line := range lines[1:] {
+		if len(line)+1 > 200 {
+			t.Errorf("Record %d is %d bytes", i, len(line)+1)
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Record %d is not JSON: %v", i, err)
+		}
+		if id == nil {
+			id = record["entry_id"]
+		}
+		if record["message"] != SplitMessage || record["entry_id"] != id || record["part"] != float64(i+1) || record["parts"] != float64(len(lines)-1) {
+			t.Errorf("Unexpected record %d: %v", i, record)
+		}
+		data.WriteString(record["data"].(string))
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(data.String()), &entry); err != nil || entry["payload"] != payload {
+		t.Errorf("Reassembled entry does not match: %v %s", err, data.String())
+	}
+}
+
+func TestTraceFn(t *testing.T) {
+	logger, buf := newBufferLogger(t, DEBUG)
+	called := false
+	logger.TraceFn(func(l *Logger) { called = true })
+	if called {
+		t.Fatal("Expected TraceFn to skip fn above TRACE")
+	}
+
+	logger.SetLevel(TRACE)
+	for i := 0; i < 2; i++ {
+		logger.TraceFn(func(l *Logger) {
+			l.Trace("step", map[string]interface{}{"i": i})
+			l.Trace("detail")
+		})
+	}
+	logger.Trace("outside")
+
+	var seqs []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid JSON %q: %v", line, err)
+		}
+		seqs = append(seqs, fmt.Sprint(entry[TraceSeqKey]))
+	}
+	if strings.Join(seqs, ",") != "1,1,2,2,<nil>" {
+		t.Errorf("Unexpected trace sequence numbers: %v", seqs)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var order []string
+	logger, err := NewLogger(Config{Level: INFO, Middleware: []Middleware{
+		RenameFields(map[string]string{"userId": "user_id"}),
+		func(entry Entry) Entry {
+			order = append(order, "config")
+			entry.Fields["deployment"] = "canary"
+			return entry
+		},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	var buf bytes.Buffer
+	logger.AddSink(NewWriterSink(&buf, &JSONFormatter{}))
+	logger.Use(PrefixFields("app.", "deployment"), func(entry Entry) Entry {
+		order = append(order, "use")
+		return Entry{Time: entry.Time, Level: entry.Level, Message: strings.ToUpper(entry.Message)}
+	})
+
+	logger.Info("signed in", map[string]interface{}{"userId": 7, "Region": "eu"})
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", buf.String(), err)
+	}
+	if entry["message"] != "SIGNED IN" || len(entry) != 3 {
+		t.Errorf("Unexpected entry: %v", entry)
+	}
+	if strings.Join(order, ",") != "config,use" {
+		t.Errorf("Unexpected middleware order: %v", order)
+	}
+
+	fields := LowercaseKeys()(Entry{Fields: map[string]interface{}{"Region": "eu", "ID": 1}}).Fields
+	fields = PrefixFields("app.", "id")(RenameFields(map[string]string{"id": "id", "region": "zone"})(Entry{Fields: fields})).Fields
+	if len(fields) != 2 || fields["app.zone"] != "eu" || fields["id"] != 1 {
+		t.Errorf("Unexpected transformed fields: %v", fields)
+	}
+}
+
+func TestSchemaPolicies(t *testing.T) {
+	schema := &Schema{Fields: map[string]FieldRule{
+		"request_id": {Type: FieldString, Required: true},
+		"status":     {Type: FieldInt},
+		"latency":    {Type: FieldDuration},
+		"region":     {Enum: []string{"eu", "us"}},
+	}}
+	valid := &Entry{Message: "ok", Fields: map[string]interface{}{"request_id": "r1", "status": uint16(200), "latency": Duration(time.Second), "region": "eu"}}
+	if err := schema.Validate(valid); err != nil {
+		t.Errorf("Expected a valid entry, got %v", err)
+	}
+	err := schema.Validate(&Entry{Message: "bad", Fields: map[string]interface{}{"status": "200", "region": "apac"}})
+	if err == nil || err.Error() != "field region is apac, want one of eu, us\nmissing required field request_id\nfield status is string, want int" {
+		t.Errorf("Unexpected violations: %v", err)
+	}
+
+	logger, err := NewLogger(Config{Level: INFO, Schema: schema})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	var buf bytes.Buffer
+	logger.AddSink(NewWriterSink(&buf, &JSONFormatter{}))
+	logger.Info("annotated", map[string]interface{}{"status": 200})
+	if !strings.Contains(buf.String(), `"schema_errors":["missing required field request_id"]`) {
+		t.Errorf("Expected a schema_errors field, got %s", buf.String())
+	}
+
+	schema.Policy = SchemaDrop
+	buf.Reset()
+	logger.Info("dropped")
+	if buf.Len() != 0 {
+		t.Errorf("Expected the entry to be dropped, got %s", buf.String())
+	}
+
+	schema.Policy = SchemaPanic
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "violates schema") {
+			t.Errorf("Expected a schema panic, got %v", r)
+		}
+	}()
+	logger.Info("panics")
+}
+
+func TestCopyFrom(t *testing.T) {
+	logger, err := NewLogger(Config{Level: INFO})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	var buf bytes.Buffer
+	logger.AddSink(NewWriterSink(&buf, &JSONFormatter{}))
+
+	input := strings.Join([]string{
+		"plain output\r",
+		"",
+		`{"level":"warn","msg":"disk low","free_mb":12}`,
+		`{"level":"debug","message":"below the level"}`,
+		`{"message":"overrides","tool":"inner"}`,
+		"{not json",
+		strings.Repeat("x", maxCopyLine+10),
+	}, "\n")
+	if err := logger.CopyFrom(strings.NewReader(input), INFO, map[string]interface{}{"tool": "ffmpeg"}); err != nil {
+		t.Fatalf("Failed to copy: %v", err)
+	}
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Failed to parse entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("Expected 6 entries, got %d: %s", len(entries), buf.String())
+	}
+	if entries[0]["message"] != "plain output" || entries[0]["level"] != "INFO" || entries[0]["tool"] != "ffmpeg" {
+		t.Errorf("Unexpected plain entry: %v", entries[0])
+	}
+	if entries[1]["message"] != "disk low" || entries[1]["level"] != "WARN" || entries[1]["free_mb"] != 12.0 {
+		t.Errorf("Unexpected JSON entry: %v", entries[1])
+	}
+	if entries[2]["tool"] != "inner" || entries[3]["message"] != "{not json" {
+		t.Errorf("Unexpected entries: %v, %v", entries[2], entries[3])
+	}
+	if len(entries[4]["message"].(string)) != maxCopyLine || entries[5]["message"] != "xxxxxxxxxx" {
+		t.Errorf("Expected the long line to be split at %d bytes", maxCopyLine)
+	}
+}
+
+func TestCloseSummary(t *testing.T) {
+	newSummaryLogger := func() (*Logger, *bytes.Buffer) {
+		logger, err := NewLogger(Config{Level: WARN, Summary: true, SampleInitial: 1})
+		if err != nil {
+			t.Fatalf("Failed to create logger: %v", err)
+		}
+		var buf bytes.Buffer
+		logger.AddSink(NewWriterSink(&buf, &JSONFormatter{}))
+		return logger, &buf
+	}
+	lastEntry := func(buf *bytes.Buffer) map[string]interface{} {
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[len(lines)-1]), &entry); err != nil {
+			t.Fatalf("Failed to parse entry: %v", err)
+		}
+		return entry
+	}
+
+	logger, buf := newSummaryLogger()
+	logger.Info("below the level")
+	logger.Warn("slow")
+	logger.Named("db").Error("query failed", map[string]interface{}{"error": "timeout"})
+	logger.Error("write failed")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+	logger.Close()
+
+	summary := lastEntry(buf)
+	want := map[string]interface{}{
+		"message": SummaryMessage, "level": "INFO", "exit_code": 0.0, "entries": 3.0, "entries_warn": 1.0,
+		"entries_error": 2.0, "entries_info": 0.0, "errors": 2.0, "dropped": 0.0, "last_error": "write failed",
+	}
+	for k, v := range want {
+		if summary[k] != v {
+			t.Errorf("Expected %s=%v in the summary, got %v", k, v, summary[k])
+		}
+	}
+	if _, ok := summary["uptime"]; !ok {
+		t.Errorf("Expected an uptime in the summary: %v", summary)
+	}
+	if strings.Count(buf.String(), SummaryMessage) != 1 {
+		t.Errorf("Expected one summary, got: %s", buf.String())
+	}
+
+	// A job closing its logger from an exit handler reports the exit code.
+	logger, buf = newSummaryLogger()
+	logger.SetLevel(TRACE)
+	for i := 0; i < 3; i++ {
+		logger.Debug("retrying")
+	}
+	SetExitFunc(func(code int) {})
+	defer SetExitFunc(nil)
+	RegisterExitHandler(func() { logger.Close() })
+	Exit(3)
+	summary = lastEntry(buf)
+	if summary["exit_code"] != 3.0 || summary["level"] != "ERROR" || summary["dropped_sampled"] != 2.0 || summary["entries_debug"] != 1.0 {
+		t.Errorf("Unexpected summary: %v", summary)
+	}
 }
@@ -0,0 +1,141 @@
+package golog
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultCorrelationHeaders are the message headers searched, in order,
+// for a correlation ID.
+var DefaultCorrelationHeaders = []string{"correlation_id", "x-correlation-id", "x-request-id"}
+
+// Message describes a queue message for logging. Fill in what the broker
+// provides: Kafka has partitions and offsets, NATS and SQS do not.
+type Message struct {
+	Topic     string            // Topic, subject or queue name
+	Key       string            // Message key or ID
+	Partition int               // Partition, or 0
+	Offset    int64             // Offset or sequence number, or 0
+	Headers   map[string]string // Message headers or attributes
+}
+
+// MessageConfig holds message handling options.
+type MessageConfig struct {
+	Retries            int           // Retry a failing handler this many times before giving up
+	RetryBackoff       time.Duration // Delay before the first retry, doubled for each retry; defaults to 100ms
+	CorrelationHeaders []string      // Headers holding the correlation ID; defaults to DefaultCorrelationHeaders
+}
+
+// MessageHandler processes one message. The logger it is given adds the
+// message's topic, key, partition, offset and correlation ID to every entry.
+type MessageHandler func(ctx context.Context, logger *Logger, msg Message) error
+
+// HandleMessages wraps a consumer's handler so every message is logged once
+// processed, with its fields, the processing time, retry count and outcome:
+//
+//	handle := golog.HandleMessages(logger, golog.MessageConfig{Retries: 2}, processOrder)
+//	for msg := range deliveries {
+//		err := handle(ctx, golog.Message{Topic: msg.Topic, Key: string(msg.Key), ...})
+//	}
+//
+// Processed messages are logged at INFO, failed attempts that are retried at
+// WARN and messages that failed every attempt at ERROR. The handler's last
+// error is returned.
+func HandleMessages(l *Logger, config MessageConfig, handler MessageHandler) func(ctx context.Context, msg Message) error {
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = 100 * time.Millisecond
+	}
+	return func(ctx context.Context, msg Message) error {
+		logger := l.withStatic(messageFields(l, msg, config.CorrelationHeaders))
+		sw := NewStopwatch()
+		backoff := config.RetryBackoff
+
+		var err error
+		retries := 0
+		for {
+			if err = handler(ctx, logger, msg); err == nil || retries >= config.Retries || ctx.Err() != nil {
+				break
+			}
+			logger.Warn("message handler failed, retrying", map[string]interface{}{"error": err.Error(), "attempt": retries + 1})
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+			case <-timer.C:
+			}
+			backoff *= 2
+			retries++
+		}
+
+		fields := map[string]interface{}{"elapsed": Duration(sw.Elapsed()), "retries": retries, "outcome": "success"}
+		if err != nil {
+			fields["outcome"] = "failure"
+			fields["error"] = err.Error()
+			logger.Error("message failed", fields)
+			return err
+		}
+		logger.Info("message processed", fields)
+		return nil
+	}
+}
+
+// PublishMessage calls publish for msg and logs the outcome at DEBUG, or at
+// ERROR if publishing failed. A message without a correlation ID gets a new
+// one in its first DefaultCorrelationHeaders header, so consumers can tie
+// their entries to the producer's.
+func PublishMessage(l *Logger, msg Message, publish func(msg Message) error) error {
+	if correlationID(msg.Headers, nil) == "" {
+		headers := make(map[string]string, len(msg.Headers)+1)
+		for k, v := range msg.Headers {
+			headers[k] = v
+		}
+		headers[DefaultCorrelationHeaders[0]] = newScopeID()
+		msg.Headers = headers
+	}
+
+	sw := NewStopwatch()
+	err := publish(msg)
+	fields := messageFields(l, msg, nil)
+	fields["elapsed"] = Duration(sw.Elapsed())
+	if err != nil {
+		fields["error"] = err.Error()
+		l.log(ERROR, "message publish failed", fields)
+		return err
+	}
+	l.log(DEBUG, "message published", fields)
+	return nil
+}
+
+// messageFields returns l's static fields and the message's fields.
+func messageFields(l *Logger, msg Message, headers []string) map[string]interface{} {
+	fields := make(map[string]interface{}, len(l.fields)+6)
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	fields["topic"] = msg.Topic
+	if msg.Key != "" {
+		fields["message_key"] = msg.Key
+	}
+	if msg.Partition != 0 || msg.Offset != 0 {
+		fields["partition"] = msg.Partition
+		fields["offset"] = msg.Offset
+	}
+	if id := correlationID(msg.Headers, headers); id != "" {
+		fields["correlation_id"] = id
+	}
+	return fields
+}
+
+// correlationID returns the first non-empty value of headers in h, using
+// DefaultCorrelationHeaders when headers is nil.
+func correlationID(h map[string]string, headers []string) string {
+	if headers == nil {
+		headers = DefaultCorrelationHeaders
+	}
+	for _, name := range headers {
+		if v := h[name]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package golog
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleMessages(t *testing.T) {
+	logger, buf := newBufferLogger(t, INFO)
+	calls := 0
+	handle := HandleMessages(logger, MessageConfig{Retries: 2, RetryBackoff: time.Millisecond}, func(ctx context.Context, l *Logger, msg Message) error {
+		calls++
+		if calls == 1 {
+			return errors.New("database busy")
+		}
+		l.Info("charging order")
+		return nil
+	})
+
+	msg := Message{Topic: "orders", Key: "order-42", Partition: 3, Offset: 1017, Headers: map[string]string{"x-correlation-id": "abc123"}}
+	if err := handle(context.Background(), msg); err != nil {
+		t.Fatalf("Expected handler to succeed after a retry: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{
+		`"attempt":1,"correlation_id":"abc123","error":"database busy","level":"WARN"`,
+		`"correlation_id":"abc123","level":"INFO","message":"charging order","message_key":"order-42","offset":1017,"partition":3,`,
+		`"message":"message processed","message_key":"order-42","offset":1017,"outcome":"success","partition":3,"retries":1`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Message log does not contain %s:\n%s", expected, output)
+		}
+	}
+
+	buf.Reset()
+	failing := HandleMessages(logger, MessageConfig{}, func(ctx context.Context, l *Logger, msg Message) error {
+		return errors.New("invalid payload")
+	})
+	if err := failing(context.Background(), Message{Topic: "orders"}); err == nil {
+		t.Errorf("Expected handler error to be returned")
+	}
+	if !strings.Contains(buf.String(), `"level":"ERROR","message":"message failed","outcome":"failure"`) {
+		t.Errorf("Unexpected failure entry: %s", buf.String())
+	}
+}
+
+func TestPublishMessage(t *testing.T) {
+	logger, buf := newBufferLogger(t, DEBUG)
+	var sent Message
+	err := PublishMessage(logger, Message{Topic: "orders", Key: "order-42"}, func(msg Message) error {
+		sent = msg
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	id := sent.Headers["correlation_id"]
+	if len(id) != 16 || !strings.Contains(buf.String(), `"correlation_id":"`+id+`"`) {
+		t.Errorf("Correlation ID %q was not added and logged: %s", id, buf.String())
+	}
+}
@@ -0,0 +1,93 @@
+package golog
+
+import "strings"
+
+// Middleware transforms an entry before it reaches hooks, formatters and
+// sinks, for cross-cutting changes such as renaming legacy fields or
+// injecting deployment metadata. The entry's Fields map is its own and may
+// be modified in place. Changing Level does not re-check the logger's
+// level.
+type Middleware func(entry Entry) Entry
+
+// Use appends middleware to the logger. Middleware runs in the order it was
+// added; a named logger's middleware runs before its parent's, ending with
+// the root logger's. Middleware configured with Config.Middleware runs
+// first.
+func (l *Logger) Use(middleware ...Middleware) {
+	l.middlewareMu.Lock()
+	defer l.middlewareMu.Unlock()
+	var chain []Middleware
+	if current := l.middleware.Load(); current != nil {
+		chain = append(chain, *current...)
+	}
+	chain = append(chain, middleware...)
+	l.middleware.Store(&chain)
+}
+
+// applyMiddleware runs the middleware of l and its ancestors on entry.
+func (l *Logger) applyMiddleware(entry *Entry) {
+	for cur := l; cur != nil; cur = cur.parent.Load() {
+		chain := cur.middleware.Load()
+		if chain == nil {
+			continue
+		}
+		for _, mw := range *chain {
+			*entry = mw(*entry)
+			if entry.Fields == nil {
+				entry.Fields = make(map[string]interface{})
+			}
+		}
+	}
+}
+
+// RenameFields returns middleware renaming fields, such as legacy names
+// during a migration. A renamed field replaces any field already using the
+// new name.
+func RenameFields(names map[string]string) Middleware {
+	return func(entry Entry) Entry {
+		for from, to := range names {
+			if v, ok := entry.Fields[from]; ok {
+				delete(entry.Fields, from)
+				entry.Fields[to] = v
+			}
+		}
+		return entry
+	}
+}
+
+// LowercaseKeys returns middleware lower-casing field names.
+func LowercaseKeys() Middleware {
+	return func(entry Entry) Entry {
+		for k, v := range entry.Fields {
+			if lower := strings.ToLower(k); lower != k {
+				delete(entry.Fields, k)
+				if _, ok := entry.Fields[lower]; !ok {
+					entry.Fields[lower] = v
+				}
+			}
+		}
+		return entry
+	}
+}
+
+// PrefixFields returns middleware namespacing fields with prefix, e.g.
+// "app." so application fields cannot clash with those of a shared
+// pipeline. Fields named in except keep their names.
+func PrefixFields(prefix string, except ...string) Middleware {
+	keep := make(map[string]bool, len(except))
+	for _, name := range except {
+		keep[name] = true
+	}
+	return func(entry Entry) Entry {
+		fields := make(map[string]interface{}, len(entry.Fields))
+		for k, v := range entry.Fields {
+			if keep[k] {
+				fields[k] = v
+			} else {
+				fields[prefix+k] = v
+			}
+		}
+		entry.Fields = fields
+		return entry
+	}
+}
@@ -0,0 +1,289 @@
+package golog
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MQTTConfig holds MQTT sink options.
+type MQTTConfig struct {
+	Broker      string        // Broker URL, e.g. "tcp://broker:1883"; "ssl://" or "tls://" connect with TLS
+	Topic       string        // Topic template, e.g. "devices/{device_id}/logs/{level}"; placeholders are filled from fields
+	QoS         byte          // 0 (at most once) or 1 (at least once, waiting for the broker's acknowledgement)
+	Retained    bool          // Publish retained messages, so new subscribers get each topic's latest entry
+	ClientID    string        // MQTT client ID; defaults to "golog-<host>-<pid>"
+	Username    string        // User name
+	Password    string        // Password
+	TLSConfig   *tls.Config   // TLS options; setting it also enables TLS for tcp:// brokers
+	Formatter   Formatter     // Payload format; defaults to JSONFormatter
+	DialTimeout time.Duration // Connection and acknowledgement timeout; defaults to 5s
+}
+
+// MQTT packet types.
+const (
+	mqttConnect    = 0x10
+	mqttConnack    = 0x20
+	mqttPublish    = 0x30
+	mqttPuback     = 0x40
+	mqttDisconnect = 0xE0
+)
+
+// MQTTSink publishes entries to MQTT topics using MQTT 3.1.1, for devices
+// whose standard channel to the backend is a broker. The sink connects on
+// first write and reconnects after a failure; wrap it in a SpoolSink to keep
+// entries while the broker is unreachable. Placeholders without a field
+// become "unknown", and slashes and wildcards in field values are replaced
+// with underscores so a value cannot add topic levels.
+type MQTTSink struct {
+	mutex    sync.Mutex
+	config   MQTTConfig
+	address  string
+	tls      *tls.Config
+	conn     net.Conn
+	reader   *bufio.Reader
+	packetID uint16
+}
+
+// NewMQTTSink creates a sink publishing to the broker at config.Broker.
+func NewMQTTSink(config MQTTConfig) (*MQTTSink, error) {
+	if config.Topic == "" {
+		return nil, fmt.Errorf("MQTT sink requires a topic")
+	}
+	if config.QoS > 1 {
+		return nil, fmt.Errorf("unsupported MQTT QoS %d", config.QoS)
+	}
+	u, err := url.Parse(config.Broker)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid MQTT broker URL %q", config.Broker)
+	}
+	tlsConfig := config.TLSConfig
+	port := "1883"
+	switch u.Scheme {
+	case "ssl", "tls", "mqtts":
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		port = "8883"
+	case "tcp", "mqtt":
+	default:
+		return nil, fmt.Errorf("unsupported MQTT broker scheme %q", u.Scheme)
+	}
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), port)
+	}
+	if config.ClientID == "" {
+		host, _ := os.Hostname()
+		config.ClientID = fmt.Sprintf("golog-%s-%d", host, os.Getpid())
+	}
+	if config.Formatter == nil {
+		config.Formatter = &JSONFormatter{}
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = 5 * time.Second
+	}
+	return &MQTTSink{config: config, address: address, tls: tlsConfig}, nil
+}
+
+// mqttTopicLevel makes a field value a single topic level.
+var mqttTopicLevel = strings.NewReplacer("/", "_", "+", "_", "#", "_", "\x00", "_")
+
+// Write implements Sink. A publish that fails on an established connection
+// is retried once on a new one.
+func (s *MQTTSink) Write(entry *Entry) error {
+	topic := expandName(s.config.Topic, entry, mqttTopicLevel.Replace, "unknown")
+	payload := strings.TrimSuffix(formatEntry(s.config.Formatter, entry), "\n")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	reused := s.conn != nil
+	err := s.publish(topic, payload)
+	if err != nil && reused {
+		err = s.publish(topic, payload)
+	}
+	return err
+}
+
+// publish sends one message, connecting first if needed; s.mutex must be
+// held.
+func (s *MQTTSink) publish(topic, payload string) error {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+
+	flags := byte(mqttPublish) | s.config.QoS<<1
+	if s.config.Retained {
+		flags |= 0x01
+	}
+	body := mqttString(topic)
+	var id uint16
+	if s.config.QoS > 0 {
+		s.packetID++
+		if s.packetID == 0 {
+			s.packetID = 1
+		}
+		id = s.packetID
+		body = binary.BigEndian.AppendUint16(body, id)
+	}
+	body = append(body, payload...)
+
+	s.conn.SetDeadline(time.Now().Add(s.config.DialTimeout))
+	if _, err := s.conn.Write(mqttPacket(flags, body)); err != nil {
+		s.disconnect()
+		return fmt.Errorf("failed to publish to MQTT broker: %v", err)
+	}
+	if s.config.QoS == 0 {
+		return nil
+	}
+	for {
+		packetType, body, err := s.readPacket()
+		if err != nil {
+			s.disconnect()
+			return fmt.Errorf("failed to read MQTT acknowledgement: %v", err)
+		}
+		if packetType == mqttPuback && len(body) >= 2 && binary.BigEndian.Uint16(body) == id {
+			return nil
+		}
+	}
+}
+
+// connect opens a session with the broker; s.mutex must be held.
+func (s *MQTTSink) connect() error {
+	conn, err := dialNetwork("tcp", s.address, s.config.DialTimeout, s.tls)
+	if err != nil {
+		return fmt.Errorf("failed to connect to MQTT broker: %v", err)
+	}
+	s.conn, s.reader = conn, bufio.NewReader(conn)
+
+	// Protocol name and level 4 (3.1.1), a clean session and no keepalive;
+	// a connection dropped while idle is replaced on the next write.
+	body := append(mqttString("MQTT"), 4, 0x02, 0, 0)
+	if s.config.Username != "" {
+		body[7] |= 0x80
+	}
+	if s.config.Password != "" {
+		body[7] |= 0x40
+	}
+	body = append(body, mqttString(s.config.ClientID)...)
+	if s.config.Username != "" {
+		body = append(body, mqttString(s.config.Username)...)
+	}
+	if s.config.Password != "" {
+		body = append(body, mqttString(s.config.Password)...)
+	}
+
+	conn.SetDeadline(time.Now().Add(s.config.DialTimeout))
+	if _, err := conn.Write(mqttPacket(mqttConnect, body)); err != nil {
+		s.disconnect()
+		return fmt.Errorf("failed to connect to MQTT broker: %v", err)
+	}
+	packetType, ack, err := s.readPacket()
+	if err != nil || packetType != mqttConnack || len(ack) < 2 {
+		s.disconnect()
+		return fmt.Errorf("failed to read MQTT connection acknowledgement: %v", err)
+	}
+	if code := ack[1]; code != 0 {
+		s.disconnect()
+		return fmt.Errorf("MQTT broker refused connection: %s", mqttRefusal(code))
+	}
+	return nil
+}
+
+// readPacket reads one control packet; s.mutex must be held.
+func (s *MQTTSink) readPacket() (byte, []byte, error) {
+	header, err := s.reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		b, err := s.reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7F) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		if i == 3 {
+			return 0, nil, fmt.Errorf("malformed packet length")
+		}
+		multiplier *= 128
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, body); err != nil {
+		return 0, nil, err
+	}
+	return header & 0xF0, body, nil
+}
+
+// disconnect drops the connection; s.mutex must be held.
+func (s *MQTTSink) disconnect() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn, s.reader = nil, nil
+	}
+}
+
+// Close implements Sink, ending the session cleanly.
+func (s *MQTTSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.conn != nil {
+		s.conn.SetDeadline(time.Now().Add(s.config.DialTimeout))
+		s.conn.Write(mqttPacket(mqttDisconnect, nil))
+	}
+	s.disconnect()
+	return nil
+}
+
+// mqttPacket frames a control packet.
+func mqttPacket(header byte, body []byte) []byte {
+	packet := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(packet, body...)
+}
+
+// mqttString encodes a length-prefixed UTF-8 string.
+func mqttString(s string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(len(s))), s...)
+}
+
+// mqttRefusal describes a CONNACK return code.
+func mqttRefusal(code byte) string {
+	switch code {
+	case 1:
+		return "unacceptable protocol version"
+	case 2:
+		return "client ID rejected"
+	case 3:
+		return "server unavailable"
+	case 4:
+		return "bad user name or password"
+	case 5:
+		return "not authorized"
+	}
+	return fmt.Sprintf("return code %d", code)
+}
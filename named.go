@@ -0,0 +1,111 @@
+package golog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// levelInherit marks a named logger whose level follows its parent.
+const levelInherit = -1
+
+// RootLogger returns the logger that named loggers write through.
+func RootLogger() *Logger {
+	return DefaultRegistry.Root()
+}
+
+// SetRootLogger replaces the logger that named loggers write through.
+// Existing named loggers switch to the new root immediately.
+func SetRootLogger(root *Logger) {
+	DefaultRegistry.SetRoot(root)
+}
+
+// GetLogger returns the named logger for a component, creating it on first
+// use. Names are dot-separated hierarchies ("db.pool"); a named logger
+// without an explicit level inherits the level of its parent ("db"), and
+// top-level names inherit from the root logger. Entries are written through
+// the root logger with a "logger" field holding the name.
+func GetLogger(name string) *Logger {
+	return DefaultRegistry.Get(name)
+}
+
+// SetLevels applies a level configuration such as
+// "db=debug,http=warn,root=info" to DefaultRegistry.
+func SetLevels(spec string) error {
+	return DefaultRegistry.SetLevels(spec)
+}
+
+// SetLevelFor temporarily sets a component's level in DefaultRegistry,
+// reverting it after d. Use "root" to lower the threshold globally.
+func SetLevelFor(name string, level LogLevel, d time.Duration) (revert func()) {
+	return DefaultRegistry.SetLevelFor(name, level, d)
+}
+
+// ParseLevel converts a level name such as "debug" or "WARN" to a LogLevel.
+func ParseLevel(s string) (LogLevel, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "TRACE":
+		return TRACE, nil
+	case "DEBUG":
+		return DEBUG, nil
+	case "INFO":
+		return INFO, nil
+	case "WARN", "WARNING":
+		return WARN, nil
+	case "ERROR":
+		return ERROR, nil
+	case "FATAL":
+		return FATAL, nil
+	}
+	return INFO, fmt.Errorf("unknown log level %q", s)
+}
+
+// Name returns the logger's component name, or "" for unnamed loggers.
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// Named returns a logger for a component of l, named by joining l's name
+// and name with a dot: Named("payments").Named("stripe") is the registry's
+// logger for "payments.stripe", so SetLevels and the other Registry
+// operations apply to it. A logger from GetLogger resolves names in its
+// registry, and a root logger in the registry it was last made the root of;
+// Named on any other logger makes it the root of a new registry.
+func (l *Logger) Named(name string) *Logger {
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	r := l.registry.Load()
+	if r == nil {
+		l.registry.CompareAndSwap(nil, newRegistry(l))
+		r = l.registry.Load()
+	}
+	return r.Get(name)
+}
+
+// Level returns the logger's effective minimum level.
+func (l *Logger) Level() LogLevel {
+	for cur := l; cur != nil; cur = cur.parent.Load() {
+		if level := cur.level.Load(); level != levelInherit {
+			return LogLevel(level)
+		}
+	}
+	return INFO
+}
+
+// SetLevel changes the logger's minimum level at runtime. It cancels any
+// pending WithLevelFor override.
+func (l *Logger) SetLevel(level LogLevel) {
+	l.storeLevel(int32(level))
+}
+
+// root returns the logger that owns the outputs this logger writes to.
+func (l *Logger) root() *Logger {
+	for {
+		parent := l.parent.Load()
+		if parent == nil {
+			return l
+		}
+		l = parent
+	}
+}
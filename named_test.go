@@ -0,0 +1,137 @@
+package golog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNamedLoggerLevels(t *testing.T) {
+	tempDir := t.TempDir()
+	logFile := filepath.Join(tempDir, "test.log")
+
+	root, err := NewLogger(Config{Level: INFO, FilePath: logFile, MaxSizeMB: 1})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer root.Close()
+
+	previous := RootLogger()
+	SetRootLogger(root)
+	defer SetRootLogger(previous)
+
+	if err := SetLevels("db=debug, http=warn, root=info"); err != nil {
+		t.Fatalf("Failed to set levels: %v", err)
+	}
+	defer SetLevels("")
+
+	GetLogger("db.pool").Debug("db debug")
+	GetLogger("http").Info("http info")
+	GetLogger("cache").Debug("cache debug")
+	GetLogger("cache").Info("cache info")
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	output := string(content)
+
+	if !strings.Contains(output, "DEBUG db debug logger=db.pool") {
+		t.Errorf("Expected debug entry from db.pool, got: %s", output)
+	}
+	if strings.Contains(output, "http info") || strings.Contains(output, "cache debug") {
+		t.Errorf("Entries below the component level were logged: %s", output)
+	}
+	if !strings.Contains(output, "INFO cache info logger=cache") {
+		t.Errorf("Expected cache entry at inherited root level, got: %s", output)
+	}
+
+	if err := SetLevels("db=loud"); err == nil {
+		t.Errorf("Expected error for unknown level")
+	}
+}
+
+func TestWithLevelFor(t *testing.T) {
+	registry := NewRegistry(&Logger{})
+	registry.Root().SetLevel(INFO)
+	db := registry.Get("db")
+
+	revert := registry.SetLevelFor("db", DEBUG, time.Hour)
+	if db.Level() != DEBUG {
+		t.Fatalf("Expected DEBUG during override, got %v", db.Level())
+	}
+	db.WithLevelFor(time.Hour, TRACE)
+	revert()
+	if db.Level() != TRACE {
+		t.Errorf("Superseded revert changed the level to %v", db.Level())
+	}
+
+	db.WithLevelFor(10*time.Millisecond, TRACE)
+	time.Sleep(50 * time.Millisecond)
+	if db.Level() != INFO {
+		t.Errorf("Expected level to revert to inherited INFO, got %v", db.Level())
+	}
+
+	registry.Root().WithLevelFor(time.Hour, DEBUG)
+	registry.Root().SetLevel(WARN)
+	if registry.Root().override != nil || db.Level() != WARN {
+		t.Errorf("SetLevel did not cancel the pending override")
+	}
+}
+
+func TestLoggerNamed(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(Config{Level: INFO})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.AddSink(NewWriterSink(&buf, &TextFormatter{}))
+
+	previous := RootLogger()
+	SetRootLogger(logger)
+	defer SetRootLogger(previous)
+	if err := SetLevels("payments=warn,payments.stripe=debug"); err != nil {
+		t.Fatalf("Failed to set levels: %v", err)
+	}
+	defer SetLevels("")
+
+	payments := logger.Named("payments")
+	stripe := payments.Named("stripe")
+	if stripe != GetLogger("payments.stripe") || logger.Named("payments.stripe") != stripe {
+		t.Errorf("Expected Named to return the registry's logger for the joined name")
+	}
+	payments.Info("payments info")
+	stripe.Debug("stripe debug")
+	payments.Named("paypal").Warn("paypal warn")
+	logger.Named("payments.stripe.webhooks").Debug("webhook debug")
+	logger.Debug("root debug")
+
+	output := buf.String()
+	for _, want := range []string{
+		"DEBUG stripe debug logger=payments.stripe",
+		"WARN paypal warn logger=payments.paypal",
+		"DEBUG webhook debug logger=payments.stripe.webhooks",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q, got: %s", want, output)
+		}
+	}
+	if strings.Contains(output, "payments info") || strings.Contains(output, "root debug") {
+		t.Errorf("Entries below the component level were logged: %s", output)
+	}
+
+	if err := SetLevels("payments.stripe=error"); err != nil {
+		t.Fatalf("Failed to set levels: %v", err)
+	}
+	if stripe.Level() != ERROR || payments.Level() != INFO {
+		t.Errorf("Expected SetLevels to control Named loggers, got %v and %v", stripe.Level(), payments.Level())
+	}
+
+	standalone := &Logger{}
+	if db := standalone.Named("db"); db.Named("pool") != standalone.Named("db.pool") || db.parent.Load() != standalone {
+		t.Errorf("Expected Named on a standalone logger to resolve names in its own registry")
+	}
+}
@@ -0,0 +1,245 @@
+package golog
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATSConfig holds NATS sink options.
+type NATSConfig struct {
+	URL         string        // Server URL, e.g. "nats://localhost:4222"; "tls://" connects with TLS
+	Subject     string        // Subject template, e.g. "logs.{service}.{level}"; placeholders are filled from fields
+	Token       string        // Authentication token
+	User        string        // User name; also taken from the URL
+	Password    string        // Password; also taken from the URL
+	TLSConfig   *tls.Config   // TLS options, used for tls:// URLs and servers that require TLS
+	Formatter   Formatter     // Payload format; defaults to JSONFormatter
+	Batch       BatchConfig   // Batching options
+	DialTimeout time.Duration // Connection and acknowledgement timeout; defaults to 5s
+}
+
+// NATSSink publishes entries to NATS subjects. Entries are sent in batches,
+// each confirmed with a PING round trip, so a batch the server rejects is
+// reported instead of lost silently. Subject placeholders without a field
+// become "unknown", and dots, wildcards and whitespace in field values are
+// replaced with underscores so a value cannot add subject tokens.
+type NATSSink struct {
+	mutex   sync.Mutex
+	config  NATSConfig
+	address string
+	tls     bool
+	batch   *batcher
+	conn    net.Conn
+	reader  *bufio.Reader
+	writer  *bufio.Writer
+}
+
+// NewNATSSink creates a sink publishing to the server at config.URL. It
+// connects on first publish and reconnects after a failure.
+func NewNATSSink(config NATSConfig) (*NATSSink, error) {
+	if config.Subject == "" {
+		return nil, fmt.Errorf("NATS sink requires a subject")
+	}
+	if config.URL == "" {
+		config.URL = "nats://localhost:4222"
+	}
+	u, err := url.Parse(config.URL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid NATS URL %q", config.URL)
+	}
+	if u.User != nil && config.User == "" {
+		config.User = u.User.Username()
+		config.Password, _ = u.User.Password()
+	}
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if config.Formatter == nil {
+		config.Formatter = &JSONFormatter{}
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = 5 * time.Second
+	}
+
+	s := &NATSSink{config: config, address: address, tls: u.Scheme == "tls"}
+	s.batch = newBatcher(config.Batch, s.publish)
+	return s, nil
+}
+
+// natsSubjectToken makes a field value a single subject token.
+var natsSubjectToken = strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_", "\t", "_", "\r", "_", "\n", "_")
+
+// Write implements Sink.
+func (s *NATSSink) Write(entry *Entry) error {
+	return s.batch.add(entry)
+}
+
+// Flush implements Flusher.
+func (s *NATSSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close implements Sink, sending any queued entries first.
+func (s *NATSSink) Close() error {
+	err := s.batch.flush()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.disconnect()
+	return err
+}
+
+// publish sends a batch, retrying once on a new connection if an
+// established one failed. A batch the server rejected is not resent.
+func (s *NATSSink) publish(entries []*Entry) error {
+	var frames strings.Builder
+	for _, entry := range entries {
+		subject := expandName(s.config.Subject, entry, natsSubjectToken.Replace, "unknown")
+		payload := strings.TrimSuffix(formatEntry(s.config.Formatter, entry), "\n")
+		fmt.Fprintf(&frames, "PUB %s %d\r\n%s\r\n", subject, len(payload), payload)
+	}
+	frames.WriteString("PING\r\n")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	reused := s.conn != nil
+	err := s.send(frames.String())
+	if _, rejected := err.(natsError); err != nil && reused && !rejected {
+		err = s.send(frames.String())
+	}
+	return err
+}
+
+// send writes frames and waits for the PONG acknowledging them; s.mutex
+// must be held. The connection is dropped on I/O errors but kept when the
+// server rejects a message and still acknowledges the batch.
+func (s *NATSSink) send(frames string) error {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+	s.conn.SetDeadline(time.Now().Add(s.config.DialTimeout))
+	s.writer.WriteString(frames)
+	if err := s.writer.Flush(); err != nil {
+		s.disconnect()
+		return fmt.Errorf("failed to publish to NATS: %v", err)
+	}
+	if err := s.awaitPong(); err != nil {
+		if _, rejected := err.(natsError); !rejected {
+			s.disconnect()
+		}
+		return err
+	}
+	return nil
+}
+
+// connect opens a connection and authenticates; s.mutex must be held.
+func (s *NATSSink) connect() error {
+	conn, err := net.DialTimeout("tcp", s.address, s.config.DialTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(s.config.DialTimeout))
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("failed to read NATS server info: %v", err)
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info)
+
+	if s.tls || info.TLSRequired {
+		tlsConfig := s.config.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig = tlsConfig.Clone()
+			tlsConfig.ServerName, _, _ = net.SplitHostPort(s.address)
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to negotiate TLS with NATS: %v", err)
+		}
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+
+	options, _ := json.Marshal(map[string]interface{}{
+		"verbose":      false,
+		"pedantic":     false,
+		"tls_required": s.tls || info.TLSRequired,
+		"name":         "golog",
+		"lang":         "go",
+		"version":      "1",
+		"user":         s.config.User,
+		"pass":         s.config.Password,
+		"auth_token":   s.config.Token,
+	})
+	s.conn, s.reader, s.writer = conn, reader, bufio.NewWriter(conn)
+	s.writer.WriteString("CONNECT " + string(options) + "\r\nPING\r\n")
+	if err := s.writer.Flush(); err != nil {
+		s.disconnect()
+		return fmt.Errorf("failed to connect to NATS: %v", err)
+	}
+	if err := s.awaitPong(); err != nil {
+		s.disconnect()
+		return err
+	}
+	return nil
+}
+
+// natsError is an -ERR reply from the NATS server.
+type natsError string
+
+func (e natsError) Error() string {
+	return "NATS server error: " + string(e)
+}
+
+// awaitPong reads server messages until a PONG, answering server PINGs,
+// and returns the first -ERR reply as natsError. If the server closes the
+// connection after an -ERR, as it does for most errors, the connection is
+// dropped and the natsError returned; s.mutex must be held.
+func (s *NATSSink) awaitPong() error {
+	var rejected error
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			if rejected != nil {
+				s.disconnect()
+				return rejected
+			}
+			return fmt.Errorf("failed to read NATS acknowledgement: %v", err)
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return rejected
+		case line == "PING":
+			s.writer.WriteString("PONG\r\n")
+			s.writer.Flush()
+		case strings.HasPrefix(line, "-ERR") && rejected == nil:
+			rejected = natsError(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+// disconnect drops the connection; s.mutex must be held.
+func (s *NATSSink) disconnect() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn, s.reader, s.writer = nil, nil, nil
+	}
+}
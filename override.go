@@ -0,0 +1,56 @@
+package golog
+
+import "time"
+
+// levelOverride is a pending WithLevelFor revert.
+type levelOverride struct {
+	previous int32
+	timer    *time.Timer
+}
+
+// WithLevelFor sets the logger's minimum level for d and then restores the
+// level it had before, for debugging sessions that should not leave DEBUG
+// on forever. Calling the returned function reverts early. Overlapping
+// calls extend the window and still revert to the original level; a
+// SetLevel call during the window cancels the revert.
+func (l *Logger) WithLevelFor(d time.Duration, level LogLevel) (revert func()) {
+	l.overrideMu.Lock()
+	defer l.overrideMu.Unlock()
+
+	previous := l.level.Load()
+	if l.override != nil {
+		previous = l.override.previous
+		l.override.timer.Stop()
+	}
+	o := &levelOverride{previous: previous}
+	o.timer = time.AfterFunc(d, func() { l.endOverride(o) })
+	l.override = o
+	l.level.Store(int32(level))
+
+	return func() { l.endOverride(o) }
+}
+
+// endOverride restores the level saved by o if o is still the active override.
+func (l *Logger) endOverride(o *levelOverride) {
+	l.overrideMu.Lock()
+	defer l.overrideMu.Unlock()
+
+	if l.override != o {
+		return
+	}
+	o.timer.Stop()
+	l.override = nil
+	l.level.Store(o.previous)
+}
+
+// storeLevel sets the raw level and cancels any pending override.
+func (l *Logger) storeLevel(level int32) {
+	l.overrideMu.Lock()
+	defer l.overrideMu.Unlock()
+
+	if l.override != nil {
+		l.override.timer.Stop()
+		l.override = nil
+	}
+	l.level.Store(level)
+}
@@ -0,0 +1,43 @@
+package golog
+
+// DevelopmentConfig returns the configuration used by NewDevelopmentLogger:
+// colored text on the console at DEBUG, with the caller of every entry.
+func DevelopmentConfig() Config {
+	return Config{
+		Level:        DEBUG,
+		LogToConsole: true,
+		Format:       "text",
+		ConsoleTheme: DefaultTheme,
+		Caller:       true,
+	}
+}
+
+// ProductionConfig returns the configuration used by NewProductionLogger:
+// JSON at INFO, sampled to the first 100 identical entries per second and
+// every 100th after that, written to filePath with rotation and compression,
+// or to the console when filePath is empty, as in containers.
+func ProductionConfig(filePath string) Config {
+	return Config{
+		Level:            INFO,
+		FilePath:         filePath,
+		LogToConsole:     filePath == "",
+		Format:           "json",
+		MaxSizeMB:        100,
+		MaxBackups:       10,
+		Compress:         true,
+		SampleInitial:    100,
+		SampleThereafter: 100,
+	}
+}
+
+// NewDevelopmentLogger returns a logger with human-friendly defaults for
+// local development; see DevelopmentConfig.
+func NewDevelopmentLogger() (*Logger, error) {
+	return NewLogger(DevelopmentConfig())
+}
+
+// NewProductionLogger returns a logger with defaults suited to production;
+// see ProductionConfig.
+func NewProductionLogger(filePath string) (*Logger, error) {
+	return NewLogger(ProductionConfig(filePath))
+}
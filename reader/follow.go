@@ -0,0 +1,229 @@
+package reader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samiullahsaleem/golog"
+)
+
+// defaultPollInterval is how often a Follower checks for new data.
+const defaultPollInterval = 250 * time.Millisecond
+
+// FollowConfig holds Follower configuration options.
+type FollowConfig struct {
+	FromEnd      bool          // Start at the end of the file instead of the beginning
+	Format       Format        // Defaults to FormatAuto
+	Filter       Filter        // Entries that do not match are dropped
+	PollInterval time.Duration // Defaults to 250ms
+}
+
+// Follower streams entries appended to a log file, like tail -F. It keeps
+// following the path across rotation and truncation.
+type Follower struct {
+	path    string
+	config  FollowConfig
+	entries chan *golog.Entry
+	done    chan struct{}
+	once    sync.Once
+	mutex   sync.Mutex
+	err     error
+}
+
+// Follow starts following the file at path. If fromEnd is true only
+// entries written after the call are delivered.
+func Follow(path string, fromEnd bool) (*Follower, error) {
+	return FollowWithConfig(path, FollowConfig{FromEnd: fromEnd})
+}
+
+// FollowWithConfig starts following the file at path with the given options.
+// The file does not need to exist yet.
+func FollowWithConfig(path string, config FollowConfig) (*Follower, error) {
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultPollInterval
+	}
+	f := &Follower{
+		path:    path,
+		config:  config,
+		entries: make(chan *golog.Entry, 64),
+		done:    make(chan struct{}),
+	}
+
+	file, err := os.Open(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to open log file: %v", err)
+	}
+	if file != nil && config.FromEnd {
+		if _, err := file.Seek(0, io.SeekEnd); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to seek log file: %v", err)
+		}
+	}
+
+	go f.run(file)
+	return f, nil
+}
+
+// Entries returns the channel of followed entries. It is closed after
+// Close is called or a fatal error occurs; see Err.
+func (f *Follower) Entries() <-chan *golog.Entry {
+	return f.entries
+}
+
+// Err returns the error that stopped the follower, if any.
+func (f *Follower) Err() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.err
+}
+
+// Close stops following. The Entries channel is closed shortly after.
+func (f *Follower) Close() error {
+	f.once.Do(func() { close(f.done) })
+	return nil
+}
+
+// run is the follower's polling loop.
+func (f *Follower) run(file *os.File) {
+	defer close(f.entries)
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+
+	var (
+		br      *bufio.Reader
+		partial strings.Builder
+		pending *golog.Entry
+		cont    continuation
+		offset  int64
+	)
+	if file != nil {
+		br = bufio.NewReader(file)
+		offset, _ = file.Seek(0, io.SeekCurrent)
+	}
+
+	emit := func() bool {
+		if pending == nil {
+			return true
+		}
+		entry := pending
+		pending = nil
+		if !f.config.Filter.Match(entry) {
+			return true
+		}
+		select {
+		case f.entries <- entry:
+			return true
+		case <-f.done:
+			return false
+		}
+	}
+
+	handleLine := func(line string) bool {
+		line = strings.TrimRight(line, "\r\n")
+		if strings.TrimSpace(line) == "" {
+			return true
+		}
+		if pending != nil && isContinuation(f.config.Format, line) {
+			cont.add(pending, line)
+			return true
+		}
+		parsed, err := ParseLine(line, f.config.Format)
+		if err != nil {
+			return true
+		}
+		if !emit() {
+			return false
+		}
+		pending = parsed
+		cont = continuation{}
+		return true
+	}
+
+	// drain reads every complete line currently available.
+	drain := func() bool {
+		for br != nil {
+			chunk, err := br.ReadString('\n')
+			offset += int64(len(chunk))
+			partial.WriteString(chunk)
+			if err != nil {
+				return true
+			}
+			line := partial.String()
+			partial.Reset()
+			if !handleLine(line) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for {
+		if !drain() {
+			return
+		}
+
+		// Nothing more to read: flush the last entry, then check whether the
+		// file was rotated or truncated before waiting for more data.
+		if !emit() {
+			return
+		}
+
+		reopen := file == nil
+		if file != nil {
+			current, statErr := os.Stat(f.path)
+			opened, openedErr := file.Stat()
+			switch {
+			case statErr != nil || openedErr != nil:
+				// The path is briefly missing during rotation; keep waiting.
+			case !os.SameFile(current, opened):
+				reopen = true
+			case current.Size() < offset:
+				if _, err := file.Seek(0, io.SeekStart); err == nil {
+					offset = 0
+					br.Reset(file)
+					partial.Reset()
+				}
+				continue
+			}
+		}
+		if reopen {
+			if next, err := os.Open(f.path); err == nil {
+				if file != nil {
+					// Pick up anything written to the old file before it was
+					// rotated away.
+					if !drain() {
+						return
+					}
+					if partial.Len() > 0 && !handleLine(partial.String()) {
+						return
+					}
+					partial.Reset()
+					file.Close()
+				}
+				file = next
+				offset = 0
+				br = bufio.NewReader(file)
+				continue
+			} else if !os.IsNotExist(err) {
+				f.mutex.Lock()
+				f.err = fmt.Errorf("failed to reopen log file: %v", err)
+				f.mutex.Unlock()
+				return
+			}
+		}
+
+		select {
+		case <-f.done:
+			return
+		case <-time.After(f.config.PollInterval):
+		}
+	}
+}
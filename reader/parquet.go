@@ -0,0 +1,527 @@
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/samiullahsaleem/golog"
+)
+
+// ParquetConfig holds Parquet export options.
+type ParquetConfig struct {
+	// RowGroupSize is the number of rows per row group; defaults to
+	// 100000.
+	RowGroupSize int
+	// Compress gzips every page.
+	Compress bool
+}
+
+// Parquet physical types, repetitions, converted types, encodings and codecs.
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetRequired = 0
+	parquetOptional = 1
+
+	parquetUTF8            = 0
+	parquetTimestampMicros = 10
+
+	parquetPlain = 0
+	parquetRLE   = 3
+
+	parquetUncompressed = 0
+	parquetGzip         = 2
+)
+
+// ParquetWriter writes entries as a Parquet file, so logs can be loaded into
+// DuckDB, Athena or BigQuery without custom ETL. The file has time
+// (a UTC microsecond timestamp), level and message columns plus one column
+// per field. A field holding only integers becomes an INT64 column, only
+// numbers a DOUBLE column and only booleans a BOOLEAN column; any other
+// field becomes a string column, with non-string values encoded as JSON.
+// Fields named time, level or message get a "field_" prefix.
+//
+// The schema is derived from every entry, so entries are held in memory
+// until Close writes the file. ParquetWriter implements golog.Sink; to
+// convert existing files, see WriteParquet.
+type ParquetWriter struct {
+	w       io.Writer
+	config  ParquetConfig
+	entries []*golog.Entry
+	closed  bool
+}
+
+// NewParquetWriter returns a writer that writes a Parquet file to w on
+// Close. The caller owns w.
+func NewParquetWriter(w io.Writer, config ParquetConfig) *ParquetWriter {
+	if config.RowGroupSize <= 0 {
+		config.RowGroupSize = 100000
+	}
+	return &ParquetWriter{w: w, config: config}
+}
+
+// WriteParquet writes every entry of r to w as a Parquet file and returns
+// the number of entries written.
+func WriteParquet(w io.Writer, r *Reader, config ParquetConfig) (int, error) {
+	pw := NewParquetWriter(w, config)
+	for r.Next() {
+		pw.Write(r.Entry())
+	}
+	if err := r.Err(); err != nil {
+		return 0, err
+	}
+	return len(pw.entries), pw.Close()
+}
+
+// Write implements golog.Sink.
+func (pw *ParquetWriter) Write(entry *golog.Entry) error {
+	if pw.closed {
+		return fmt.Errorf("parquet writer is closed")
+	}
+	pw.entries = append(pw.entries, entry)
+	return nil
+}
+
+// parquetColumn is one column of the derived schema.
+type parquetColumn struct {
+	name     string
+	field    string // entry field, or "" for the time, level and message columns
+	kind     int    // physical type
+	optional bool
+	values   func(entry *golog.Entry) (interface{}, bool)
+}
+
+// Close implements golog.Sink, writing the file.
+func (pw *ParquetWriter) Close() error {
+	if pw.closed {
+		return nil
+	}
+	pw.closed = true
+	columns := parquetSchema(pw.entries)
+
+	out := countingWriter{w: pw.w}
+	out.Write([]byte("PAR1"))
+	var groups [][]byte
+	for start := 0; start < len(pw.entries); start += pw.config.RowGroupSize {
+		rows := pw.entries[start:min(start+pw.config.RowGroupSize, len(pw.entries))]
+		group, err := pw.writeRowGroup(&out, columns, rows)
+		if err != nil {
+			return fmt.Errorf("failed to write parquet file: %v", err)
+		}
+		groups = append(groups, group)
+	}
+
+	var meta thriftWriter
+	meta.i32(1, 1)
+	meta.list(2, thriftStruct, len(columns)+1)
+	meta.beginElem()
+	meta.str(4, "schema")
+	meta.i32(5, int32(len(columns)))
+	meta.end()
+	for _, col := range columns {
+		meta.beginElem()
+		meta.i32(1, int32(col.kind))
+		repetition := parquetRequired
+		if col.optional {
+			repetition = parquetOptional
+		}
+		meta.i32(3, int32(repetition))
+		meta.str(4, col.name)
+		switch {
+		case col.kind == parquetByteArray:
+			meta.i32(6, parquetUTF8)
+		case col.field == "" && col.kind == parquetInt64:
+			meta.i32(6, parquetTimestampMicros)
+		}
+		meta.end()
+	}
+	meta.i64(3, int64(len(pw.entries)))
+	meta.list(4, thriftStruct, len(groups))
+	for _, group := range groups {
+		meta.raw(group)
+	}
+	meta.str(6, "golog")
+	meta.stop()
+
+	out.Write(meta.buf.Bytes())
+	out.Write(binary.LittleEndian.AppendUint32(nil, uint32(meta.buf.Len())))
+	out.Write([]byte("PAR1"))
+	if out.err != nil {
+		return fmt.Errorf("failed to write parquet file: %v", out.err)
+	}
+	return nil
+}
+
+// writeRowGroup writes one data page per column and returns the encoded
+// RowGroup metadata.
+func (pw *ParquetWriter) writeRowGroup(out *countingWriter, columns []parquetColumn, rows []*golog.Entry) ([]byte, error) {
+	var group thriftWriter
+	group.beginElem()
+	group.list(1, thriftStruct, len(columns))
+	var total int64
+	for _, col := range columns {
+		page, levels := encodeColumn(col, rows)
+		var data []byte
+		if col.optional {
+			data = binary.LittleEndian.AppendUint32(nil, uint32(len(levels)))
+			data = append(data, levels...)
+		}
+		data = append(data, page...)
+
+		codec := parquetUncompressed
+		compressed := data
+		if pw.config.Compress {
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			gz.Write(data)
+			gz.Close()
+			compressed = buf.Bytes()
+			codec = parquetGzip
+		}
+
+		var header thriftWriter
+		header.i32(1, 0) // DATA_PAGE
+		header.i32(2, int32(len(data)))
+		header.i32(3, int32(len(compressed)))
+		header.begin(5)
+		header.i32(1, int32(len(rows)))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.end()
+		header.stop()
+
+		offset := out.n
+		out.Write(header.buf.Bytes())
+		out.Write(compressed)
+		size := int64(header.buf.Len() + len(compressed))
+		uncompressed := int64(header.buf.Len() + len(data))
+		total += uncompressed
+
+		group.beginElem()
+		group.i64(2, offset)
+		group.begin(3)
+		group.i32(1, int32(col.kind))
+		group.list(2, thriftI32, 2)
+		group.elemI32(parquetPlain)
+		group.elemI32(parquetRLE)
+		group.list(3, thriftBinary, 1)
+		group.elemStr(col.name)
+		group.i32(4, int32(codec))
+		group.i64(5, int64(len(rows)))
+		group.i64(6, uncompressed)
+		group.i64(7, size)
+		group.i64(9, offset)
+		group.end()
+		group.end()
+	}
+	group.i64(2, total)
+	group.i64(3, int64(len(rows)))
+	group.end()
+	return group.buf.Bytes(), out.err
+}
+
+// parquetSchema derives the columns for entries.
+func parquetSchema(entries []*golog.Entry) []parquetColumn {
+	columns := []parquetColumn{
+		{name: "time", kind: parquetInt64, optional: true, values: func(e *golog.Entry) (interface{}, bool) {
+			if e.Time.IsZero() {
+				return nil, false
+			}
+			return e.Time.UnixMicro(), true
+		}},
+		{name: "level", kind: parquetByteArray, values: func(e *golog.Entry) (interface{}, bool) { return e.Level.String(), true }},
+		{name: "message", kind: parquetByteArray, values: func(e *golog.Entry) (interface{}, bool) { return e.Message, true }},
+	}
+
+	// Record the kinds of value each field holds.
+	const (
+		seenInt = 1 << iota
+		seenFloat
+		seenBool
+		seenOther
+	)
+	seen := make(map[string]int)
+	for _, entry := range entries {
+		for k, v := range entry.Fields {
+			switch parquetScalar(v).(type) {
+			case nil:
+				seen[k] |= 0 // a column even if every value is null
+			case int64:
+				seen[k] |= seenInt
+			case float64:
+				seen[k] |= seenFloat
+			case bool:
+				seen[k] |= seenBool
+			default:
+				seen[k] |= seenOther
+			}
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for k := range seen {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, field := range names {
+		name := field
+		if name == "time" || name == "level" || name == "message" {
+			name = "field_" + name
+		}
+		kind := parquetByteArray
+		switch seen[field] {
+		case seenInt:
+			kind = parquetInt64
+		case seenFloat, seenInt | seenFloat:
+			kind = parquetDouble
+		case seenBool:
+			kind = parquetBoolean
+		}
+		columns = append(columns, parquetColumn{name: name, field: field, kind: kind, optional: true,
+			values: func(e *golog.Entry) (interface{}, bool) {
+				v, ok := e.Fields[field]
+				if !ok {
+					return nil, false
+				}
+				v = parquetScalar(v)
+				if v == nil {
+					return nil, false
+				}
+				switch kind {
+				case parquetDouble:
+					if i, ok := v.(int64); ok {
+						return float64(i), true
+					}
+				case parquetByteArray:
+					return parquetString(v), true
+				}
+				return v, true
+			}})
+	}
+	return columns
+}
+
+// parquetScalar converts a field value to int64, float64, bool or string
+// where its type allows, and leaves other values alone.
+func parquetScalar(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string, bool, int64, float64:
+		return v
+	case error:
+		return val.Error()
+	case fmt.Stringer:
+		return val.String()
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			return int64(u)
+		}
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return nil
+		}
+	}
+	return v
+}
+
+// parquetString renders a value for a string column.
+func parquetString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	}
+	if data, err := json.Marshal(v); err == nil {
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
+
+// encodeColumn returns the PLAIN encoded values of col for rows and, for
+// optional columns, the RLE encoded definition levels.
+func encodeColumn(col parquetColumn, rows []*golog.Entry) (values, levels []byte) {
+	var defined []bool
+	var bits []bool
+	for _, row := range rows {
+		v, ok := col.values(row)
+		defined = append(defined, ok)
+		if !ok {
+			continue
+		}
+		switch col.kind {
+		case parquetInt64:
+			values = binary.LittleEndian.AppendUint64(values, uint64(v.(int64)))
+		case parquetDouble:
+			values = binary.LittleEndian.AppendUint64(values, math.Float64bits(v.(float64)))
+		case parquetBoolean:
+			bits = append(bits, v.(bool))
+		case parquetByteArray:
+			s := v.(string)
+			values = binary.LittleEndian.AppendUint32(values, uint32(len(s)))
+			values = append(values, s...)
+		}
+	}
+	if col.kind == parquetBoolean {
+		values = make([]byte, (len(bits)+7)/8)
+		for i, b := range bits {
+			if b {
+				values[i/8] |= 1 << (i % 8)
+			}
+		}
+	}
+
+	// Definition levels as RLE runs with a bit width of 1.
+	for i := 0; i < len(defined); {
+		j := i
+		for j < len(defined) && defined[j] == defined[i] {
+			j++
+		}
+		levels = binary.AppendUvarint(levels, uint64(j-i)<<1)
+		if defined[i] {
+			levels = append(levels, 1)
+		} else {
+			levels = append(levels, 0)
+		}
+		i = j
+	}
+	return values, levels
+}
+
+// countingWriter tracks the file offset and the first write error.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+// Thrift compact protocol types.
+const (
+	thriftBinary = 8
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the Thrift compact protocol used by Parquet
+// metadata. Fields must be written in increasing id order within a struct.
+type thriftWriter struct {
+	buf  bytes.Buffer
+	last []int16 // last field id of each open struct
+	id   int16
+}
+
+// field writes a field header.
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.id; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(int64(id))
+	}
+	t.id = id
+}
+
+// varint writes a zigzag varint.
+func (t *thriftWriter) varint(v int64) {
+	t.buf.Write(binary.AppendUvarint(nil, uint64(v<<1^v>>63)))
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) str(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.elemStr(s)
+}
+
+// list writes the header of a list field with n elements of typ.
+func (t *thriftWriter) list(id int16, typ byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | typ)
+	} else {
+		t.buf.WriteByte(0xF0 | typ)
+		t.buf.Write(binary.AppendUvarint(nil, uint64(n)))
+	}
+}
+
+func (t *thriftWriter) elemI32(v int32) {
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) elemStr(s string) {
+	t.buf.Write(binary.AppendUvarint(nil, uint64(len(s))))
+	t.buf.WriteString(s)
+}
+
+// begin opens a struct field.
+func (t *thriftWriter) begin(id int16) {
+	t.field(id, thriftStruct)
+	t.beginElem()
+}
+
+// beginElem opens a struct written as a list element.
+func (t *thriftWriter) beginElem() {
+	t.last = append(t.last, t.id)
+	t.id = 0
+}
+
+// end closes the innermost struct.
+func (t *thriftWriter) end() {
+	t.stop()
+	t.id = t.last[len(t.last)-1]
+	t.last = t.last[:len(t.last)-1]
+}
+
+// stop ends the top-level struct.
+func (t *thriftWriter) stop() {
+	t.buf.WriteByte(0)
+}
+
+// raw appends an encoded struct as a list element.
+func (t *thriftWriter) raw(data []byte) {
+	t.buf.Write(data)
+}
@@ -0,0 +1,419 @@
+package reader
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samiullahsaleem/golog"
+)
+
+// textTimeLayout is the timestamp layout written by golog.TextFormatter.
+const textTimeLayout = "2006-01-02 15:04:05"
+
+// ParseLine parses a single formatted line. With FormatAuto the format is
+// detected from the line itself.
+func ParseLine(line string, format Format) (*golog.Entry, error) {
+	line = strings.TrimRight(line, "\r\n")
+	if format == FormatAuto {
+		format = DetectFormat(line)
+	}
+	switch format {
+	case FormatJSON:
+		return parseJSON(line)
+	case FormatLogfmt:
+		return parseLogfmt(line)
+	default:
+		return parseText(line)
+	}
+}
+
+// DetectFormat guesses the format of a single line.
+func DetectFormat(line string) Format {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(trimmed, "{"):
+		return FormatJSON
+	case strings.HasPrefix(trimmed, "["):
+		return FormatText
+	}
+	if _, _, ok := cutLevel(trimmed); ok {
+		return FormatText
+	}
+	if strings.Contains(trimmed, "=") {
+		return FormatLogfmt
+	}
+	return FormatText
+}
+
+// parseJSON parses a line written by golog.JSONFormatter.
+func parseJSON(line string) (*golog.Entry, error) {
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("invalid JSON entry: %v", err)
+	}
+
+	entry := &golog.Entry{Level: golog.INFO, Fields: make(map[string]interface{})}
+	for k, v := range obj {
+		switch k {
+		case "timestamp", "time", "ts":
+			if s, ok := v.(string); ok {
+				if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+					entry.Time = t
+					continue
+				}
+			}
+		case "level", "lvl":
+			if s, ok := v.(string); ok {
+				if level, err := golog.ParseLevel(s); err == nil {
+					entry.Level = level
+					continue
+				}
+			}
+		case "message", "msg":
+			if s, ok := v.(string); ok {
+				entry.Message = s
+				continue
+			}
+		}
+		entry.Fields[k] = convertJSON(v)
+	}
+	return entry, nil
+}
+
+// convertJSON turns json.Number values into int64 or float64.
+func convertJSON(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case map[string]interface{}:
+		for k, nested := range val {
+			val[k] = convertJSON(nested)
+		}
+	case []interface{}:
+		for i, nested := range val {
+			val[i] = convertJSON(nested)
+		}
+	}
+	return v
+}
+
+// parseLogfmt parses a line of key=value pairs.
+func parseLogfmt(line string) (*golog.Entry, error) {
+	pairs, err := splitLogfmt(line)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &golog.Entry{Level: golog.INFO, Fields: make(map[string]interface{})}
+	for _, p := range pairs {
+		switch p.key {
+		case "time", "ts", "timestamp":
+			if t, err := time.Parse(time.RFC3339Nano, p.value); err == nil {
+				entry.Time = t
+				continue
+			}
+		case "level", "lvl":
+			if level, err := golog.ParseLevel(p.value); err == nil {
+				entry.Level = level
+				continue
+			}
+		case "msg", "message":
+			entry.Message = p.value
+			continue
+		}
+		if p.quoted {
+			entry.Fields[p.key] = p.value
+		} else {
+			entry.Fields[p.key] = parseScalar(p.value)
+		}
+	}
+	return entry, nil
+}
+
+// logfmtPair is a single key=value token.
+type logfmtPair struct {
+	key    string
+	value  string
+	quoted bool
+}
+
+// splitLogfmt tokenizes key=value pairs, unquoting double-quoted values.
+func splitLogfmt(s string) ([]logfmtPair, error) {
+	var pairs []logfmtPair
+	i := 0
+	for i < len(s) {
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		if i == len(s) {
+			break
+		}
+		start := i
+		for i < len(s) && s[i] != '=' && s[i] != ' ' {
+			i++
+		}
+		key := s[start:i]
+		if i == len(s) || s[i] == ' ' {
+			pairs = append(pairs, logfmtPair{key: key, value: "true"})
+			continue
+		}
+		i++ // skip '='
+		if i < len(s) && s[i] == '"' {
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("unterminated quoted value for key %q", key)
+			}
+			value, err := strconv.Unquote(s[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted value for key %q: %v", key, err)
+			}
+			pairs = append(pairs, logfmtPair{key: key, value: value, quoted: true})
+			i = end + 1
+			continue
+		}
+		start = i
+		for i < len(s) && s[i] != ' ' {
+			i++
+		}
+		pairs = append(pairs, logfmtPair{key: key, value: s[start:i]})
+	}
+	return pairs, nil
+}
+
+// parseText parses the first line of an entry written by golog.TextFormatter.
+func parseText(line string) (*golog.Entry, error) {
+	entry := &golog.Entry{Fields: make(map[string]interface{})}
+	rest := line
+
+	if strings.HasPrefix(rest, "[") {
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("invalid text entry: unterminated timestamp")
+		}
+		t, err := time.ParseInLocation(textTimeLayout, rest[1:end], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid text entry timestamp: %v", err)
+		}
+		entry.Time = t
+		rest = strings.TrimPrefix(rest[end+1:], " ")
+	}
+
+	level, msg, ok := cutLevel(rest)
+	if !ok {
+		return nil, fmt.Errorf("invalid text entry: missing level")
+	}
+	entry.Level = level
+	entry.Message = msg
+
+	if i := fieldsStart(msg); i >= 0 {
+		entry.Message = msg[:i]
+		entry.Fields = parseGoMap(msg[i+len(" map[") : len(msg)-1])
+		return entry, nil
+	}
+	entry.Message, entry.Fields = splitTextPairs(msg)
+	return entry, nil
+}
+
+// splitTextPairs splits a message followed by key=value fields, the text
+// format's default. The fields are the longest run of key=value tokens at
+// the end of the line; a quoted message is unquoted. Nested groups stay
+// flattened under their dotted keys.
+func splitTextPairs(s string) (string, map[string]interface{}) {
+	fields := make(map[string]interface{})
+	starts := pairStarts(s)
+	if len(starts) == 0 {
+		return s, fields
+	}
+	pairs, err := splitLogfmt(s[starts[0]:])
+	if err != nil {
+		return s, fields
+	}
+	for _, p := range pairs {
+		if p.quoted {
+			fields[p.key] = p.value
+		} else {
+			fields[p.key] = parseScalar(p.value)
+		}
+	}
+
+	msg := strings.TrimRight(s[:starts[0]], " ")
+	if len(msg) >= 2 && msg[0] == '"' && msg[len(msg)-1] == '"' {
+		if unquoted, err := strconv.Unquote(msg); err == nil {
+			msg = unquoted
+		}
+	}
+	return msg, fields
+}
+
+// pairStarts returns the start offsets of the trailing key=value tokens of
+// s, honoring double-quoted sections.
+func pairStarts(s string) []int {
+	var starts []int
+	for i := 0; i < len(s); {
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		if i == len(s) {
+			break
+		}
+		start, eq := i, -1
+		for inQuote := false; i < len(s) && (inQuote || s[i] != ' '); i++ {
+			switch {
+			case s[i] == '\\' && inQuote:
+				i++
+			case s[i] == '"':
+				inQuote = !inQuote
+			case s[i] == '=' && eq < 0 && !inQuote:
+				eq = i
+			}
+		}
+		if eq > start && !strings.Contains(s[start:eq], `"`) {
+			starts = append(starts, start)
+		} else {
+			starts = starts[:0]
+		}
+	}
+	return starts
+}
+
+// cutLevel splits a leading level name from the rest of the line.
+func cutLevel(s string) (golog.LogLevel, string, bool) {
+	word, rest, _ := strings.Cut(s, " ")
+	if word != strings.ToUpper(word) {
+		return golog.INFO, "", false
+	}
+	level, err := golog.ParseLevel(word)
+	if err != nil {
+		return golog.INFO, "", false
+	}
+	return level, rest, true
+}
+
+// fieldsStart returns the index of the " map[...]" suffix holding the
+// fields, or -1 when the message has no fields.
+func fieldsStart(msg string) int {
+	if !strings.HasSuffix(msg, "]") {
+		return -1
+	}
+	for i := strings.Index(msg, " map["); i >= 0; {
+		if matchBracket(msg, i+len(" map")) == len(msg)-1 {
+			return i
+		}
+		next := strings.Index(msg[i+1:], " map[")
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return -1
+}
+
+// matchBracket returns the index of the ']' matching the '[' at open.
+func matchBracket(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseGoMap parses the body of a fmt-rendered map ("a:1 b:map[c:2]").
+// Values may contain spaces; a new pair starts at a space followed by
+// "key:" at bracket depth zero.
+func parseGoMap(s string) map[string]interface{} {
+	result := make(map[string]interface{})
+	for len(s) > 0 {
+		colon := strings.IndexByte(s, ':')
+		if colon < 0 {
+			break
+		}
+		key := s[:colon]
+		s = s[colon+1:]
+
+		end := nextPair(s)
+		value := s[:end]
+		if end < len(s) {
+			s = s[end+1:]
+		} else {
+			s = ""
+		}
+
+		if strings.HasPrefix(value, "map[") && strings.HasSuffix(value, "]") {
+			result[key] = parseGoMap(value[len("map[") : len(value)-1])
+		} else {
+			result[key] = parseScalar(value)
+		}
+	}
+	return result
+}
+
+// nextPair returns the index of the space that separates the current value
+// from the next "key:" pair, or len(s).
+func nextPair(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ' ':
+			if depth == 0 && looksLikeKey(s[i+1:]) {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+// looksLikeKey reports whether s starts with a field key followed by ':'.
+func looksLikeKey(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == ':' {
+			return i > 0
+		}
+		if c == ' ' || c == '[' || c == ']' {
+			return false
+		}
+	}
+	return false
+}
+
+// parseScalar converts an unquoted value to a bool, int64 or float64 when
+// possible, leaving it as a string otherwise.
+func parseScalar(s string) interface{} {
+	if b, err := strconv.ParseBool(s); err == nil && (s == "true" || s == "false") {
+		return b
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strings.ContainsAny(s, ".eE") {
+		return f
+	}
+	return s
+}
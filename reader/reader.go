@@ -0,0 +1,291 @@
+// Package reader parses files written by golog's Text and JSON formatters,
+// as well as logfmt output, back into golog.Entry values. Gzip-compressed
+// rotated backups are decompressed transparently.
+package reader
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/samiullahsaleem/golog"
+)
+
+// Format identifies the formatter that produced a log file.
+type Format int
+
+const (
+	// FormatAuto detects the format of every line.
+	FormatAuto Format = iota
+	// FormatText is the output of golog.TextFormatter.
+	FormatText
+	// FormatJSON is the output of golog.JSONFormatter.
+	FormatJSON
+	// FormatLogfmt is key=value output with time, level and msg keys.
+	FormatLogfmt
+)
+
+// maxLineSize bounds the length of a single log line.
+const maxLineSize = 16 * 1024 * 1024
+
+// Predicate reports whether an entry should be returned.
+type Predicate func(entry *golog.Entry) bool
+
+// Filter restricts the entries returned by a Reader. The zero Filter
+// matches every entry.
+type Filter struct {
+	MinLevel   golog.LogLevel // Entries below this level are skipped
+	Since      time.Time      // If set, entries before Since are skipped
+	Until      time.Time      // If set, entries at or after Until are skipped
+	Predicates []Predicate    // All predicates must match
+}
+
+// Match reports whether entry passes the filter.
+func (f Filter) Match(entry *golog.Entry) bool {
+	if entry.Level < f.MinLevel {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !entry.Time.Before(f.Until) {
+		return false
+	}
+	for _, p := range f.Predicates {
+		if !p(entry) {
+			return false
+		}
+	}
+	return true
+}
+
+// FieldEquals matches entries whose field key renders as value.
+func FieldEquals(key string, value interface{}) Predicate {
+	want := fmt.Sprint(value)
+	return func(entry *golog.Entry) bool {
+		v, ok := entry.Fields[key]
+		return ok && fmt.Sprint(v) == want
+	}
+}
+
+// FieldExists matches entries that have the field key.
+func FieldExists(key string) Predicate {
+	return func(entry *golog.Entry) bool {
+		_, ok := entry.Fields[key]
+		return ok
+	}
+}
+
+// MessageContains matches entries whose message contains substr.
+func MessageContains(substr string) Predicate {
+	return func(entry *golog.Entry) bool {
+		return strings.Contains(entry.Message, substr)
+	}
+}
+
+// Reader iterates over the entries of a log stream in the style of
+// bufio.Scanner:
+//
+//	for r.Next() {
+//		entry := r.Entry()
+//	}
+//	if err := r.Err(); err != nil { ... }
+type Reader struct {
+	scanner *bufio.Scanner
+	format  Format
+	filter  Filter
+	closers []io.Closer
+	entry   *golog.Entry
+	pending *golog.Entry
+	err     error
+	skipped int
+}
+
+// New returns a Reader that parses r in the given format. Gzip-compressed
+// input is detected and decompressed.
+func New(r io.Reader, format Format) (*Reader, error) {
+	reader := &Reader{format: format}
+
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open gzip stream: %v", err)
+		}
+		reader.closers = append(reader.closers, gz)
+		r = gz
+	} else {
+		r = br
+	}
+
+	reader.scanner = bufio.NewScanner(r)
+	reader.scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	return reader, nil
+}
+
+// Open opens a log file, including gzip-compressed backups, for reading.
+func Open(path string, format Format) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %v", err)
+	}
+	reader, err := New(file, format)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	reader.closers = append(reader.closers, file)
+	return reader, nil
+}
+
+// SetFilter restricts the entries returned by Next.
+func (r *Reader) SetFilter(filter Filter) {
+	r.filter = filter
+}
+
+// Next advances to the next matching entry, returning false at the end of
+// the input or on a read error. Lines that cannot be parsed are skipped
+// and counted by Skipped.
+func (r *Reader) Next() bool {
+	for {
+		entry, ok := r.readEntry()
+		if !ok {
+			r.entry = nil
+			return false
+		}
+		if r.filter.Match(entry) {
+			r.entry = entry
+			return true
+		}
+	}
+}
+
+// Entry returns the entry read by the last call to Next.
+func (r *Reader) Entry() *golog.Entry {
+	return r.entry
+}
+
+// Err returns the first read error encountered.
+func (r *Reader) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.scanner.Err()
+}
+
+// Skipped returns the number of lines that could not be parsed.
+func (r *Reader) Skipped() int {
+	return r.skipped
+}
+
+// Close releases the underlying file and decompressor, if any.
+func (r *Reader) Close() error {
+	var firstErr error
+	for _, c := range r.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	r.closers = nil
+	return firstErr
+}
+
+// fieldLine matches a MultiLine text field line ("  key: value").
+var fieldLine = regexp.MustCompile(`^\s+([^\s:]+): ?(.*)$`)
+
+// readEntry returns the next complete entry. Text entries may span several
+// lines, so one line of lookahead is kept in r.pending.
+func (r *Reader) readEntry() (*golog.Entry, bool) {
+	entry := r.pending
+	r.pending = nil
+	cont := continuation{}
+
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if entry != nil && isContinuation(r.format, line) {
+			cont.add(entry, line)
+			continue
+		}
+
+		parsed, err := ParseLine(line, r.format)
+		if err != nil {
+			r.skipped++
+			continue
+		}
+		if entry == nil {
+			entry = parsed
+			if r.format != FormatText && DetectFormat(line) != FormatText {
+				return entry, true
+			}
+			continue
+		}
+		r.pending = parsed
+		return entry, true
+	}
+	return entry, entry != nil
+}
+
+// continuation accumulates the extra lines of a multi-line text entry.
+type continuation struct {
+	key         string                 // field that unprefixed lines continue
+	group       map[string]interface{} // nested group being filled, if any
+	groupIndent int
+}
+
+// add applies one continuation line to entry: a "key: value" field line, a
+// "key:" group header, or a continued message or field value.
+func (c *continuation) add(entry *golog.Entry, line string) {
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	if m := fieldLine.FindStringSubmatch(line); m != nil {
+		target := entry.Fields
+		if c.group != nil && indent > c.groupIndent {
+			target = c.group
+		} else {
+			c.group = nil
+		}
+		if m[2] == "" {
+			c.group = make(map[string]interface{})
+			c.groupIndent = indent
+			target[m[1]] = c.group
+			c.key = ""
+			return
+		}
+		target[m[1]] = parseScalar(m[2])
+		c.key = m[1]
+		return
+	}
+
+	if c.key == "" {
+		entry.Message += "\n" + line
+		return
+	}
+	target := entry.Fields
+	if c.group != nil {
+		target = c.group
+	}
+	target[c.key] = fmt.Sprint(target[c.key]) + "\n" + strings.TrimLeft(line, " ")
+}
+
+// isContinuation reports whether line continues the previous text entry.
+func isContinuation(format Format, line string) bool {
+	if format == FormatJSON || format == FormatLogfmt {
+		return false
+	}
+	if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+		return true
+	}
+	if strings.HasPrefix(line, "[") {
+		return false
+	}
+	_, _, isEntry := cutLevel(line)
+	return !isEntry && DetectFormat(line) == FormatText
+}
@@ -0,0 +1,419 @@
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samiullahsaleem/golog"
+)
+
+func readAll(t *testing.T, r *Reader) []*golog.Entry {
+	t.Helper()
+	var entries []*golog.Entry
+	for r.Next() {
+		entries = append(entries, r.Entry())
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("Failed to read entries: %v", err)
+	}
+	return entries
+}
+
+func TestReadLoggerOutput(t *testing.T) {
+	for _, format := range []string{"text", "json"} {
+		tempDir := t.TempDir()
+		logFile := filepath.Join(tempDir, "test.log")
+
+		logger, err := golog.NewLogger(golog.Config{Level: golog.TRACE, FilePath: logFile, Format: format, MaxSizeMB: 1})
+		if err != nil {
+			t.Fatalf("Failed to create logger: %v", err)
+		}
+		logger.Info("user logged in", map[string]interface{}{"user_id": 123, "ip": "192.168.1.1"})
+		logger.Warn("low memory", map[string]interface{}{"memory_mb": 100})
+		logger.Close()
+
+		r, err := Open(logFile, FormatAuto)
+		if err != nil {
+			t.Fatalf("Failed to open log file: %v", err)
+		}
+		entries := readAll(t, r)
+		r.Close()
+
+		if len(entries) != 2 {
+			t.Fatalf("%s: expected 2 entries, got %d", format, len(entries))
+		}
+		first := entries[0]
+		if first.Level != golog.INFO || first.Message != "user logged in" {
+			t.Errorf("%s: unexpected first entry: %+v", format, first)
+		}
+		if first.Fields["user_id"] != int64(123) || first.Fields["ip"] != "192.168.1.1" {
+			t.Errorf("%s: unexpected fields: %v", format, first.Fields)
+		}
+		if time.Since(first.Time) > time.Minute {
+			t.Errorf("%s: unexpected timestamp: %v", format, first.Time)
+		}
+	}
+}
+
+func TestReadMultiLineText(t *testing.T) {
+	input := "[2025-07-18 21:48:00] ERROR query failed\n" +
+		"| retrying\n" +
+		"  db: users\n" +
+		"  stack: main.main()\n" +
+		"    | main.go:10\n" +
+		"  user:\n" +
+		"    id: 7\n" +
+		"[2025-07-18 21:48:01] INFO done\n"
+
+	r, err := New(strings.NewReader(input), FormatText)
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	entries := readAll(t, r)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+
+	first := entries[0]
+	if first.Message != "query failed\n| retrying" {
+		t.Errorf("Unexpected message: %q", first.Message)
+	}
+	if first.Fields["db"] != "users" || first.Fields["stack"] != "main.main()\n| main.go:10" {
+		t.Errorf("Unexpected fields: %#v", first.Fields)
+	}
+	if user, ok := first.Fields["user"].(map[string]interface{}); !ok || user["id"] != int64(7) {
+		t.Errorf("Unexpected nested group: %#v", first.Fields["user"])
+	}
+}
+
+func TestParseTextPairs(t *testing.T) {
+	line := (&golog.TextFormatter{}).Format(golog.INFO, "a=b in message", map[string]interface{}{
+		"note": "two words",
+		"n":    3,
+		"user": golog.Dict("id", 7),
+	})
+	entry, err := ParseLine(line, FormatText)
+	if err != nil {
+		t.Fatalf("Failed to parse %q: %v", line, err)
+	}
+	if entry.Message != "a=b in message" {
+		t.Errorf("Unexpected message: %q", entry.Message)
+	}
+	if entry.Fields["note"] != "two words" || entry.Fields["n"] != int64(3) || entry.Fields["user.id"] != int64(7) {
+		t.Errorf("Unexpected fields: %#v", entry.Fields)
+	}
+
+	legacy, err := ParseLine("[2025-07-18 21:48:00] INFO hello map[user:alice]", FormatText)
+	if err != nil || legacy.Message != "hello" || legacy.Fields["user"] != "alice" {
+		t.Errorf("Unexpected legacy entry: %+v (%v)", legacy, err)
+	}
+}
+
+func TestReadLogfmtAndFilters(t *testing.T) {
+	input := `time=2025-07-18T21:48:00Z level=debug msg="cache warm" hits=10
+time=2025-07-18T21:49:00Z level=error msg="payment failed" order=42 reason="card declined"
+level=info msg="unterminated
+time=2025-07-18T21:50:00Z level=error msg="payment failed" order=43
+`
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "app.log.20250718_215000.gz")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	gz := gzip.NewWriter(file)
+	gz.Write([]byte(input))
+	gz.Close()
+	file.Close()
+
+	r, err := Open(path, FormatLogfmt)
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+	defer r.Close()
+	r.SetFilter(Filter{
+		MinLevel:   golog.ERROR,
+		Until:      time.Date(2025, 7, 18, 21, 50, 0, 0, time.UTC),
+		Predicates: []Predicate{FieldExists("reason"), MessageContains("payment")},
+	})
+
+	entries := readAll(t, r)
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	if entries[0].Fields["order"] != int64(42) || entries[0].Fields["reason"] != "card declined" {
+		t.Errorf("Unexpected fields: %v", entries[0].Fields)
+	}
+	if r.Skipped() != 1 {
+		t.Errorf("Expected 1 skipped line, got %d", r.Skipped())
+	}
+}
+
+func TestFollowAcrossRotation(t *testing.T) {
+	tempDir := t.TempDir()
+	logFile := filepath.Join(tempDir, "app.log")
+
+	writeLine := func(path, line string) {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("Failed to open log file: %v", err)
+		}
+		f.WriteString(line + "\n")
+		f.Close()
+	}
+
+	writeLine(logFile, `{"level":"INFO","message":"old entry"}`)
+
+	follower, err := FollowWithConfig(logFile, FollowConfig{FromEnd: true, PollInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to follow log file: %v", err)
+	}
+	defer follower.Close()
+
+	next := func() *golog.Entry {
+		select {
+		case entry := <-follower.Entries():
+			return entry
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for entry")
+			return nil
+		}
+	}
+
+	writeLine(logFile, `{"level":"INFO","message":"first"}`)
+	if entry := next(); entry.Message != "first" {
+		t.Fatalf("Expected first entry, got %q", entry.Message)
+	}
+
+	if err := os.Rename(logFile, logFile+".1"); err != nil {
+		t.Fatalf("Failed to rotate log file: %v", err)
+	}
+	writeLine(logFile, `{"level":"WARN","message":"after rotation"}`)
+
+	if entry := next(); entry.Message != "after rotation" || entry.Level != golog.WARN {
+		t.Fatalf("Expected entry from the new file, got %+v", entry)
+	}
+}
+
+type recordingSink struct {
+	entries []*golog.Entry
+	times   []time.Time
+}
+
+func (s *recordingSink) Write(entry *golog.Entry) error {
+	s.entries = append(s.entries, entry)
+	s.times = append(s.times, time.Now())
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func TestReplayPacing(t *testing.T) {
+	input := `{"timestamp":"2025-07-18T21:48:00Z","level":"INFO","message":"first"}
+{"timestamp":"2025-07-18T21:48:01Z","level":"INFO","message":"second"}
+{"timestamp":"2025-07-18T21:48:02Z","level":"ERROR","message":"third"}
+`
+	r, err := New(strings.NewReader(input), FormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+
+	sink := &recordingSink{}
+	count, err := Replay(context.Background(), r, sink, ReplayConfig{Speed: 20})
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if count != 3 || len(sink.entries) != 3 {
+		t.Fatalf("Expected 3 replayed entries, got %d", count)
+	}
+
+	if !sink.entries[2].Time.Equal(time.Date(2025, 7, 18, 21, 48, 2, 0, time.UTC)) {
+		t.Errorf("Original timestamp was not preserved: %v", sink.entries[2].Time)
+	}
+	if gap := sink.times[2].Sub(sink.times[0]); gap < 80*time.Millisecond {
+		t.Errorf("Expected about 100ms between first and last entry at 20x, got %v", gap)
+	}
+}
+
+func TestSearchSkipsIndexedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	logFile := filepath.Join(tempDir, "app.log")
+
+	logger, err := golog.NewLogger(golog.Config{
+		Level:        golog.TRACE,
+		FilePath:     logFile,
+		Format:       "json",
+		MaxSizeMB:    1,
+		MaxBackups:   5,
+		IndexBackups: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	padding := strings.Repeat("x", 1024)
+	for i := 0; i < 1100; i++ {
+		logger.Info("old batch", map[string]interface{}{"batch": "old", "padding": padding})
+	}
+	logger.Error("new batch", map[string]interface{}{"batch": "new", "user_id": 42})
+	logger.Close()
+
+	files, err := LogFiles(logFile)
+	if err != nil {
+		t.Fatalf("Failed to list log files: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected one backup and the active file, got %v", files)
+	}
+	if _, err := golog.LoadFileIndex(files[0]); err != nil {
+		t.Fatalf("Expected an index for the backup: %v", err)
+	}
+
+	var found []string
+	stats, err := Search(files, FormatAuto, Query{Fields: map[string]interface{}{"user_id": 42}}, func(path string, entry *golog.Entry) bool {
+		found = append(found, entry.Message)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(found) != 1 || found[0] != "new batch" {
+		t.Errorf("Unexpected search results: %v", found)
+	}
+	if stats.Skipped != 1 {
+		t.Errorf("Expected the indexed backup to be skipped, stats: %+v", stats)
+	}
+
+	stats, _ = Search(files, FormatAuto, Query{Filter: Filter{MinLevel: golog.ERROR}}, func(string, *golog.Entry) bool { return true })
+	if stats.Skipped != 1 || stats.Entries != 1 {
+		t.Errorf("Expected level counts to skip the backup, stats: %+v", stats)
+	}
+}
+
+// thriftStructFields decodes a Thrift compact struct into its field values,
+// keyed by id: integers as int64, binaries as string, lists as slices and
+// structs as maps.
+func thriftStructFields(t *testing.T, r *bytes.Reader) map[int16]interface{} {
+	t.Helper()
+	fields := make(map[int16]interface{})
+	var id int16
+	for {
+		header, err := r.ReadByte()
+		if err != nil {
+			t.Fatalf("Truncated struct: %v", err)
+		}
+		if header == 0 {
+			return fields
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			v, _ := binary.ReadUvarint(r)
+			id = int16(int64(v>>1) ^ -int64(v&1))
+		}
+		fields[id] = thriftValue(t, r, header&0x0F)
+	}
+}
+
+func thriftValue(t *testing.T, r *bytes.Reader, typ byte) interface{} {
+	switch typ {
+	case 1, 2:
+		return typ == 1
+	case 5, 6:
+		v, _ := binary.ReadUvarint(r)
+		return int64(v>>1) ^ -int64(v&1)
+	case 8:
+		n, _ := binary.ReadUvarint(r)
+		data := make([]byte, n)
+		r.Read(data)
+		return string(data)
+	case 9:
+		header, _ := r.ReadByte()
+		n := uint64(header >> 4)
+		if n == 15 {
+			n, _ = binary.ReadUvarint(r)
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = thriftValue(t, r, header&0x0F)
+		}
+		return list
+	case 12:
+		return thriftStructFields(t, r)
+	}
+	t.Fatalf("Unexpected thrift type %d", typ)
+	return nil
+}
+
+func TestWriteParquet(t *testing.T) {
+	input := `{"time":"2025-07-18T21:48:00Z","level":"INFO","message":"request","status":200,"latency":0.5,"cached":true}
+{"time":"2025-07-18T21:49:00Z","level":"ERROR","message":"failed","status":500,"latency":2,"error":{"code":"E1"}}
+{"time":"2025-07-18T21:50:00Z","level":"WARN","message":"slow","path":"/slow","cached":false}
+`
+	r, err := New(strings.NewReader(input), FormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	var out bytes.Buffer
+	n, err := WriteParquet(&out, r, ParquetConfig{RowGroupSize: 2})
+	if err != nil {
+		t.Fatalf("Failed to write parquet: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("Expected 3 entries, got %d", n)
+	}
+
+	data := out.Bytes()
+	if string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+		t.Fatalf("Missing parquet magic")
+	}
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	meta := thriftStructFields(t, bytes.NewReader(data[len(data)-8-size:len(data)-8]))
+	if meta[3] != int64(3) {
+		t.Errorf("Expected 3 rows, got %v", meta[3])
+	}
+
+	// Column name to physical type.
+	types := make(map[string]int64)
+	for _, element := range meta[2].([]interface{})[1:] {
+		fields := element.(map[int16]interface{})
+		types[fields[4].(string)] = fields[1].(int64)
+	}
+	expected := map[string]int64{"time": 2, "level": 6, "message": 6, "status": 2, "latency": 5, "cached": 0, "error": 6, "path": 6}
+	for name, typ := range expected {
+		if got, ok := types[name]; !ok || got != typ {
+			t.Errorf("Expected column %s of type %d, got %d (present %v)", name, typ, got, ok)
+		}
+	}
+	if len(types) != len(expected) {
+		t.Errorf("Unexpected columns: %v", types)
+	}
+
+	groups := meta[4].([]interface{})
+	if len(groups) != 2 || groups[0].(map[int16]interface{})[3] != int64(2) || groups[1].(map[int16]interface{})[3] != int64(1) {
+		t.Fatalf("Expected row groups of 2 and 1 rows, got %v", groups)
+	}
+
+	// The first page of the status column holds the first two values.
+	for _, chunk := range groups[0].(map[int16]interface{})[1].([]interface{}) {
+		column := chunk.(map[int16]interface{})[3].(map[int16]interface{})
+		if column[3].([]interface{})[0] != "status" {
+			continue
+		}
+		page := bytes.NewReader(data[column[9].(int64):])
+		header := thriftStructFields(t, page)
+		body := make([]byte, header[3].(int64))
+		page.Read(body)
+		levels := binary.LittleEndian.Uint32(body)
+		values := body[4+levels:]
+		if binary.LittleEndian.Uint64(values) != 200 || binary.LittleEndian.Uint64(values[8:]) != 500 {
+			t.Errorf("Unexpected status values: %x", values)
+		}
+	}
+}
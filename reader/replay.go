@@ -0,0 +1,65 @@
+package reader
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samiullahsaleem/golog"
+)
+
+// ReplayConfig holds replay options.
+type ReplayConfig struct {
+	// Speed scales the original gaps between entries: 1 replays at the
+	// original pace, 10 ten times faster. Zero or less replays as fast as
+	// the sink accepts entries.
+	Speed float64
+}
+
+// Replay reads every entry from r and writes it to sink with its original
+// timestamp, pacing delivery according to config. It returns the number of
+// entries written. Replay stops at the first sink error or when ctx is done.
+func Replay(ctx context.Context, r *Reader, sink golog.Sink, config ReplayConfig) (int, error) {
+	var (
+		count     int
+		firstTime time.Time
+		started   = time.Now()
+		timer     = time.NewTimer(0)
+	)
+	defer timer.Stop()
+	<-timer.C
+
+	for r.Next() {
+		entry := r.Entry()
+
+		if config.Speed > 0 && !entry.Time.IsZero() {
+			if firstTime.IsZero() {
+				firstTime = entry.Time
+			}
+			offset := time.Duration(float64(entry.Time.Sub(firstTime)) / config.Speed)
+			if wait := time.Until(started.Add(offset)); wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-ctx.Done():
+					return count, ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return count, ctx.Err()
+		default:
+		}
+
+		if err := sink.Write(entry); err != nil {
+			return count, fmt.Errorf("failed to replay entry %d: %v", count+1, err)
+		}
+		count++
+	}
+	if err := r.Err(); err != nil {
+		return count, err
+	}
+	return count, nil
+}
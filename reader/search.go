@@ -0,0 +1,120 @@
+package reader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samiullahsaleem/golog"
+)
+
+// Query selects entries across several log files.
+type Query struct {
+	Filter
+	// Fields requires each key to render as the given value. Unlike
+	// Predicates, field matches are checked against backup indexes so
+	// files that cannot match are skipped without being read.
+	Fields map[string]interface{}
+}
+
+// SearchStats reports how much work a search did.
+type SearchStats struct {
+	Files   int // Files considered
+	Skipped int // Files skipped thanks to their index
+	Entries int // Matching entries delivered
+}
+
+// LogFiles returns the backups of the log at path, oldest first, followed
+// by path itself if it exists. Index files are excluded.
+func LogFiles(path string) ([]string, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	type backup struct {
+		path  string
+		mtime int64
+	}
+	var backups []backup
+	for _, m := range matches {
+		if strings.HasSuffix(m, golog.IndexSuffix) || strings.HasSuffix(m, golog.LockSuffix) {
+			continue
+		}
+		info, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: m, mtime: info.ModTime().UnixNano()})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].mtime < backups[j].mtime })
+
+	files := make([]string, 0, len(backups)+1)
+	for _, b := range backups {
+		files = append(files, b.path)
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// Search calls fn for every entry in paths that matches q, consulting each
+// file's index first to skip files that cannot match. fn returns false to
+// stop the search.
+func Search(paths []string, format Format, q Query, fn func(path string, entry *golog.Entry) bool) (SearchStats, error) {
+	var stats SearchStats
+
+	filter := q.Filter
+	for key, value := range q.Fields {
+		filter.Predicates = append(filter.Predicates, FieldEquals(key, value))
+	}
+
+	for _, path := range paths {
+		stats.Files++
+		if index, err := golog.LoadFileIndex(path); err == nil && !q.mayMatch(index) {
+			stats.Skipped++
+			continue
+		}
+
+		r, err := Open(path, format)
+		if err != nil {
+			return stats, err
+		}
+		r.SetFilter(filter)
+		for r.Next() {
+			stats.Entries++
+			if !fn(path, r.Entry()) {
+				r.Close()
+				return stats, nil
+			}
+		}
+		err = r.Err()
+		r.Close()
+		if err != nil {
+			return stats, fmt.Errorf("failed to search %s: %v", path, err)
+		}
+	}
+	return stats, nil
+}
+
+// mayMatch reports whether the file described by index can hold a match.
+func (q Query) mayMatch(index *golog.FileIndex) bool {
+	if index.Partial {
+		return true
+	}
+	if index.Entries == 0 {
+		return false
+	}
+	if !index.MayContainLevel(q.MinLevel) || !index.Overlaps(q.Since, q.Until) {
+		return false
+	}
+	for key, value := range q.Fields {
+		if !index.MayContainField(key, value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package golog
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+)
+
+// RecoverAndLog recovers from a panic and logs it at ERROR with the panic
+// value, stack trace and goroutine ID. It must be deferred directly:
+//
+//	defer golog.RecoverAndLog(logger)
+func RecoverAndLog(l *Logger) {
+	if r := recover(); r != nil {
+		logPanic(l, ERROR, r, nil)
+	}
+}
+
+// RecoverAndRepanic logs a recovered panic at FATAL, without exiting, and
+// then panics again with the original value. It must be deferred directly.
+func RecoverAndRepanic(l *Logger) {
+	if r := recover(); r != nil {
+		logPanic(l, FATAL, r, nil)
+		panic(r)
+	}
+}
+
+// RecoverToError logs a recovered panic at ERROR and stores it in *errp so
+// the surrounding function returns an error instead of crashing. It must
+// be deferred directly:
+//
+//	func handle() (err error) {
+//		defer golog.RecoverToError(logger, &err)
+//		...
+//	}
+func RecoverToError(l *Logger, errp *error) {
+	if r := recover(); r != nil {
+		logPanic(l, ERROR, r, nil)
+		if err, ok := r.(error); ok {
+			*errp = fmt.Errorf("recovered panic: %w", err)
+		} else {
+			*errp = fmt.Errorf("recovered panic: %v", r)
+		}
+	}
+}
+
+// RecoveryMiddleware returns an http.Handler that logs panics raised by
+// next, together with the request method and path, and responds with
+// 500 Internal Server Error. http.ErrAbortHandler is re-panicked untouched.
+func RecoveryMiddleware(l *Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				logPanic(l, ERROR, rec, map[string]interface{}{
+					"method": r.Method,
+					"path":   r.URL.Path,
+				})
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// logPanic writes a structured entry describing a recovered panic.
+func logPanic(l *Logger, level LogLevel, r interface{}, extra map[string]interface{}) {
+	stack := debug.Stack()
+	fields := mergeFields([]map[string]interface{}{extra})
+	fields["panic"] = fmt.Sprint(r)
+	fields["panic_type"] = fmt.Sprintf("%T", r)
+	fields["stack"] = string(stack)
+	if id, ok := goroutineID(stack); ok {
+		fields["goroutine"] = id
+	}
+	if entry := l.log(level, "recovered panic", fields); entry != nil && level < FATAL {
+		l.reportCrash(entry) // FATAL entries are reported by log
+	}
+}
+
+// goroutineID parses the ID from a stack trace's "goroutine N [...]" header.
+func goroutineID(stack []byte) (uint64, bool) {
+	stack = bytes.TrimPrefix(stack, []byte("goroutine "))
+	end := bytes.IndexByte(stack, ' ')
+	if end < 0 {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(string(stack[:end]), 10, 64)
+	return id, err == nil
+}
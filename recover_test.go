@@ -0,0 +1,190 @@
+package golog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newBufferLogger(t *testing.T, level LogLevel) (*Logger, *bytes.Buffer) {
+	t.Helper()
+	logger, err := NewLogger(Config{Level: level})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	var buf bytes.Buffer
+	logger.AddSink(NewWriterSink(&buf, &JSONFormatter{}))
+	return logger, &buf
+}
+
+func TestRecoverToError(t *testing.T) {
+	logger, buf := newBufferLogger(t, TRACE)
+	sentinel := errors.New("boom")
+
+	run := func() (err error) {
+		defer RecoverToError(logger, &err)
+		panic(sentinel)
+	}
+
+	err := run()
+	if !errors.Is(err, sentinel) {
+		t.Fatalf("Expected wrapped panic error, got: %v", err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, `"level":"ERROR"`) || !strings.Contains(output, `"panic":"boom"`) {
+		t.Errorf("Unexpected panic entry: %s", output)
+	}
+	if !strings.Contains(output, `"goroutine":`) || !strings.Contains(output, `"stack":"goroutine `) {
+		t.Errorf("Panic entry lacks goroutine info: %s", output)
+	}
+}
+
+func TestRecoverAndRepanic(t *testing.T) {
+	logger, buf := newBufferLogger(t, TRACE)
+
+	defer func() {
+		if r := recover(); r != "again" {
+			t.Errorf("Expected re-panic with original value, got: %v", r)
+		}
+		if !strings.Contains(buf.String(), `"level":"FATAL"`) {
+			t.Errorf("Expected FATAL entry, got: %s", buf.String())
+		}
+	}()
+
+	func() {
+		defer RecoverAndRepanic(logger)
+		panic("again")
+	}()
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	logger, buf := newBufferLogger(t, TRACE)
+	handler := RecoveryMiddleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rec.Code)
+	}
+	if !strings.Contains(buf.String(), `"path":"/orders"`) {
+		t.Errorf("Panic entry lacks request path: %s", buf.String())
+	}
+}
+
+func TestStackSampling(t *testing.T) {
+	logger, err := NewLogger(Config{Level: INFO, StackSampleWindow: time.Minute})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	var buf bytes.Buffer
+	logger.AddSink(NewWriterSink(&buf, &JSONFormatter{}))
+
+	for i := 0; i < 3; i++ {
+		func() {
+			defer RecoverAndLog(logger)
+			panic(fmt.Sprintf("boom %d", i))
+		}()
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(lines))
+	}
+	var hashes []string
+	for i, line := range lines {
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+			t.Fatalf("Failed to decode entry: %v", err)
+		}
+		if _, ok := decoded["stack"]; ok != (i == 0) {
+			t.Errorf("Entry %d: stack present = %v", i, ok)
+		}
+		hash, _ := decoded["stack_hash"].(string)
+		hashes = append(hashes, hash)
+	}
+	if hashes[0] == "" || hashes[0] != hashes[1] || hashes[1] != hashes[2] {
+		t.Errorf("Expected matching stack hashes, got %v", hashes)
+	}
+}
+
+func TestStackSamplingBound(t *testing.T) {
+	s := newStackSampler(time.Minute)
+	start := time.Now()
+	sample := func(i int) *Entry {
+		entry := &Entry{Time: start.Add(time.Duration(i) * time.Millisecond), Fields: map[string]interface{}{
+			"stack": fmt.Sprintf("main.handler%d()\n\t/src/main.go:%d +0x1f\n", i, i),
+		}}
+		s.sample(entry)
+		return entry
+	}
+	for i := 0; i < maxStackSignatures+500; i++ {
+		sample(i)
+	}
+	if len(s.seen) > maxStackSignatures {
+		t.Errorf("Expected at most %d signatures, got %d", maxStackSignatures, len(s.seen))
+	}
+	if _, ok := sample(maxStackSignatures + 499).Fields["stack"]; ok {
+		t.Errorf("Expected the newest signature to be remembered")
+	}
+	if _, ok := sample(0).Fields["stack"]; !ok {
+		t.Errorf("Expected the oldest signature to be evicted")
+	}
+}
+
+func TestCrashReports(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "crashes")
+	logger, err := NewLogger(Config{Level: INFO, CrashReportDir: dir, CrashReportRecent: 2})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	for i := 1; i <= 3; i++ {
+		logger.Info(fmt.Sprintf("step %d", i))
+	}
+	logger.Debug("below the level")
+	func() {
+		defer RecoverAndLog(logger.Named("worker"))
+		panic("boom")
+	}()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "crash-*.json"))
+	if len(files) != 1 {
+		t.Fatalf("Expected one crash report, got %v", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("Failed to read crash report: %v", err)
+	}
+	var report CrashReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("Failed to decode crash report: %v", err)
+	}
+	var entry map[string]interface{}
+	json.Unmarshal(report.Entry, &entry)
+	if entry["message"] != "recovered panic" || entry["panic"] != "boom" || entry["logger"] != "worker" {
+		t.Errorf("Unexpected entry: %s", report.Entry)
+	}
+	if len(report.Recent) != 2 || !strings.Contains(string(report.Recent[0]), "step 2") || !strings.Contains(string(report.Recent[1]), "step 3") {
+		t.Errorf("Unexpected recent entries: %s", report.Recent)
+	}
+	if !strings.Contains(report.Goroutines, "TestCrashReports") || report.PID != os.Getpid() || report.GoVersion == "" {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	logger.Log(FATAL, "out of memory")
+	if files, _ = filepath.Glob(filepath.Join(dir, "crash-*.json")); len(files) != 2 {
+		t.Errorf("Expected a crash report for the FATAL entry, got %v", files)
+	}
+}
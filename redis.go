@@ -0,0 +1,256 @@
+package golog
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedisStreamConfig holds Redis Streams sink options.
+type RedisStreamConfig struct {
+	Address     string        // Server address; defaults to "localhost:6379"
+	Username    string        // ACL user name
+	Password    string        // Password; enables AUTH
+	DB          int           // Database number
+	TLSConfig   *tls.Config   // Connect with TLS when set
+	Stream      string        // Stream key template, e.g. "logs:{service}"; placeholders are filled from fields
+	MaxLen      int64         // Trim each stream to about this many entries; 0 disables trimming
+	Batch       BatchConfig   // Batching options
+	DialTimeout time.Duration // Connection and reply timeout; defaults to 5s
+}
+
+// RedisStreamSink appends entries to Redis Streams with XADD. Each stream
+// entry has time, level and message fields plus one field per entry field;
+// strings are stored as is and other values as JSON. A batch is sent as one
+// pipeline. Stream placeholders without a field become "unknown".
+type RedisStreamSink struct {
+	mutex  sync.Mutex
+	config RedisStreamConfig
+	batch  *batcher
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewRedisStreamSink creates a sink appending to the streams named by
+// config.Stream. It connects on first send and reconnects after a failure.
+func NewRedisStreamSink(config RedisStreamConfig) (*RedisStreamSink, error) {
+	if config.Stream == "" {
+		return nil, fmt.Errorf("Redis stream sink requires a stream")
+	}
+	if config.Address == "" {
+		config.Address = "localhost:6379"
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = 5 * time.Second
+	}
+	s := &RedisStreamSink{config: config}
+	s.batch = newBatcher(config.Batch, s.append)
+	return s, nil
+}
+
+// redisKeyPart keeps field values from splitting a stream key.
+var redisKeyPart = strings.NewReplacer(" ", "_", "\t", "_", "\r", "_", "\n", "_")
+
+// Write implements Sink.
+func (s *RedisStreamSink) Write(entry *Entry) error {
+	return s.batch.add(entry)
+}
+
+// Flush implements Flusher.
+func (s *RedisStreamSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close implements Sink, sending any queued entries first.
+func (s *RedisStreamSink) Close() error {
+	err := s.batch.flush()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.disconnect()
+	return err
+}
+
+// append sends a batch as a pipeline of XADD commands, retrying once on a
+// new connection if an established one failed.
+func (s *RedisStreamSink) append(entries []*Entry) error {
+	var pipeline strings.Builder
+	for _, entry := range entries {
+		writeRESP(&pipeline, s.xadd(entry))
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	reused := s.conn != nil
+	err := s.send(pipeline.String(), len(entries))
+	if err != nil && reused && s.conn == nil {
+		err = s.send(pipeline.String(), len(entries))
+	}
+	return err
+}
+
+// xadd returns the XADD command for an entry.
+func (s *RedisStreamSink) xadd(entry *Entry) []string {
+	args := []string{"XADD", expandName(s.config.Stream, entry, redisKeyPart.Replace, "unknown")}
+	if s.config.MaxLen > 0 {
+		args = append(args, "MAXLEN", "~", strconv.FormatInt(s.config.MaxLen, 10))
+	}
+	args = append(args, "*",
+		"time", entry.Time.Format(time.RFC3339Nano),
+		"level", entry.Level.String(),
+		"message", entry.Message)
+
+	keys := make([]string, 0, len(entry.Fields))
+	for k := range entry.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		value := normalizeValue(entry.Fields[k])
+		str, ok := value.(string)
+		if !ok {
+			data, err := json.Marshal(value)
+			if err != nil {
+				data = []byte(fmt.Sprint(value))
+			}
+			str = string(data)
+		}
+		args = append(args, k, str)
+	}
+	return args
+}
+
+// send writes a pipeline of n commands and reads their replies; s.mutex
+// must be held. The connection is dropped on I/O errors but kept when Redis
+// rejects a command.
+func (s *RedisStreamSink) send(pipeline string, n int) error {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+	s.conn.SetDeadline(time.Now().Add(s.config.DialTimeout))
+	if _, err := io.WriteString(s.conn, pipeline); err != nil {
+		s.disconnect()
+		return fmt.Errorf("failed to send to Redis: %v", err)
+	}
+	var firstErr error
+	for i := 0; i < n; i++ {
+		if err := s.readReply(); err != nil {
+			if _, rejected := err.(redisError); !rejected {
+				s.disconnect()
+				return err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// connect opens a connection, authenticating and selecting the database;
+// s.mutex must be held.
+func (s *RedisStreamSink) connect() error {
+	conn, err := dialNetwork("tcp", s.config.Address, s.config.DialTimeout, s.config.TLSConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Redis: %v", err)
+	}
+	s.conn, s.reader = conn, bufio.NewReader(conn)
+
+	var setup [][]string
+	if s.config.Password != "" {
+		if s.config.Username != "" {
+			setup = append(setup, []string{"AUTH", s.config.Username, s.config.Password})
+		} else {
+			setup = append(setup, []string{"AUTH", s.config.Password})
+		}
+	}
+	if s.config.DB != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(s.config.DB)})
+	}
+	if len(setup) == 0 {
+		return nil
+	}
+	var commands strings.Builder
+	for _, args := range setup {
+		writeRESP(&commands, args)
+	}
+	if err := s.send(commands.String(), len(setup)); err != nil {
+		s.disconnect()
+		return fmt.Errorf("failed to set up Redis connection: %v", err)
+	}
+	return nil
+}
+
+// redisError is an error reply from Redis.
+type redisError string
+
+func (e redisError) Error() string {
+	return "Redis error: " + string(e)
+}
+
+// readReply reads one RESP reply, returning error replies as redisError;
+// s.mutex must be held.
+func (s *RedisStreamSink) readReply() error {
+	line, err := s.reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read Redis reply: %v", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return fmt.Errorf("failed to read Redis reply: empty line")
+	}
+	switch line[0] {
+	case '+', ':':
+		return nil
+	case '-':
+		return redisError(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return fmt.Errorf("failed to read Redis reply: %v", err)
+		}
+		if n >= 0 {
+			if _, err := io.CopyN(io.Discard, s.reader, int64(n)+2); err != nil {
+				return fmt.Errorf("failed to read Redis reply: %v", err)
+			}
+		}
+		return nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return fmt.Errorf("failed to read Redis reply: %v", err)
+		}
+		for i := 0; i < n; i++ {
+			if err := s.readReply(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("failed to read Redis reply: unexpected %q", line)
+}
+
+// disconnect drops the connection; s.mutex must be held.
+func (s *RedisStreamSink) disconnect() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn, s.reader = nil, nil
+	}
+}
+
+// writeRESP encodes a command as a RESP array of bulk strings.
+func writeRESP(sb *strings.Builder, args []string) {
+	fmt.Fprintf(sb, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(sb, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+}
@@ -0,0 +1,131 @@
+package golog
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"sync/atomic"
+)
+
+// maxReentrantEntries bounds the entries that sinks may log through their
+// own logger while it writes one entry, so a sink that logs on every write
+// cannot loop forever.
+const maxReentrantEntries = 16
+
+// firingHooks counts the fireHooksOnce calls in progress. Only while it is
+// non-zero can an entry have been logged by a hook, so the calling stack is
+// only inspected then.
+var firingHooks atomic.Int32
+
+// fireHooksName is the function name of Logger.fireHooks in stack frames.
+var fireHooksName = runtime.FuncForPC(reflect.ValueOf((*Logger).fireHooks).Pointer()).Name()
+
+// pendingEntry is an entry deferred by deferEntry, with the named sink it
+// was sent to by To, if any.
+type pendingEntry struct {
+	entry  *Entry
+	target string
+}
+
+// currentGoroutineID returns the ID of the calling goroutine.
+func currentGoroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	id, _ := goroutineID(buf[:n])
+	return id
+}
+
+// reentrant reports whether the calling goroutine is the one dispatching
+// l's sinks, that is, whether a sink is logging through its own logger.
+// The goroutine ID is only looked up while some dispatch is in progress.
+func (l *Logger) reentrant() bool {
+	owner := l.sinkOwner.Load()
+	return owner != 0 && owner == currentGoroutineID()
+}
+
+// deferEntry queues an entry logged by one of l's sinks while l.mutex is
+// held by the same goroutine, to be written once the sinks return: to the
+// named sink target, or to l's regular outputs if target is "".
+func (l *Logger) deferEntry(entry *Entry, target string) {
+	if len(l.pending) >= maxReentrantEntries {
+		l.skipped++
+		return
+	}
+	l.pending = append(l.pending, pendingEntry{entry: entry, target: target})
+}
+
+// drainPending writes the entries deferred during a dispatch; l.mutex must
+// be held. Writing them may defer more, up to maxReentrantEntries in total.
+func (l *Logger) drainPending() {
+	if l.draining {
+		return
+	}
+	l.draining = true
+	for i := 0; i < len(l.pending); i++ {
+		switch p := l.pending[i]; {
+		case p.target != "":
+			l.writeNamedLocked(p.target, p.entry)
+		case l.parent.Load() == nil:
+			l.output(p.entry)
+		default:
+			l.dispatchSinks(p.entry)
+		}
+	}
+	l.draining = false
+	l.pending = nil
+	if l.skipped > 0 {
+		fmt.Fprintf(os.Stderr, "Dropped %d log entries logged recursively by sinks\n", l.skipped)
+		l.skipped = 0
+	}
+}
+
+// flushReentrant implements Flush for a sink flushing, or calling Fatal on,
+// the logger that is writing to it. The deferred entries are written to the
+// console and file only, since the sinks are mid-write, and the file is
+// synced; l.mutex is already held by the calling goroutine. Entries sent
+// to named sinks stay deferred until the sinks return.
+func (l *Logger) flushReentrant() error {
+	var named []pendingEntry
+	for _, p := range l.pending {
+		if p.target != "" {
+			named = append(named, p)
+			continue
+		}
+		l.writeLocal(p.entry, formatEntry(l.formatter, p.entry))
+	}
+	l.pending = named
+	if l.file != nil {
+		if err := l.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync log file: %v", err)
+		}
+	}
+	return nil
+}
+
+// fireHooksOnce runs fireHooks unless the calling goroutine is already
+// running hooks.
+func (l *Logger) fireHooksOnce(entry *Entry) {
+	if firingHooks.Load() > 0 && insideFireHooks() {
+		return
+	}
+	firingHooks.Add(1)
+	defer firingHooks.Add(-1)
+	l.fireHooks(entry)
+}
+
+// insideFireHooks reports whether fireHooks is on the calling goroutine's
+// stack, that is, whether a hook is logging.
+func insideFireHooks() bool {
+	pcs := make([]uintptr, 256)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if frame.Function == fireHooksName {
+			return true
+		}
+		if !more {
+			return false
+		}
+	}
+}
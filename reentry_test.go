@@ -0,0 +1,130 @@
+package golog
+
+import (
+	"bytes"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// echoSink logs a note through its own logger for every entry it receives.
+type echoSink struct {
+	logger *Logger
+	fatal  bool
+	buf    bytes.Buffer
+}
+
+func (s *echoSink) Write(entry *Entry) error {
+	s.buf.WriteString(entry.Message + "\n")
+	if s.fatal {
+		s.logger.Fatal("sink gave up")
+		return nil
+	}
+	s.logger.Warn("sink saw " + entry.Message)
+	return nil
+}
+
+func (s *echoSink) Close() error { return nil }
+
+// loggingHook logs through the logger it is attached to.
+type loggingHook struct {
+	logger *Logger
+	fired  atomic.Int32
+}
+
+func (h *loggingHook) Levels() []LogLevel { return AllLevels }
+
+func (h *loggingHook) Fire(entry *Entry) error {
+	h.fired.Add(1)
+	h.logger.Debug("hook saw " + entry.Message)
+	return nil
+}
+
+// withDeadline fails the test if fn does not return within five seconds.
+func withDeadline(t *testing.T, what string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s deadlocked", what)
+	}
+}
+
+func TestSinkLoggingThroughItsLogger(t *testing.T) {
+	for _, async := range []int{0, 10} {
+		logger, err := NewLogger(Config{Level: INFO, AsyncBuffer: async})
+		if err != nil {
+			t.Fatalf("Failed to create logger: %v", err)
+		}
+		sink := &echoSink{logger: logger}
+		logger.AddSink(sink)
+
+		withDeadline(t, "Logging from a sink", func() {
+			logger.Info("hello")
+			logger.Close()
+		})
+
+		// Every entry the sink logs is echoed again, until the re-entrancy
+		// limit stops the feedback loop.
+		lines := strings.Split(strings.TrimSpace(sink.buf.String()), "\n")
+		if lines[0] != "hello" || lines[1] != "sink saw hello" || len(lines) != 1+maxReentrantEntries {
+			t.Errorf("Unexpected sink entries (async %d): %v", async, lines)
+		}
+	}
+}
+
+func TestNamedSinkLoggingToItself(t *testing.T) {
+	logger, buf := newBufferLogger(t, INFO)
+	sink := &echoSink{logger: logger.To("audit")}
+	logger.AddNamedSink("audit", sink)
+
+	withDeadline(t, "Logging from a named sink", func() { logger.To("audit").Info("refund") })
+
+	lines := strings.Split(strings.TrimSpace(sink.buf.String()), "\n")
+	if lines[0] != "refund" || lines[1] != "sink saw refund" || len(lines) != 1+maxReentrantEntries {
+		t.Errorf("Unexpected named sink entries: %v", lines)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Entries sent to the named sink reached the regular outputs: %s", buf.String())
+	}
+}
+
+func TestFatalFromSink(t *testing.T) {
+	logger, err := NewLogger(Config{Level: INFO, LogToConsole: true})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	var stdout bytes.Buffer
+	logger.stdout = &stdout
+	logger.AddSink(&echoSink{logger: logger, fatal: true})
+
+	exited := make(chan int, 1)
+	SetExitFunc(func(code int) { exited <- code })
+	defer SetExitFunc(nil)
+
+	withDeadline(t, "Fatal from a sink", func() { logger.Error("disk full") })
+	if code := <-exited; code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "FATAL sink gave up") {
+		t.Errorf("Fatal entry from the sink was not written before exit: %s", stdout.String())
+	}
+}
+
+func TestHookLoggingThroughItsLogger(t *testing.T) {
+	logger, buf := newBufferLogger(t, DEBUG)
+	hook := &loggingHook{logger: logger}
+	logger.AddHook(hook)
+
+	withDeadline(t, "Logging from a hook", func() { logger.Info("hello") })
+
+	if hook.fired.Load() != 1 || !strings.Contains(buf.String(), "hook saw hello") {
+		t.Errorf("Unexpected hook behavior (%d calls): %s", hook.fired.Load(), buf.String())
+	}
+}
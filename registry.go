@@ -0,0 +1,246 @@
+package golog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Registry tracks a root logger and the named loggers derived from it.
+// The package-level GetLogger, SetRootLogger and SetLevels functions operate
+// on DefaultRegistry.
+type Registry struct {
+	mutex   sync.Mutex
+	root    *Logger
+	loggers map[string]*Logger
+}
+
+// DefaultRegistry is the registry used by the package-level functions.
+var DefaultRegistry = NewRegistry(newDefaultRoot())
+
+// NewRegistry creates a registry whose named loggers write through root.
+// root.Named resolves names in the new registry.
+func NewRegistry(root *Logger) *Registry {
+	r := newRegistry(root)
+	root.registry.Store(r)
+	return r
+}
+
+// newRegistry creates a registry without binding root.Named to it.
+func newRegistry(root *Logger) *Registry {
+	return &Registry{root: root, loggers: make(map[string]*Logger)}
+}
+
+// newDefaultRoot returns the root logger used until SetRootLogger is called:
+// text output to the console at INFO.
+func newDefaultRoot() *Logger {
+	logger, _ := NewLogger(Config{Level: INFO, LogToConsole: true})
+	return logger
+}
+
+// Root returns the logger that named loggers write through.
+func (r *Registry) Root() *Logger {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.root
+}
+
+// SetRoot replaces the logger that named loggers write through. Existing
+// named loggers switch to the new root immediately.
+func (r *Registry) SetRoot(root *Logger) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.root.registry.CompareAndSwap(r, nil)
+	root.registry.Store(r)
+	r.root = root
+	for name, l := range r.loggers {
+		if !strings.Contains(name, ".") {
+			l.parent.Store(root)
+		}
+	}
+}
+
+// Get returns the named logger for a component, creating it on first use.
+func (r *Registry) Get(name string) *Logger {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.getLocked(name)
+}
+
+// getLocked implements Get; r.mutex must be held.
+func (r *Registry) getLocked(name string) *Logger {
+	if l, ok := r.loggers[name]; ok {
+		return l
+	}
+
+	l := &Logger{name: name}
+	l.level.Store(levelInherit)
+	l.registry.Store(r)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		l.parent.Store(r.getLocked(name[:i]))
+	} else {
+		l.parent.Store(r.root)
+	}
+	r.loggers[name] = l
+	return l
+}
+
+// Lookup returns the named logger if it has been created.
+func (r *Registry) Lookup(name string) (*Logger, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	l, ok := r.loggers[name]
+	return l, ok
+}
+
+// Names returns the names of all registered loggers in sorted order.
+func (r *Registry) Names() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	names := make([]string, 0, len(r.loggers))
+	for name := range r.loggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Loggers returns all registered named loggers sorted by name.
+func (r *Registry) Loggers() []*Logger {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.sortedLocked()
+}
+
+// sortedLocked returns the named loggers sorted by name; r.mutex must be held.
+func (r *Registry) sortedLocked() []*Logger {
+	loggers := make([]*Logger, 0, len(r.loggers))
+	for _, l := range r.loggers {
+		loggers = append(loggers, l)
+	}
+	sort.Slice(loggers, func(i, j int) bool { return loggers[i].name < loggers[j].name })
+	return loggers
+}
+
+// SetLevels applies a level configuration such as
+// "db=debug,http=warn,root=info". Named loggers not mentioned in spec go
+// back to inheriting their parent's level.
+func (r *Registry) SetLevels(spec string) error {
+	levels, err := parseLevelSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, l := range r.loggers {
+		l.storeLevel(levelInherit)
+	}
+	for name, level := range levels {
+		if name == "root" {
+			r.root.SetLevel(level)
+			continue
+		}
+		r.getLocked(name).SetLevel(level)
+	}
+	return nil
+}
+
+// parseLevelSpec parses a level configuration such as
+// "db=debug,http=warn" into levels by name.
+func parseLevelSpec(spec string) (map[string]LogLevel, error) {
+	levels := make(map[string]LogLevel)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid level spec %q: expected name=level", part)
+		}
+		level, err := ParseLevel(value)
+		if err != nil {
+			return nil, err
+		}
+		levels[strings.TrimSpace(name)] = level
+	}
+	return levels, nil
+}
+
+// SetLevelAll sets the root logger to level and makes every named logger
+// inherit it.
+func (r *Registry) SetLevelAll(level LogLevel) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.root.SetLevel(level)
+	for _, l := range r.loggers {
+		l.storeLevel(levelInherit)
+	}
+}
+
+// SetLevelFor temporarily sets the level of the named logger, or of the
+// root logger when name is "" or "root", and reverts it after d. See
+// Logger.WithLevelFor.
+func (r *Registry) SetLevelFor(name string, level LogLevel, d time.Duration) (revert func()) {
+	if name == "" || name == "root" {
+		return r.Root().WithLevelFor(d, level)
+	}
+	return r.Get(name).WithLevelFor(d, level)
+}
+
+// AddSink attaches sink to the root logger, where it receives the entries
+// of every registered logger.
+func (r *Registry) AddSink(sink Sink) {
+	r.Root().AddSink(sink)
+}
+
+// Each calls fn for every registered named logger in name order.
+func (r *Registry) Each(fn func(*Logger)) {
+	r.mutex.Lock()
+	loggers := r.sortedLocked()
+	r.mutex.Unlock()
+
+	for _, l := range loggers {
+		fn(l)
+	}
+}
+
+// FlushAll flushes the root logger and every named logger, returning the
+// first error encountered.
+func (r *Registry) FlushAll() error {
+	r.mutex.Lock()
+	loggers := append(r.sortedLocked(), r.root)
+	r.mutex.Unlock()
+
+	var firstErr error
+	for _, l := range loggers {
+		if err := l.Flush(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to flush logger %q: %v", l.name, err)
+		}
+	}
+	return firstErr
+}
+
+// Reset closes the sinks of all named loggers and forgets them. The root
+// logger is left untouched. It is mainly useful for test cleanup.
+func (r *Registry) Reset() error {
+	r.mutex.Lock()
+	loggers := r.sortedLocked()
+	r.loggers = make(map[string]*Logger)
+	r.mutex.Unlock()
+
+	var firstErr error
+	for _, l := range loggers {
+		if err := l.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close logger %q: %v", l.name, err)
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,68 @@
+package golog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRegistryBulkOperations(t *testing.T) {
+	root, err := NewLogger(Config{Level: INFO})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	registry := NewRegistry(root)
+
+	var buf bytes.Buffer
+	registry.AddSink(NewWriterSink(&buf, &JSONFormatter{}))
+
+	registry.Get("db.pool")
+	registry.Get("http")
+	if names := strings.Join(registry.Names(), ","); names != "db,db.pool,http" {
+		t.Errorf("Unexpected logger names: %s", names)
+	}
+
+	registry.Get("db.pool").SetLevel(ERROR)
+	registry.SetLevelAll(DEBUG)
+	registry.Get("db.pool").Debug("pool debug")
+	registry.Get("http").Trace("http trace")
+
+	if !strings.Contains(buf.String(), `"logger":"db.pool"`) {
+		t.Errorf("Expected db.pool entry in sink after SetLevelAll, got: %s", buf.String())
+	}
+	if strings.Contains(buf.String(), "http trace") {
+		t.Errorf("Entry below the bulk level was logged: %s", buf.String())
+	}
+
+	if err := registry.FlushAll(); err != nil {
+		t.Errorf("FlushAll failed: %v", err)
+	}
+	if err := registry.Reset(); err != nil {
+		t.Errorf("Reset failed: %v", err)
+	}
+	if _, ok := registry.Lookup("http"); ok {
+		t.Errorf("Expected registry to be empty after Reset")
+	}
+}
+
+func TestNamedLoggerSinks(t *testing.T) {
+	root, err := NewLogger(Config{Level: INFO})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	registry := NewRegistry(root)
+
+	var dbBuf, rootBuf bytes.Buffer
+	registry.Get("db").AddSink(NewWriterSink(&dbBuf, nil))
+	root.AddSink(NewWriterSink(&rootBuf, nil))
+
+	registry.Get("db.pool").Info("from pool")
+	registry.Get("http").Info("from http")
+
+	if !strings.Contains(dbBuf.String(), "from pool") || strings.Contains(dbBuf.String(), "from http") {
+		t.Errorf("Unexpected db sink output: %s", dbBuf.String())
+	}
+	if !strings.Contains(rootBuf.String(), "from pool") || !strings.Contains(rootBuf.String(), "from http") {
+		t.Errorf("Unexpected root sink output: %s", rootBuf.String())
+	}
+}
@@ -0,0 +1,193 @@
+package golog
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a RetrySink's circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets writes through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen drops writes until the cooldown has passed.
+	BreakerOpen
+	// BreakerHalfOpen lets a single trial write through after the cooldown.
+	BreakerHalfOpen
+)
+
+// String returns the string representation of the breaker state.
+func (s BreakerState) String() string {
+	return [...]string{"closed", "open", "half-open"}[s]
+}
+
+// RetryConfig holds retry and circuit breaker options for a RetrySink.
+type RetryConfig struct {
+	Attempts         int                              // Attempts per entry, including the first; defaults to 3
+	Backoff          time.Duration                    // Delay before the first retry, doubled for each retry; defaults to 100ms
+	MaxBackoff       time.Duration                    // Upper bound for the delay; defaults to 5s
+	Jitter           float64                          // Randomize each delay by up to this fraction, e.g. 0.2
+	BreakerThreshold int                              // Consecutive failed entries that open the circuit; 0 disables the breaker
+	BreakerCooldown  time.Duration                    // Time the circuit stays open before a trial write; defaults to 30s
+	OnError          func(err error, stats SinkStats) // Called in order on a worker goroutine for every entry that could not be written
+}
+
+// retryErrorQueue bounds the failures waiting for OnError; further ones are
+// counted in SinkStats.Overflow.
+const retryErrorQueue = 64
+
+// SinkStats is a snapshot of a RetrySink's counters and breaker state.
+type SinkStats struct {
+	Written   int64        // Entries written successfully
+	Failed    int64        // Entries given up on after all attempts
+	Retries   int64        // Retried attempts
+	Dropped   int64        // Entries dropped while the circuit was open
+	State     BreakerState // Current breaker state
+	LastError error        // Most recent write error
+	Overflow  int64        // Failures not passed to OnError because its queue was full
+}
+
+// sinkFailure is a failure queued for OnError.
+type sinkFailure struct {
+	err   error
+	stats SinkStats
+}
+
+// RetrySink wraps a sink with a retry policy and a circuit breaker, so a
+// flapping remote endpoint neither loses entries on transient errors nor
+// stalls logging while it is down. Retries sleep inside Write; pair the
+// logger with AsyncBuffer to keep that delay off the calling goroutine.
+type RetrySink struct {
+	mutex    sync.Mutex
+	sink     Sink
+	config   RetryConfig
+	stats    SinkStats
+	failures int // consecutive failed entries
+	openedAt time.Time
+	sleep    func(time.Duration)
+	failed   chan sinkFailure // feeds OnError; nil without it
+	closed   bool
+}
+
+// NewRetrySink wraps sink with the given retry policy. The RetrySink takes
+// ownership of sink and closes it on Close.
+func NewRetrySink(sink Sink, config RetryConfig) *RetrySink {
+	if config.Attempts <= 0 {
+		config.Attempts = 3
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = 100 * time.Millisecond
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = 5 * time.Second
+	}
+	if config.BreakerCooldown <= 0 {
+		config.BreakerCooldown = 30 * time.Second
+	}
+	s := &RetrySink{sink: sink, config: config, sleep: time.Sleep}
+	if config.OnError != nil {
+		s.failed = make(chan sinkFailure, retryErrorQueue)
+		go s.reportFailures()
+	}
+	return s
+}
+
+// reportFailures passes queued failures to OnError. It runs on its own
+// goroutine, as Write is called with the logger's mutex held and a callback
+// that logs to the same logger would otherwise deadlock.
+func (s *RetrySink) reportFailures() {
+	for f := range s.failed {
+		s.config.OnError(f.err, f.stats)
+	}
+}
+
+// Write implements Sink. While the circuit is open entries are dropped
+// without error and counted in Stats.
+func (s *RetrySink) Write(entry *Entry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	attempts := s.config.Attempts
+	switch s.stats.State {
+	case BreakerOpen:
+		if time.Since(s.openedAt) < s.config.BreakerCooldown {
+			s.stats.Dropped++
+			return nil
+		}
+		s.stats.State = BreakerHalfOpen
+		attempts = 1
+	case BreakerHalfOpen:
+		attempts = 1
+	}
+
+	var err error
+	delay := s.config.Backoff
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			s.stats.Retries++
+			s.sleep(s.jitter(delay))
+			if delay *= 2; delay > s.config.MaxBackoff {
+				delay = s.config.MaxBackoff
+			}
+		}
+		if err = s.sink.Write(entry); err == nil {
+			s.stats.Written++
+			s.stats.State = BreakerClosed
+			s.failures = 0
+			return nil
+		}
+	}
+
+	s.stats.Failed++
+	s.stats.LastError = err
+	s.failures++
+	if s.stats.State == BreakerHalfOpen || (s.config.BreakerThreshold > 0 && s.failures >= s.config.BreakerThreshold) {
+		s.stats.State = BreakerOpen
+		s.openedAt = time.Now()
+	}
+	if s.failed != nil && !s.closed {
+		select {
+		case s.failed <- sinkFailure{err: err, stats: s.stats}:
+		default:
+			s.stats.Overflow++
+		}
+	}
+	return err
+}
+
+// jitter randomizes d by up to config.Jitter in either direction.
+func (s *RetrySink) jitter(d time.Duration) time.Duration {
+	if s.config.Jitter <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + s.config.Jitter*(2*rand.Float64()-1)))
+}
+
+// Stats returns a snapshot of the sink's counters and breaker state.
+func (s *RetrySink) Stats() SinkStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.stats
+}
+
+// Flush implements Flusher if the wrapped sink does.
+func (s *RetrySink) Flush() error {
+	if f, ok := s.sink.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close implements Sink. Failures already queued are still passed to
+// OnError.
+func (s *RetrySink) Close() error {
+	s.mutex.Lock()
+	if s.failed != nil && !s.closed {
+		close(s.failed)
+	}
+	s.closed = true
+	s.mutex.Unlock()
+	return s.sink.Close()
+}
@@ -7,9 +7,14 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 )
 
+// renameFile is os.Rename, replaced in tests to simulate platforms where
+// renaming an open file fails.
+var renameFile = os.Rename
+
 // Rotator handles log file rotation.
 type Rotator struct {
 	filePath   string
@@ -29,41 +34,149 @@ func NewRotator(filePath string, maxSizeMB, maxBackups int, compress bool) *Rota
 }
 
 // RotateIfNeeded rotates the log file if it exceeds the size limit.
+//
+// Deprecated: RotateIfNeeded closes file without handing back the reopened
+// log file. Use ShouldRotate and Rotate instead.
 func (r *Rotator) RotateIfNeeded(file *os.File) error {
+	rotate, err := r.ShouldRotate(file)
+	if err != nil || !rotate {
+		return err
+	}
+
+	reopened, _, err := r.Rotate(file)
+	if reopened != nil {
+		reopened.Close()
+	}
+	return err
+}
+
+// ShouldRotate reports whether file has reached the size limit. A rotator
+// without a size limit never rotates.
+func (r *Rotator) ShouldRotate(file *os.File) (bool, error) {
+	if r.maxSize <= 0 {
+		return false, nil
+	}
+
 	info, err := file.Stat()
 	if err != nil {
-		return fmt.Errorf("failed to stat log file: %v", err)
+		return false, fmt.Errorf("failed to stat log file: %v", err)
 	}
+	return r.exceeds(info.Size()), nil
+}
 
-	if info.Size() < r.maxSize {
-		return nil
-	}
+// exceeds reports whether a file of size bytes has reached the size limit.
+func (r *Rotator) exceeds(size int64) bool {
+	return r.maxSize > 0 && size >= r.maxSize
+}
 
+// Rotate closes file, moves it to a timestamped backup (compressing it if
+// configured), removes excess backups and reopens the log path. It returns
+// the reopened file, which is non-nil whenever the log path could be
+// reopened, even if an earlier step failed, and the backup's path.
+//
+// When the log file cannot be renamed, as on Windows while another process
+// has it open, its contents are copied to the backup and it is truncated
+// instead. Lines another process appends between the copy and the
+// truncation are lost, unless it takes the FileLock.
+func (r *Rotator) Rotate(file *os.File) (*os.File, string, error) {
 	if err := file.Close(); err != nil {
-		return fmt.Errorf("failed to close log file: %v", err)
+		return r.reopen(fmt.Errorf("failed to close log file: %v", err))
 	}
 
-	newPath := fmt.Sprintf("%s.%s", r.filePath, time.Now().Format("20060102_150405"))
-	if err := os.Rename(r.filePath, newPath); err != nil {
-		return fmt.Errorf("failed to rename log file: %v", err)
+	newPath := r.backupPath(time.Now())
+	if renameErr := renameLog(r.filePath, newPath); renameErr != nil {
+		if err := copyTruncate(r.filePath, newPath); err != nil {
+			return r.reopen(fmt.Errorf("failed to rename log file: %v; failed to copy it: %v", renameErr, err))
+		}
 	}
 
+	var rotateErr error
 	if r.compress {
 		if err := compressFile(newPath); err != nil {
-			return fmt.Errorf("failed to compress log file: %v", err)
+			rotateErr = fmt.Errorf("failed to compress log file: %v", err)
+		} else {
+			os.Remove(newPath)
+			newPath += ".gz"
 		}
-		os.Remove(newPath)
-		newPath += ".gz"
 	}
 
 	r.cleanupBackups()
 
-	file, err = os.OpenFile(r.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	reopened, _, err := r.reopen(rotateErr)
+	return reopened, newPath, err
+}
+
+// renameLog renames the log file, trying renameAttempts times.
+func renameLog(from, to string) error {
+	for attempt := 1; ; attempt++ {
+		err := renameFile(from, to)
+		if err == nil || attempt >= renameAttempts {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * 10 * time.Millisecond)
+	}
+}
+
+// copyTruncate copies the file at from to a new file at to and truncates
+// the original, which stays the same file for every process that has it
+// open.
+func copyTruncate(from, to string) error {
+	src, err := os.OpenFile(from, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
 	if err != nil {
-		return fmt.Errorf("failed to reopen log file: %v", err)
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(to)
+		return err
+	}
+	return src.Truncate(0)
+}
+
+// backupPath returns a timestamped backup name that is not taken yet.
+// Rotations within the same second get a numeric suffix.
+func (r *Rotator) backupPath(now time.Time) string {
+	base := fmt.Sprintf("%s.%s", r.filePath, now.Format("20060102_150405"))
+	path := base
+	for n := 1; backupExists(path); n++ {
+		path = fmt.Sprintf("%s.%d", base, n)
 	}
+	return path
+}
 
-	return nil
+// backupExists reports whether a backup exists, compressed or not.
+func backupExists(path string) bool {
+	for _, p := range []string{path, path + ".gz"} {
+		if _, err := os.Stat(p); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// reopen opens the log path for appending, combining any failure with the
+// error from an earlier rotation step.
+func (r *Rotator) reopen(cause error) (*os.File, string, error) {
+	file, err := os.OpenFile(r.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		if cause != nil {
+			return nil, "", fmt.Errorf("%v; failed to reopen log file: %v", cause, err)
+		}
+		return nil, "", fmt.Errorf("failed to reopen log file: %v", err)
+	}
+	return file, "", cause
 }
 
 // compressFile compresses a file using gzip.
@@ -87,13 +200,21 @@ func compressFile(filePath string) error {
 	return err
 }
 
-// cleanupBackups removes old log files if the number exceeds maxBackups.
+// cleanupBackups removes old log files if the number exceeds maxBackups,
+// together with their index files. Index and lock files are not backups.
 func (r *Rotator) cleanupBackups() {
-	files, err := filepath.Glob(r.filePath + ".*")
+	matches, err := filepath.Glob(r.filePath + ".*")
 	if err != nil {
 		return
 	}
 
+	var files []string
+	for _, f := range matches {
+		if !strings.HasSuffix(f, IndexSuffix) && !strings.HasSuffix(f, LockSuffix) {
+			files = append(files, f)
+		}
+	}
+
 	if len(files) <= r.maxBackups {
 		return
 	}
@@ -119,5 +240,6 @@ func (r *Rotator) cleanupBackups() {
 	// Remove oldest files
 	for i := r.maxBackups; i < len(fileInfos); i++ {
 		os.Remove(fileInfos[i].name)
+		os.Remove(fileInfos[i].name + IndexSuffix)
 	}
 }
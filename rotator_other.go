@@ -0,0 +1,8 @@
+//go:build !windows
+
+package golog
+
+// renameAttempts is how often rotation tries to rename the log file before
+// falling back to copy-truncate. Elsewhere renaming an open file succeeds,
+// so a failure is not worth retrying.
+const renameAttempts = 1
@@ -0,0 +1,46 @@
+//go:build unix
+
+package golog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotationRenamesOpenFile(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "test.log")
+	logger, err := NewLogger(Config{Level: INFO, FilePath: logFile, MaxSizeMB: 1, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+	other, err := os.Open(logFile)
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+	defer other.Close()
+
+	for i := 0; i < 1030; i++ {
+		logger.Info(strings.Repeat("x", 1024))
+	}
+	logger.Info("after rotation")
+
+	// Renaming keeps the open handle on the rotated file, whose contents
+	// are left intact.
+	backups, _ := filepath.Glob(logFile + ".*")
+	if len(backups) != 1 {
+		t.Fatalf("Expected one backup, got %v", backups)
+	}
+	info, _ := other.Stat()
+	backup, _ := os.Stat(backups[0])
+	if !os.SameFile(info, backup) {
+		t.Errorf("Expected the open handle to follow the renamed file")
+	}
+	data, _ := io.ReadAll(other)
+	if len(data) < 1024*1024 || strings.Contains(string(data), "after rotation") {
+		t.Errorf("Unexpected rotated file contents: %d bytes", len(data))
+	}
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package golog
+
+// renameAttempts is how often rotation tries to rename the log file before
+// falling back to copy-truncate. Windows refuses to rename a file another
+// process has open, which for log shippers and virus scanners is often
+// only briefly.
+const renameAttempts = 5
@@ -0,0 +1,39 @@
+//go:build windows
+
+package golog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotationWhileOpenElsewhere(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "test.log")
+	logger, err := NewLogger(Config{Level: INFO, FilePath: logFile, MaxSizeMB: 1, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+	// An open handle without FILE_SHARE_DELETE makes renaming fail.
+	other, err := os.Open(logFile)
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+	defer other.Close()
+
+	for i := 0; i < 1030; i++ {
+		logger.Info(strings.Repeat("x", 1024))
+	}
+	logger.Info("after rotation")
+
+	backups, _ := filepath.Glob(logFile + ".*")
+	if len(backups) != 1 {
+		t.Fatalf("Expected one backup, got %v", backups)
+	}
+	current, err := os.ReadFile(logFile)
+	if err != nil || len(current) >= 1024*1024 || !strings.Contains(string(current), "after rotation") {
+		t.Errorf("Expected the log file to be truncated and written to, got %d bytes, %v", len(current), err)
+	}
+}
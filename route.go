@@ -0,0 +1,116 @@
+package golog
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// AddNamedSink attaches a sink that receives only the entries sent to name
+// with To, such as billing or security events that need a dedicated
+// output. Adding a sink under a name already in use closes the sink it
+// replaces. The logger takes ownership of the sink and closes it on Close.
+func (l *Logger) AddNamedSink(name string, sink Sink) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.namedSinks == nil {
+		l.namedSinks = make(map[string]Sink)
+	}
+	if previous, ok := l.namedSinks[name]; ok {
+		if err := previous.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close sink: %v\n", err)
+		}
+	}
+	l.namedSinks[name] = sink
+}
+
+// To returns a logger whose entries are written to the named sinks instead
+// of the console, file and unnamed sinks:
+//
+//	logger.To("audit").Info("refund issued", map[string]interface{}{"order": id})
+//
+// Each name is looked up on l and then its ancestors, so a sink added to
+// the root logger is reachable from every named logger. Hooks fire as
+// usual. An entry whose names match no sink is written to the regular
+// outputs instead, so it is not lost to a misconfiguration.
+func (l *Logger) To(names ...string) *Logger {
+	child := l.withStatic(l.fields)
+	child.targets = names
+	return child
+}
+
+// deliverTo writes an entry directed by To to the named sinks found on l
+// and its ancestors, and reports whether there were any.
+func (l *Logger) deliverTo(entry *Entry, names []string) bool {
+	found := false
+	for _, name := range names {
+		for cur := l; cur != nil; cur = cur.parent.Load() {
+			if cur.writeNamed(name, entry) {
+				found = true
+				break
+			}
+		}
+	}
+	return found
+}
+
+// writeNamed writes an entry to the sink named name, if l has one.
+func (l *Logger) writeNamed(name string, entry *Entry) bool {
+	if l.reentrant() {
+		if _, ok := l.namedSinks[name]; !ok {
+			return false
+		}
+		l.deferEntry(entry, name)
+		return true
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if !l.writeNamedLocked(name, entry) {
+		return false
+	}
+	l.drainPending()
+	return true
+}
+
+// writeNamedLocked implements writeNamed; l.mutex must be held.
+func (l *Logger) writeNamedLocked(name string, entry *Entry) bool {
+	sink, ok := l.namedSinks[name]
+	if !ok {
+		return false
+	}
+	l.sinkOwner.Store(currentGoroutineID())
+	if err := sink.Write(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write to sink %s: %v\n", name, err)
+	}
+	l.sinkOwner.Store(0)
+	return true
+}
+
+// routedTargets returns the sink names set by To on l or the logger it was
+// derived from.
+func (l *Logger) routedTargets() []string {
+	for cur := l; cur != nil; cur = cur.parent.Load() {
+		if cur.targets != nil {
+			return cur.targets
+		}
+	}
+	return nil
+}
+
+// allSinks returns the unnamed sinks followed by the named ones, in name
+// order; l.mutex must be held.
+func (l *Logger) allSinks() []Sink {
+	if len(l.namedSinks) == 0 {
+		return l.sinks
+	}
+	names := make([]string, 0, len(l.namedSinks))
+	for name := range l.namedSinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sinks := append([]Sink(nil), l.sinks...)
+	for _, name := range names {
+		sinks = append(sinks, l.namedSinks[name])
+	}
+	return sinks
+}
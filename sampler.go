@@ -0,0 +1,68 @@
+package golog
+
+import (
+	"sync"
+	"time"
+)
+
+// sampler caps repetitive entries: within each second it lets through the
+// first `initial` entries with the same level and message, then every
+// `thereafter`-th one. WARN and above are never sampled.
+type sampler struct {
+	mutex      sync.Mutex
+	initial    int
+	thereafter int
+	tick       time.Time
+	counts     map[sampleKey]int
+}
+
+// sampleKey identifies entries that are sampled together.
+type sampleKey struct {
+	level LogLevel
+	msg   string
+}
+
+// SampleRateKey is the field holding the sample rate of an entry the
+// sampler let through after the initial ones: each such entry stands for
+// that many entries.
+const SampleRateKey = "sample_rate"
+
+// maxSampleKeys bounds the per-second counters under message cardinality
+// explosions; beyond it entries are counted under a shared key.
+const maxSampleKeys = 4096
+
+// newSampler returns nil if initial disables sampling.
+func newSampler(initial, thereafter int) *sampler {
+	if initial <= 0 {
+		return nil
+	}
+	return &sampler{initial: initial, thereafter: thereafter, counts: make(map[sampleKey]int)}
+}
+
+// rate returns 0 if an entry at level with msg should be dropped, and
+// otherwise the number of entries logging it stands for.
+func (s *sampler) rate(level LogLevel, msg string, now time.Time) int {
+	if level >= WARN {
+		return 1
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if tick := now.Truncate(time.Second); !tick.Equal(s.tick) {
+		s.tick = tick
+		clear(s.counts)
+	}
+	key := sampleKey{level, msg}
+	if _, ok := s.counts[key]; !ok && len(s.counts) >= maxSampleKeys {
+		key = sampleKey{level, ""}
+	}
+	s.counts[key]++
+	n := s.counts[key]
+	switch {
+	case n <= s.initial:
+		return 1
+	case s.thereafter > 0 && (n-s.initial)%s.thereafter == 0:
+		return s.thereafter
+	}
+	return 0
+}
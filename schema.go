@@ -0,0 +1,191 @@
+package golog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FieldType is the type a schema requires of a field.
+type FieldType int
+
+const (
+	// FieldAny accepts any value.
+	FieldAny FieldType = iota
+	// FieldString requires a string.
+	FieldString
+	// FieldInt requires an integer.
+	FieldInt
+	// FieldNumber requires an integer or floating-point number.
+	FieldNumber
+	// FieldBool requires a bool.
+	FieldBool
+	// FieldTime requires a time.Time.
+	FieldTime
+	// FieldDuration requires a time.Duration or a Duration value.
+	FieldDuration
+)
+
+// String returns the type name used in violations.
+func (t FieldType) String() string {
+	return [...]string{"any", "string", "int", "number", "bool", "time", "duration"}[t]
+}
+
+// SchemaPolicy selects what happens to an entry that violates a schema.
+type SchemaPolicy int
+
+const (
+	// SchemaAnnotate writes the entry with a schema_errors field listing
+	// the violations.
+	SchemaAnnotate SchemaPolicy = iota
+	// SchemaDrop discards the entry, reporting it on stderr.
+	SchemaDrop
+	// SchemaPanic panics in the logging call, so contract violations fail
+	// tests and development runs loudly.
+	SchemaPanic
+)
+
+// SchemaErrorsKey is the field listing an entry's schema violations under
+// SchemaAnnotate.
+const SchemaErrorsKey = "schema_errors"
+
+// FieldRule constrains one field.
+type FieldRule struct {
+	Type     FieldType                     // Required type; FieldAny accepts any value
+	Required bool                          // The field must be present
+	Enum     []string                      // Allowed values, compared in their fmt.Sprint form; empty allows any
+	Check    func(value interface{}) error // Further validation of present values
+}
+
+// Schema declares the fields structured entries must carry, so services
+// keep a consistent log contract:
+//
+//	schema := &golog.Schema{Fields: map[string]golog.FieldRule{
+//		"request_id": {Type: golog.FieldString, Required: true},
+//		"status":     {Type: golog.FieldInt},
+//		"region":     {Enum: []string{"eu", "us"}},
+//	}}
+//
+// Entries are checked after Middleware, so renamed fields are checked under
+// their new names.
+type Schema struct {
+	Fields map[string]FieldRule    // Rules by field name; fields without a rule are not checked
+	Match  func(entry *Entry) bool // Limits the entries checked; nil checks all
+	Policy SchemaPolicy            // What to do with violating entries
+}
+
+// Validate checks an entry against the schema, returning the violations
+// joined into one error, or nil.
+func (s *Schema) Validate(entry *Entry) error {
+	violations := s.violations(entry)
+	if len(violations) == 0 {
+		return nil
+	}
+	errs := make([]error, len(violations))
+	for i, v := range violations {
+		errs[i] = errors.New(v)
+	}
+	return errors.Join(errs...)
+}
+
+// violations lists the entry's violations in field order.
+func (s *Schema) violations(entry *Entry) []string {
+	if s.Match != nil && !s.Match(entry) {
+		return nil
+	}
+	names := make([]string, 0, len(s.Fields))
+	for name := range s.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var violations []string
+	for _, name := range names {
+		rule := s.Fields[name]
+		value, ok := entry.Fields[name]
+		if !ok {
+			if rule.Required {
+				violations = append(violations, fmt.Sprintf("missing required field %s", name))
+			}
+			continue
+		}
+		if !rule.Type.accepts(value) {
+			violations = append(violations, fmt.Sprintf("field %s is %T, want %s", name, value, rule.Type))
+			continue
+		}
+		if len(rule.Enum) > 0 && !containsString(rule.Enum, fmt.Sprint(value)) {
+			violations = append(violations, fmt.Sprintf("field %s is %v, want one of %s", name, value, strings.Join(rule.Enum, ", ")))
+			continue
+		}
+		if rule.Check != nil {
+			if err := rule.Check(value); err != nil {
+				violations = append(violations, fmt.Sprintf("field %s: %v", name, err))
+			}
+		}
+	}
+	return violations
+}
+
+// accepts reports whether value has type t.
+func (t FieldType) accepts(value interface{}) bool {
+	switch value.(type) {
+	case time.Duration, DurationValue:
+		return t == FieldAny || t == FieldDuration
+	case time.Time:
+		return t == FieldAny || t == FieldTime
+	}
+	if t == FieldAny {
+		return true
+	}
+	if value == nil {
+		return false
+	}
+	switch kind := reflect.TypeOf(value).Kind(); t {
+	case FieldString:
+		return kind == reflect.String
+	case FieldBool:
+		return kind == reflect.Bool
+	case FieldInt:
+		return isIntKind(kind)
+	case FieldNumber:
+		return isIntKind(kind) || kind == reflect.Float32 || kind == reflect.Float64
+	}
+	return false
+}
+
+// isIntKind reports whether kind is a signed or unsigned integer.
+func isIntKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Uintptr
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// enforce applies the schema policy to an entry and reports whether
+// it should be written.
+func (s *Schema) enforce(entry *Entry) bool {
+	violations := s.violations(entry)
+	if len(violations) == 0 {
+		return true
+	}
+	switch s.Policy {
+	case SchemaDrop:
+		fmt.Fprintf(os.Stderr, "Dropped log entry %q: %s\n", entry.Message, strings.Join(violations, "; "))
+		return false
+	case SchemaPanic:
+		panic(fmt.Sprintf("golog: log entry %q violates schema: %s", entry.Message, strings.Join(violations, "; ")))
+	}
+	entry.Fields[SchemaErrorsKey] = violations
+	return true
+}
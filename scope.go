@@ -0,0 +1,77 @@
+package golog
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Scope is a logger for one operation. Every entry it writes carries the
+// scope name, ID and nesting depth, and the parent scope's ID when nested,
+// so an operation's entries can be grouped into a lightweight trace.
+type Scope struct {
+	*Logger
+	id    string
+	name  string
+	start time.Time
+	once  sync.Once
+}
+
+// Scope logs "begin <name>" and returns a scoped logger for the operation.
+// Calling Scope on a scoped logger starts a nested scope one level deeper.
+// Close the scope, typically via defer, to log "end <name>" with the
+// elapsed duration.
+func (l *Logger) Scope(name string, fields ...map[string]interface{}) *Scope {
+	id := newScopeID()
+	static := make(map[string]interface{}, len(l.fields)+4)
+	for k, v := range l.fields {
+		static[k] = v
+	}
+	depth := 0
+	if parentID, ok := l.fields["scope_id"]; ok {
+		static["parent_scope_id"] = parentID
+		if d, ok := l.fields["scope_depth"].(int); ok {
+			depth = d + 1
+		}
+	}
+	static["scope"] = name
+	static["scope_id"] = id
+	static["scope_depth"] = depth
+
+	child := l.withStatic(static)
+	s := &Scope{Logger: child, id: id, name: name, start: time.Now()}
+	child.log(INFO, "begin "+name, mergeFields(fields))
+	return s
+}
+
+// withStatic returns a child logger that adds static to every entry and
+// otherwise behaves like l.
+func (l *Logger) withStatic(static map[string]interface{}) *Logger {
+	child := &Logger{name: l.name, fields: static}
+	child.level.Store(levelInherit)
+	child.parent.Store(l)
+	return child
+}
+
+// ID returns the scope's unique ID.
+func (s *Scope) ID() string {
+	return s.id
+}
+
+// Close logs "end <name>" with the elapsed duration in an "elapsed" field.
+// Only the first call logs.
+func (s *Scope) Close(fields ...map[string]interface{}) {
+	s.once.Do(func() {
+		merged := mergeFields(fields)
+		merged["elapsed"] = Duration(time.Since(s.start))
+		s.Logger.log(INFO, "end "+s.name, merged)
+	})
+}
+
+// newScopeID returns a random 16-character hex ID.
+func newScopeID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,47 @@
+package golog
+
+import (
+	"io"
+	"sync"
+)
+
+// Sink is an additional log output. Loggers call Write for every entry at
+// or above their level, serialized by the logger's mutex.
+type Sink interface {
+	Write(entry *Entry) error
+	Close() error
+}
+
+// Flusher is implemented by sinks that buffer entries.
+type Flusher interface {
+	Flush() error
+}
+
+// WriterSink writes formatted entries to an io.Writer.
+type WriterSink struct {
+	mutex     sync.Mutex
+	writer    io.Writer
+	formatter Formatter
+}
+
+// NewWriterSink creates a sink that renders entries with formatter and
+// writes them to w. A nil formatter defaults to TextFormatter.
+func NewWriterSink(w io.Writer, formatter Formatter) *WriterSink {
+	if formatter == nil {
+		formatter = &TextFormatter{}
+	}
+	return &WriterSink{writer: w, formatter: formatter}
+}
+
+// Write implements Sink.
+func (s *WriterSink) Write(entry *Entry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, err := io.WriteString(s.writer, formatEntry(s.formatter, entry))
+	return err
+}
+
+// Close implements Sink. The writer is owned by the caller and is left open.
+func (s *WriterSink) Close() error {
+	return nil
+}
@@ -0,0 +1,472 @@
+package golog
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakySink records entry messages and fails while down is set.
+type flakySink struct {
+	mutex    sync.Mutex
+	down     bool
+	messages []string
+	closed   bool
+}
+
+func (s *flakySink) Write(entry *Entry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.down {
+		return errors.New("remote unavailable")
+	}
+	s.messages = append(s.messages, entry.Message)
+	return nil
+}
+
+func (s *flakySink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *flakySink) received() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+func TestSpoolSinkSurvivesRestart(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spool")
+	down := &flakySink{down: true}
+	spool, err := NewSpoolSink(down, SpoolConfig{Dir: dir, RetryInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to create spool: %v", err)
+	}
+	for _, msg := range []string{"one", "two", "three"} {
+		if err := spool.Write(&Entry{Time: time.Now(), Level: INFO, Message: msg, Fields: map[string]interface{}{"n": 1}}); err != nil {
+			t.Fatalf("Failed to spool entry: %v", err)
+		}
+	}
+	if err := spool.Close(); err != nil {
+		t.Fatalf("Failed to close spool: %v", err)
+	}
+	if len(down.received()) != 0 || spool.Pending() == 0 {
+		t.Fatalf("Expected entries to stay spooled while the sink is down")
+	}
+
+	// Append a torn record, as left by a crash mid-write.
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.spool"))
+	if len(segments) != 1 {
+		t.Fatalf("Expected one segment, got %v", segments)
+	}
+	f, _ := os.OpenFile(segments[0], os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`deadbeef {"message":"torn`)
+	f.Close()
+
+	up := &flakySink{}
+	spool, err = NewSpoolSink(up, SpoolConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Failed to reopen spool: %v", err)
+	}
+	spool.Write(&Entry{Time: time.Now(), Level: INFO, Message: "four"})
+	if err := spool.Close(); err != nil {
+		t.Fatalf("Failed to close spool: %v", err)
+	}
+
+	got := up.received()
+	if len(got) != 4 || got[0] != "one" || got[2] != "three" || got[3] != "four" {
+		t.Errorf("Unexpected delivery order: %v", got)
+	}
+	if !up.closed {
+		t.Errorf("Expected the wrapped sink to be closed")
+	}
+	if n := spool.Pending(); n != 0 {
+		t.Errorf("Expected empty spool, %d bytes pending", n)
+	}
+}
+
+func TestRetrySinkCircuitBreaker(t *testing.T) {
+	remote := &flakySink{down: true}
+	reported := make(chan SinkStats, 10)
+	sink := NewRetrySink(remote, RetryConfig{
+		Attempts:         3,
+		Backoff:          time.Millisecond,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Hour,
+		OnError:          func(err error, stats SinkStats) { reported <- stats },
+	})
+	var slept []time.Duration
+	sink.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	entry := &Entry{Time: time.Now(), Level: INFO, Message: "hello"}
+	for i := 0; i < 2; i++ {
+		if err := sink.Write(entry); err == nil {
+			t.Fatalf("Expected write %d to fail", i)
+		}
+	}
+	if err := sink.Write(entry); err != nil {
+		t.Errorf("Expected open circuit to drop silently, got %v", err)
+	}
+
+	stats := sink.Stats()
+	if stats.State != BreakerOpen || stats.Failed != 2 || stats.Retries != 4 || stats.Dropped != 1 {
+		t.Errorf("Unexpected stats while open: %+v", stats)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-reported:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("OnError was called %d times, expected 2", i)
+		}
+	}
+	if len(slept) != 4 || slept[0] != time.Millisecond || slept[1] != 2*time.Millisecond {
+		t.Errorf("Unexpected backoff delays: %v", slept)
+	}
+
+	remote.mutex.Lock()
+	remote.down = false
+	remote.mutex.Unlock()
+	sink.openedAt = time.Now().Add(-2 * time.Hour)
+	if err := sink.Write(entry); err != nil {
+		t.Fatalf("Expected trial write to succeed: %v", err)
+	}
+	if stats := sink.Stats(); stats.State != BreakerClosed || stats.Written != 1 {
+		t.Errorf("Unexpected stats after recovery: %+v", stats)
+	}
+}
+
+func TestRetrySinkOnErrorLogs(t *testing.T) {
+	logger, buf := newBufferLogger(t, INFO)
+	logged := make(chan struct{})
+	sink := NewRetrySink(&flakySink{down: true}, RetryConfig{
+		Attempts:         1,
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Hour,
+		OnError: func(err error, stats SinkStats) {
+			logger.Warn("sink failing", map[string]interface{}{"error": err.Error()})
+			close(logged)
+		},
+	})
+	logger.AddSink(sink)
+
+	logger.Info("hello")
+	select {
+	case <-logged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Logging from OnError deadlocked")
+	}
+	if !strings.Contains(buf.String(), "sink failing") {
+		t.Errorf("Entry logged from OnError was not written: %s", buf.String())
+	}
+}
+
+func TestRetrySinkOnErrorOrder(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var mutex sync.Mutex
+	var failed []int64
+	sink := NewRetrySink(&flakySink{down: true}, RetryConfig{
+		Attempts: 1,
+		OnError: func(err error, stats SinkStats) {
+			mutex.Lock()
+			failed = append(failed, stats.Failed)
+			mutex.Unlock()
+			if stats.Failed == 1 {
+				close(started)
+				<-release
+			}
+		},
+	})
+
+	entry := &Entry{Time: time.Now(), Level: INFO, Message: "hello"}
+	sink.Write(entry)
+	<-started
+	for i := 0; i < retryErrorQueue+5; i++ {
+		sink.Write(entry)
+	}
+	if overflow := sink.Stats().Overflow; overflow != 5 {
+		t.Errorf("Expected 5 unreported failures, got %d", overflow)
+	}
+	close(release)
+	sink.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mutex.Lock()
+		n := len(failed)
+		mutex.Unlock()
+		if n == 1+retryErrorQueue || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(failed) != 1+retryErrorQueue {
+		t.Fatalf("Expected %d OnError calls, got %d", 1+retryErrorQueue, len(failed))
+	}
+	for i, n := range failed {
+		if n != int64(i+1) {
+			t.Fatalf("OnError calls out of order: %v", failed)
+		}
+	}
+}
+
+func TestLevelSinkAndHook(t *testing.T) {
+	logger, err := NewLogger(Config{Level: INFO})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	alerts := &flakySink{}
+	sink := NewLevelSink(alerts, ERROR)
+	logger.AddSink(sink)
+	hook := NewLevelHook(&countingHook{}, WARN)
+	logger.AddHook(hook)
+
+	logger.Warn("disk at 80%")
+	logger.Error("disk full")
+	sink.SetLevel(FATAL)
+	logger.Error("disk still full")
+
+	if got := alerts.received(); strings.Join(got, ",") != "disk full" {
+		t.Errorf("Unexpected alert sink entries: %v", got)
+	}
+	if fired := hook.hook.(*countingHook).fired.Load(); fired != 3 {
+		t.Errorf("Expected hook to fire 3 times, got %d", fired)
+	}
+	hook.SetLevel(FATAL)
+	logger.Error("muted")
+	if fired := hook.hook.(*countingHook).fired.Load(); fired != 3 {
+		t.Errorf("Expected muted hook not to fire, got %d calls", fired)
+	}
+}
+
+// hangingSink blocks every write until released.
+type hangingSink struct {
+	release chan struct{}
+}
+
+func (s *hangingSink) Write(entry *Entry) error {
+	<-s.release
+	return nil
+}
+
+func (s *hangingSink) Close() error { return nil }
+
+func TestTimeoutSink(t *testing.T) {
+	hung := &hangingSink{release: make(chan struct{})}
+	fallback := &flakySink{}
+	sink := NewTimeoutSink(hung, 20*time.Millisecond, fallback)
+	logger, err := NewLogger(Config{Level: INFO})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.AddSink(sink)
+
+	start := time.Now()
+	logger.Info("first")
+	logger.Info("second")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Logging waited %v on a hung sink", elapsed)
+	}
+	if got := fallback.received(); strings.Join(got, ",") != "first,second" || sink.Timeouts() != 1 {
+		t.Errorf("Unexpected fallback entries %v after %d timeouts", got, sink.Timeouts())
+	}
+
+	close(hung.release)
+	logger.Close()
+}
+
+func TestSocketSinkStream(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collector.sock")
+	listen := func() net.Listener {
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		return listener
+	}
+	readLine := func(listener net.Listener) string {
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatalf("Failed to accept: %v", err)
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		return line
+	}
+
+	sink, err := NewSocketSink(SocketSinkConfig{Address: path, ReconnectBackoff: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	defer sink.Close()
+	if err := sink.Write(&Entry{Time: time.Now(), Level: INFO, Message: "collector down"}); err == nil {
+		t.Fatal("Expected write without a collector to fail")
+	}
+	if err := sink.Write(&Entry{Time: time.Now(), Level: INFO, Message: "backing off"}); err == nil || !strings.Contains(err.Error(), "retrying in") {
+		t.Fatalf("Expected reconnect backoff, got %v", err)
+	}
+
+	sink.failedAt = time.Time{}
+	listener := listen()
+	sink.Write(&Entry{Time: time.Now(), Level: INFO, Message: "first"})
+	if line := readLine(listener); !strings.Contains(line, `"message":"first"`) || !strings.HasSuffix(line, "}\n") {
+		t.Errorf("Unexpected line: %q", line)
+	}
+
+	// The collector restarts; the sink reconnects on the next write.
+	listener.Close()
+	listener = listen()
+	defer listener.Close()
+	lines := make(chan string, 1)
+	go func() { lines <- readLine(listener) }()
+	var werr error
+	for i := 0; i < 5; i++ {
+		if werr = sink.Write(&Entry{Time: time.Now(), Level: INFO, Message: "after restart"}); werr == nil {
+			break
+		}
+	}
+	if werr != nil {
+		t.Fatalf("Expected the sink to reconnect: %v", werr)
+	}
+	select {
+	case line := <-lines:
+		if !strings.Contains(line, "after restart") {
+			t.Errorf("Unexpected line after restart: %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the reconnected write")
+	}
+}
+
+func TestSocketSinkDatagram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collector.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	sink, _ := NewSocketSink(SocketSinkConfig{Network: "unixgram", Address: path, Formatter: &TextFormatter{}})
+	defer sink.Close()
+	for _, msg := range []string{"one", "two"} {
+		if err := sink.Write(&Entry{Time: time.Now(), Level: WARN, Message: msg}); err != nil {
+			t.Fatalf("Failed to write datagram: %v", err)
+		}
+	}
+	buf := make([]byte, 4096)
+	for _, msg := range []string{"one", "two"} {
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("Failed to read datagram: %v", err)
+		}
+		if got := string(buf[:n]); !strings.Contains(got, msg) || strings.Count(got, "\n") != 1 {
+			t.Errorf("Unexpected datagram: %q", got)
+		}
+	}
+}
+
+func TestConfigOutputs(t *testing.T) {
+	var custom []*url.URL
+	if err := RegisterSink("Mem", func(u *url.URL) (Sink, error) {
+		custom = append(custom, u)
+		return &flakySink{}, nil
+	}); err != nil {
+		t.Fatalf("Failed to register sink: %v", err)
+	}
+	if err := RegisterSink("mem", func(u *url.URL) (Sink, error) { return nil, nil }); err == nil {
+		t.Error("Expected a duplicate scheme to be rejected")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	lines := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		lines <- line
+	}()
+
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	logger, err := NewLogger(Config{Level: INFO, Outputs: []string{
+		"file://" + logPath + "?rotate=1KB&backups=2&format=json",
+		"tcp://" + listener.Addr().String() + "?format=logfmt",
+		"mem://inbox/?job=api",
+	}})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	for _, msg := range []string{"hello", "again", "rotated"} {
+		logger.Info(msg, map[string]interface{}{"padding": strings.Repeat("x", 600)})
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Failed to close logger: %v", err)
+	}
+
+	select {
+	case line := <-lines:
+		if !strings.HasPrefix(line, "time=") || !strings.Contains(line, "msg=hello") {
+			t.Errorf("Unexpected tcp line: %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the tcp output")
+	}
+	content, _ := os.ReadFile(logPath)
+	backups, _ := filepath.Glob(logPath + ".*")
+	if !strings.Contains(string(content), `"message":"rotated"`) || len(backups) != 1 {
+		t.Errorf("Expected the file output to rotate after 1KB, got %d backups and %q", len(backups), content)
+	}
+	if len(custom) != 1 || custom[0].Host != "inbox" || custom[0].Query().Get("job") != "api" {
+		t.Errorf("Unexpected custom sink URL: %v", custom)
+	}
+
+	for _, output := range []string{"nope://x", "file:///tmp/app.log?rotation=1MB", "tcp://host:1?format=xml"} {
+		if _, err := NewLogger(Config{Outputs: []string{output}}); err == nil {
+			t.Errorf("Expected output %q to be rejected", output)
+		}
+	}
+}
+
+func TestToNamedSink(t *testing.T) {
+	logger, buf := newBufferLogger(t, INFO)
+	audit, replaced := &flakySink{}, &flakySink{}
+	logger.AddNamedSink("audit", replaced)
+	logger.AddNamedSink("audit", audit)
+	if !replaced.closed {
+		t.Error("Expected the replaced sink to be closed")
+	}
+
+	logger.Info("regular")
+	logger.To("audit").Info("refund issued")
+	scope := logger.To("audit").Scope("settlement")
+	scope.Close()
+	logger.To("billing").Warn("no such sink")
+
+	if got := audit.received(); strings.Join(got, ",") != "refund issued,begin settlement,end settlement" {
+		t.Errorf("Unexpected audit entries: %v", got)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "regular") || !strings.Contains(output, "no such sink") || strings.Contains(output, "refund") || strings.Contains(output, "settlement") {
+		t.Errorf("Unexpected regular output: %s", output)
+	}
+	logger.Close()
+	if !audit.closed {
+		t.Error("Expected Close to close named sinks")
+	}
+}
@@ -0,0 +1,239 @@
+package sinktest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TCPServer is a line-oriented TCP collector that can go away and come back
+// on the same address, or cut connections, to exercise reconnects.
+type TCPServer struct {
+	t        testing.TB
+	addr     string
+	mutex    sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]bool
+	lines    []string
+	dropNext int
+	wg       sync.WaitGroup
+}
+
+// NewTCPServer starts a collector on a free loopback port. It is stopped
+// when the test ends.
+func NewTCPServer(t testing.TB) *TCPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := &TCPServer{t: t, addr: listener.Addr().String(), conns: make(map[net.Conn]bool)}
+	s.serve(listener)
+	t.Cleanup(s.Stop)
+	return s
+}
+
+// Addr returns the host:port the server listens on.
+func (s *TCPServer) Addr() string {
+	return s.addr
+}
+
+// Lines returns the lines received so far, without newlines.
+func (s *TCPServer) Lines() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.lines...)
+}
+
+// DropNext makes the server close the next n connections as soon as they
+// are accepted, before reading from them.
+func (s *TCPServer) DropNext(n int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.dropNext = n
+}
+
+// Disconnect closes every open connection while continuing to listen.
+func (s *TCPServer) Disconnect() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// Stop closes the listener and every connection, so connection attempts
+// are refused until Start.
+func (s *TCPServer) Stop() {
+	s.mutex.Lock()
+	if s.listener != nil {
+		s.listener.Close()
+		s.listener = nil
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mutex.Unlock()
+	s.wg.Wait()
+}
+
+// Start listens again on the original address after Stop.
+func (s *TCPServer) Start() {
+	s.t.Helper()
+	s.mutex.Lock()
+	running := s.listener != nil
+	s.mutex.Unlock()
+	if running {
+		return
+	}
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		s.t.Fatalf("Failed to listen on %s again: %v", s.addr, err)
+	}
+	s.serve(listener)
+}
+
+// serve accepts connections on listener until it is closed.
+func (s *TCPServer) serve(listener net.Listener) {
+	s.mutex.Lock()
+	s.listener = listener
+	s.mutex.Unlock()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mutex.Lock()
+			if s.dropNext > 0 {
+				s.dropNext--
+				s.mutex.Unlock()
+				conn.Close()
+				continue
+			}
+			s.conns[conn] = true
+			s.mutex.Unlock()
+			s.wg.Add(1)
+			go s.read(conn)
+		}
+	}()
+}
+
+// read records the lines of one connection.
+func (s *TCPServer) read(conn net.Conn) {
+	defer s.wg.Done()
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		s.mutex.Lock()
+		s.lines = append(s.lines, scanner.Text())
+		s.mutex.Unlock()
+	}
+	s.mutex.Lock()
+	delete(s.conns, conn)
+	s.mutex.Unlock()
+	conn.Close()
+}
+
+// Request is a request received by an HTTPCollector.
+type Request struct {
+	Method string
+	Path   string // Path and query
+	Header http.Header
+	Body   []byte // Decompressed if the request was gzip-encoded
+}
+
+// HTTPCollector is an HTTP intake that can reject or stall requests, for
+// sinks posting to a remote API.
+type HTTPCollector struct {
+	*httptest.Server
+	mutex      sync.Mutex
+	requests   []Request
+	failNext   int
+	failStatus int
+	delay      time.Duration
+	status     int
+	reply      string
+}
+
+// NewHTTPCollector starts a collector answering 200 with an empty JSON
+// object. It is closed when the test ends.
+func NewHTTPCollector(t testing.TB) *HTTPCollector {
+	c := &HTTPCollector{status: http.StatusOK, reply: "{}"}
+	c.Server = httptest.NewServer(http.HandlerFunc(c.handle))
+	t.Cleanup(c.Close)
+	return c
+}
+
+// handle records a request and answers it.
+func (c *HTTPCollector) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		if zr, err := gzip.NewReader(bytes.NewReader(body)); err == nil {
+			if decoded, err := io.ReadAll(zr); err == nil {
+				body = decoded
+			}
+		}
+	}
+
+	c.mutex.Lock()
+	delay := c.delay
+	status, reply := c.status, c.reply
+	failed := c.failNext > 0
+	if failed {
+		c.failNext--
+		status, reply = c.failStatus, `{"error":"injected failure"}`
+	} else {
+		c.requests = append(c.requests, Request{Method: r.Method, Path: r.URL.RequestURI(), Header: r.Header.Clone(), Body: body})
+	}
+	c.mutex.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, reply)
+}
+
+// FailNext makes the next n requests fail with status, without recording
+// them.
+func (c *HTTPCollector) FailNext(n, status int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.failNext, c.failStatus = n, status
+}
+
+// SetDelay makes the collector wait d before answering, to trip client
+// timeouts.
+func (c *HTTPCollector) SetDelay(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.delay = d
+}
+
+// SetReply sets the status and body of successful answers.
+func (c *HTTPCollector) SetReply(status int, body string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.status, c.reply = status, body
+}
+
+// Requests returns the requests accepted so far.
+func (c *HTTPCollector) Requests() []Request {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]Request(nil), c.requests...)
+}
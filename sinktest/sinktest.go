@@ -0,0 +1,234 @@
+// Package sinktest provides fake endpoints with fault injection and
+// assertion helpers for testing golog sinks, so sink implementations and
+// the wrappers around them (retries, spools, timeouts) can be validated
+// consistently:
+//
+//	server := sinktest.NewTCPServer(t)
+//	sink, _ := golog.NewSocketSink(golog.SocketSinkConfig{Network: "tcp", Address: server.Addr()})
+//	server.Stop() // the collector goes away
+//	...
+//	server.Start()
+//	sinktest.Eventually(t, 5*time.Second, func() bool { return len(server.Lines()) == 3 }, "lines after restart")
+package sinktest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samiullahsaleem/golog"
+)
+
+// ErrInjected is the error returned by injected failures.
+var ErrInjected = errors.New("sinktest: injected failure")
+
+// Recorder is a golog.Sink that records the entries written to it and can
+// fail on demand, standing in for the sink behind a wrapper under test.
+type Recorder struct {
+	mutex    sync.Mutex
+	entries  []*golog.Entry
+	attempts int
+	failNext int
+	down     bool
+	delay    time.Duration
+	closed   bool
+}
+
+// Write implements golog.Sink.
+func (r *Recorder) Write(entry *golog.Entry) error {
+	r.mutex.Lock()
+	r.attempts++
+	delay := r.delay
+	fail := r.down || r.failNext > 0
+	if r.failNext > 0 {
+		r.failNext--
+	}
+	r.mutex.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	if fail {
+		return ErrInjected
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+// Close implements golog.Sink.
+func (r *Recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.closed = true
+	return nil
+}
+
+// FailNext makes the next n writes fail.
+func (r *Recorder) FailNext(n int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.failNext = n
+}
+
+// SetDown makes every write fail until called with false.
+func (r *Recorder) SetDown(down bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.down = down
+}
+
+// SetDelay makes every write take at least d, like a slow remote.
+func (r *Recorder) SetDelay(d time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.delay = d
+}
+
+// Entries returns the entries written successfully, in order.
+func (r *Recorder) Entries() []*golog.Entry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]*golog.Entry(nil), r.entries...)
+}
+
+// Messages returns the messages of the entries written successfully.
+func (r *Recorder) Messages() []string {
+	return Messages(r.Entries())
+}
+
+// Attempts returns the number of writes, including failed ones.
+func (r *Recorder) Attempts() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.attempts
+}
+
+// Closed reports whether Close was called.
+func (r *Recorder) Closed() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.closed
+}
+
+// Messages returns the messages of entries.
+func Messages(entries []*golog.Entry) []string {
+	messages := make([]string, len(entries))
+	for i, entry := range entries {
+		messages[i] = entry.Message
+	}
+	return messages
+}
+
+// Eventually polls condition until it holds, failing the test with what
+// as the description if it does not within timeout.
+func Eventually(t testing.TB, timeout time.Duration, condition func() bool, what string) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out after %v waiting for %s", timeout, what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// AssertMessages fails the test unless got holds exactly want, in order.
+func AssertMessages(t testing.TB, got []string, want ...string) {
+	t.Helper()
+	equal := len(got) == len(want)
+	for i := 0; equal && i < len(got); i++ {
+		equal = got[i] == want[i]
+	}
+	if !equal {
+		t.Errorf("Unexpected messages:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+// AssertDelivered fails the test unless every message in want appears in
+// got exactly once, in any order, as expected of a sink that retries or
+// spools without duplicating or losing entries.
+func AssertDelivered(t testing.TB, got []string, want ...string) {
+	t.Helper()
+	counts := make(map[string]int, len(got))
+	for _, msg := range got {
+		counts[msg]++
+	}
+	for _, msg := range want {
+		switch counts[msg] {
+		case 0:
+			t.Errorf("Message %q was not delivered", msg)
+		case 1:
+		default:
+			t.Errorf("Message %q was delivered %d times", msg, counts[msg])
+		}
+		delete(counts, msg)
+	}
+	for msg := range counts {
+		t.Errorf("Unexpected message %q was delivered", msg)
+	}
+}
+
+// AssertDropped fails the test if any message in dropped appears in got,
+// as expected of a sink that sheds entries under failure.
+func AssertDropped(t testing.TB, got []string, dropped ...string) {
+	t.Helper()
+	for _, msg := range got {
+		for _, d := range dropped {
+			if msg == d {
+				t.Errorf("Message %q was delivered, expected it to be dropped", msg)
+			}
+		}
+	}
+}
+
+// Conformance checks the contract every golog.Sink must meet: concurrent
+// writes are all delivered once, Flush (for golog.Flusher sinks) and Close
+// succeed, and a second Close does not fail. open returns the sink under
+// test and received returns the messages its destination has seen so far.
+func Conformance(t *testing.T, open func(t *testing.T) golog.Sink, received func() []string) {
+	t.Helper()
+	sink := open(t)
+
+	const writers, perWriter = 4, 25
+	var want []string
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		for i := 0; i < perWriter; i++ {
+			want = append(want, fmt.Sprintf("entry %d-%d", w, i))
+		}
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				entry := &golog.Entry{
+					Time:    time.Now(),
+					Level:   golog.INFO,
+					Message: fmt.Sprintf("entry %d-%d", w, i),
+					Fields:  map[string]interface{}{"writer": w, "seq": i},
+				}
+				if err := sink.Write(entry); err != nil {
+					t.Errorf("Write failed: %v", err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if f, ok := sink.(golog.Flusher); ok {
+		if err := f.Flush(); err != nil {
+			t.Errorf("Flush failed: %v", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	Eventually(t, 5*time.Second, func() bool { return len(received()) >= len(want) }, fmt.Sprintf("%d entries", len(want)))
+	AssertDelivered(t, received(), want...)
+	if err := sink.Close(); err != nil {
+		t.Errorf("Second Close failed: %v", err)
+	}
+}
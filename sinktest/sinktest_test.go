@@ -0,0 +1,126 @@
+package sinktest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samiullahsaleem/golog"
+)
+
+func entry(msg string) *golog.Entry {
+	return &golog.Entry{Time: time.Now(), Level: golog.INFO, Message: msg}
+}
+
+func TestConformanceOfRetrySink(t *testing.T) {
+	rec := &Recorder{}
+	Conformance(t, func(t *testing.T) golog.Sink {
+		rec.FailNext(3)
+		return golog.NewRetrySink(rec, golog.RetryConfig{Attempts: 5, Backoff: time.Millisecond})
+	}, rec.Messages)
+	if rec.Attempts() != 103 || !rec.Closed() {
+		t.Errorf("Expected 103 attempts and a closed sink, got %d and %v", rec.Attempts(), rec.Closed())
+	}
+}
+
+func TestConformanceOfSocketSink(t *testing.T) {
+	server := NewTCPServer(t)
+	Conformance(t, func(t *testing.T) golog.Sink {
+		sink, _ := golog.NewSocketSink(golog.SocketSinkConfig{Network: "tcp", Address: server.Addr()})
+		return sink
+	}, func() []string {
+		var messages []string
+		for _, line := range server.Lines() {
+			var entry struct{ Message string }
+			json.Unmarshal([]byte(line), &entry)
+			messages = append(messages, entry.Message)
+		}
+		return messages
+	})
+}
+
+func TestTCPServerRestart(t *testing.T) {
+	server := NewTCPServer(t)
+	sink, err := golog.NewSocketSink(golog.SocketSinkConfig{Network: "tcp", Address: server.Addr(), ReconnectBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	defer sink.Close()
+
+	sink.Write(entry("before"))
+	Eventually(t, 5*time.Second, func() bool { return len(server.Lines()) == 1 }, "the first line")
+
+	// A write into a connection closed by the peer can succeed locally, so
+	// the failure may surface a write later.
+	server.Stop()
+	Eventually(t, 5*time.Second, func() bool { return sink.Write(entry("while down")) != nil }, "writes to fail")
+	server.Start()
+	server.DropNext(1)
+	Eventually(t, 5*time.Second, func() bool {
+		sink.Write(entry("after"))
+		lines := server.Lines()
+		return len(lines) > 1 && strings.Contains(lines[len(lines)-1], `"message":"after"`)
+	}, "a line after the restart")
+
+	var messages []string
+	for _, line := range server.Lines() {
+		var decoded map[string]interface{}
+		json.Unmarshal([]byte(line), &decoded)
+		messages = append(messages, decoded["message"].(string))
+	}
+	AssertDropped(t, messages, "while down")
+}
+
+func TestHTTPCollectorFailures(t *testing.T) {
+	collector := NewHTTPCollector(t)
+	sink, err := golog.NewDatadogSink(golog.DatadogConfig{APIKey: "key", URL: collector.URL, Compress: true})
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	defer sink.Close()
+
+	collector.FailNext(1, http.StatusServiceUnavailable)
+	sink.Write(entry("rejected"))
+	if err := sink.Flush(); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Expected the injected 503, got %v", err)
+	}
+	sink.Write(entry("accepted"))
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	requests := collector.Requests()
+	if len(requests) != 1 || !strings.Contains(string(requests[0].Body), `"message":"accepted"`) {
+		t.Errorf("Unexpected requests: %+v", requests)
+	}
+
+	collector.SetDelay(time.Second)
+	client := &http.Client{Timeout: 20 * time.Millisecond}
+	slow, _ := golog.NewDatadogSink(golog.DatadogConfig{APIKey: "key", URL: collector.URL, Client: client})
+	slow.Write(entry("stalled"))
+	if err := slow.Flush(); err == nil {
+		t.Error("Expected a stalled collector to time out")
+	}
+}
+
+func TestSlowWriterTripsTimeout(t *testing.T) {
+	w := &SlowWriter{}
+	fallback := &Recorder{}
+	sink := golog.NewTimeoutSink(golog.NewWriterSink(w, nil), 20*time.Millisecond, fallback)
+
+	w.Block()
+	if err := sink.Write(entry("stuck")); err != nil {
+		t.Fatalf("Expected the fallback to take the entry: %v", err)
+	}
+	w.Release()
+	Eventually(t, 5*time.Second, func() bool { return len(w.Lines()) == 1 }, "the abandoned write to finish")
+	if err := sink.Write(entry("fast")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	AssertMessages(t, fallback.Messages(), "stuck")
+	if lines := w.Lines(); len(lines) != 2 || !strings.Contains(lines[1], "fast") {
+		t.Errorf("Unexpected lines: %q", lines)
+	}
+	sink.Close()
+}
@@ -0,0 +1,65 @@
+package sinktest
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SlowWriter is an io.Writer that takes Delay per write and can be blocked
+// outright, like a congested pipe or a stalled disk, for testing timeouts
+// and asynchronous writers.
+type SlowWriter struct {
+	Delay time.Duration // Time each write takes
+
+	mutex   sync.Mutex
+	buf     bytes.Buffer
+	blocked chan struct{}
+}
+
+// Write implements io.Writer.
+func (w *SlowWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	blocked := w.blocked
+	w.mutex.Unlock()
+	if blocked != nil {
+		<-blocked
+	}
+	if w.Delay > 0 {
+		time.Sleep(w.Delay)
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.buf.Write(p)
+}
+
+// Block makes writes wait until Release.
+func (w *SlowWriter) Block() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.blocked == nil {
+		w.blocked = make(chan struct{})
+	}
+}
+
+// Release lets blocked and later writes through.
+func (w *SlowWriter) Release() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.blocked != nil {
+		close(w.blocked)
+		w.blocked = nil
+	}
+}
+
+// Lines returns the complete lines written so far, without newlines.
+func (w *SlowWriter) Lines() []string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	content := w.buf.String()
+	if i := strings.LastIndexByte(content, '\n'); i >= 0 {
+		return strings.Split(content[:i], "\n")
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package golog
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SinkFactory opens the sink described by a URL.
+type SinkFactory func(u *url.URL) (Sink, error)
+
+var (
+	sinkFactoriesMu sync.RWMutex
+	sinkFactories   = make(map[string]SinkFactory)
+)
+
+// The built-in factories are added in init because the file factory creates
+// a Logger, whose Config.Outputs refer back to the factories.
+func init() {
+	sinkFactories["file"] = newFileSinkFromURL
+	for _, network := range []string{"tcp", "udp", "unix", "unixgram"} {
+		sinkFactories[network] = newSocketSinkFromURL
+	}
+}
+
+// sinkScheme matches valid URL schemes.
+var sinkScheme = regexp.MustCompile(`^[a-z][a-z0-9+.-]*$`)
+
+// RegisterSink makes a sink available to OpenSink and Config.Outputs under
+// a URL scheme, so outputs such as "loki://loki:3100/?job=api" can be
+// declared in configuration. Schemes are case-insensitive and can be
+// registered once; file, tcp, udp, unix and unixgram are built in.
+func RegisterSink(scheme string, factory SinkFactory) error {
+	scheme = strings.ToLower(scheme)
+	if !sinkScheme.MatchString(scheme) {
+		return fmt.Errorf("invalid sink scheme %q", scheme)
+	}
+	if factory == nil {
+		return fmt.Errorf("sink factory for %q is nil", scheme)
+	}
+	sinkFactoriesMu.Lock()
+	defer sinkFactoriesMu.Unlock()
+	if _, ok := sinkFactories[scheme]; ok {
+		return fmt.Errorf("sink scheme %q is already registered", scheme)
+	}
+	sinkFactories[scheme] = factory
+	return nil
+}
+
+// OpenSink opens the sink for an output URL using the factory registered
+// for its scheme. A URL without a scheme is a file path, and "stdout" and
+// "stderr" write to the standard streams. Built-in outputs take these
+// query parameters:
+//
+//	file:///var/log/app.log?rotate=100MB&backups=5&compress=true&format=json
+//	tcp://collector:514?format=logfmt (also udp://, unix:///path and unixgram:///path)
+//
+// rotate accepts a size in KB, MB or GB, or a number of megabytes. format
+// is text, json or logfmt, defaulting to text for files and the standard
+// streams and to json for sockets.
+func OpenSink(output string) (Sink, error) {
+	switch output {
+	case "stdout":
+		return NewWriterSink(os.Stdout, nil), nil
+	case "stderr":
+		return NewWriterSink(os.Stderr, nil), nil
+	}
+	u, err := url.Parse(output)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output %q: %v", output, err)
+	}
+	if u.Scheme == "" {
+		u = &url.URL{Scheme: "file", Path: output}
+	}
+	sinkFactoriesMu.RLock()
+	factory, ok := sinkFactories[u.Scheme]
+	sinkFactoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no sink registered for scheme %q", u.Scheme)
+	}
+	sink, err := factory(u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output %q: %v", output, err)
+	}
+	return sink, nil
+}
+
+// sinkQuery returns the query parameters of u, failing on any not in
+// allowed so a misspelled option is not silently ignored.
+func sinkQuery(u *url.URL, allowed ...string) (url.Values, error) {
+	query := u.Query()
+	var unknown []string
+	for key := range query {
+		found := false
+		for _, name := range allowed {
+			found = found || key == name
+		}
+		if !found {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown option %q", unknown[0])
+	}
+	return query, nil
+}
+
+// queryFormatter returns the formatter named by the format parameter.
+func queryFormatter(query url.Values, fallback string) (Formatter, error) {
+	format := query.Get("format")
+	if format == "" {
+		format = fallback
+	}
+	switch format {
+	case "text", "json", "logfmt":
+		return newFormatter(format, nil), nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// fileSink writes entries through a logger with only a file output, reusing
+// its rotation.
+type fileSink struct {
+	logger *Logger
+}
+
+// Write implements Sink.
+func (s *fileSink) Write(entry *Entry) error {
+	s.logger.write(entry)
+	return nil
+}
+
+// Flush implements Flusher.
+func (s *fileSink) Flush() error {
+	return s.logger.Flush()
+}
+
+// Close implements Sink.
+func (s *fileSink) Close() error {
+	return s.logger.Close()
+}
+
+// newFileSinkFromURL opens a file:// output.
+func newFileSinkFromURL(u *url.URL) (Sink, error) {
+	path := u.Path
+	if u.Opaque != "" {
+		path = u.Opaque // file:app.log
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return nil, fmt.Errorf("file output must be local, got host %q", u.Host)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("file output requires a path")
+	}
+	query, err := sinkQuery(u, "rotate", "backups", "compress", "format", "lock")
+	if err != nil {
+		return nil, err
+	}
+	formatter, err := queryFormatter(query, "text")
+	if err != nil {
+		return nil, err
+	}
+	config := Config{FilePath: path, Formatter: formatter}
+	var maxSize int64
+	if value := query.Get("rotate"); value != "" {
+		if maxSize, err = parseByteSize(value); err != nil {
+			return nil, fmt.Errorf("invalid rotate size %q", value)
+		}
+	}
+	if value := query.Get("backups"); value != "" {
+		if config.MaxBackups, err = strconv.Atoi(value); err != nil || config.MaxBackups < 0 {
+			return nil, fmt.Errorf("invalid backups %q", value)
+		}
+	}
+	for name, option := range map[string]*bool{"compress": &config.Compress, "lock": &config.FileLock} {
+		if value := query.Get(name); value != "" {
+			if *option, err = strconv.ParseBool(value); err != nil {
+				return nil, fmt.Errorf("invalid %s %q", name, value)
+			}
+		}
+	}
+
+	logger, err := NewLogger(config)
+	if err != nil {
+		return nil, err
+	}
+	logger.rotator.maxSize = maxSize
+	return &fileSink{logger: logger}, nil
+}
+
+// parseByteSize parses a size such as "100MB"; a bare number is megabytes.
+func parseByteSize(value string) (int64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1024 * 1024)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"KB", 1024}, {"MB", 1024 * 1024}, {"GB", 1024 * 1024 * 1024}} {
+		if strings.HasSuffix(upper, unit.suffix) {
+			upper, multiplier = strings.TrimSuffix(upper, unit.suffix), unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(upper), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return n * multiplier, nil
+}
+
+// newSocketSinkFromURL opens a tcp://, udp://, unix:// or unixgram:// output.
+func newSocketSinkFromURL(u *url.URL) (Sink, error) {
+	query, err := sinkQuery(u, "format")
+	if err != nil {
+		return nil, err
+	}
+	formatter, err := queryFormatter(query, "json")
+	if err != nil {
+		return nil, err
+	}
+	address := u.Host
+	if u.Scheme == "unix" || u.Scheme == "unixgram" {
+		address = u.Path
+	}
+	return NewSocketSink(SocketSinkConfig{Network: u.Scheme, Address: address, Formatter: formatter})
+}
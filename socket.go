@@ -0,0 +1,129 @@
+package golog
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SocketSinkConfig holds socket sink options.
+type SocketSinkConfig struct {
+	Network          string        // "unix" or "tcp" for a stream socket, "unixgram" or "udp" for a datagram socket; defaults to "unix"
+	Address          string        // Socket path, or host:port for tcp and udp
+	Formatter        Formatter     // Entry format; defaults to JSONFormatter
+	DialTimeout      time.Duration // Connection timeout; defaults to 5s
+	WriteTimeout     time.Duration // Deadline for each write; defaults to 5s
+	ReconnectBackoff time.Duration // Wait after a failed connection attempt before the next; defaults to 1s
+}
+
+// SocketSink writes newline-delimited formatted entries to a unix domain or
+// network socket, such as the socket source of vector or fluent-bit. Each
+// entry is one line on a stream socket and one datagram on a datagram
+// socket. The sink connects on first write and reconnects after the
+// collector restarts; entries written while it is unreachable fail.
+type SocketSink struct {
+	mutex    sync.Mutex
+	config   SocketSinkConfig
+	conn     net.Conn
+	failedAt time.Time // last failed connection attempt
+}
+
+// NewSocketSink creates a sink writing to the socket at config.Address.
+func NewSocketSink(config SocketSinkConfig) (*SocketSink, error) {
+	switch config.Network {
+	case "":
+		config.Network = "unix"
+	case "unix", "unixgram", "tcp", "udp":
+	default:
+		return nil, fmt.Errorf("unsupported socket network %q", config.Network)
+	}
+	if config.Address == "" {
+		return nil, fmt.Errorf("socket sink requires an address")
+	}
+	if config.Formatter == nil {
+		config.Formatter = &JSONFormatter{}
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = 5 * time.Second
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = 5 * time.Second
+	}
+	if config.ReconnectBackoff <= 0 {
+		config.ReconnectBackoff = time.Second
+	}
+	return &SocketSink{config: config}, nil
+}
+
+// Write implements Sink. A write that fails on an established connection is
+// retried once on a new one.
+func (s *SocketSink) Write(entry *Entry) error {
+	line := formatEntry(s.config.Formatter, entry)
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	reused := s.conn != nil
+	err := s.send(line)
+	if err != nil && reused {
+		err = s.send(line)
+	}
+	return err
+}
+
+// send writes one line, connecting first if needed; s.mutex must be held.
+func (s *SocketSink) send(line string) error {
+	if s.conn == nil {
+		if since := time.Since(s.failedAt); since < s.config.ReconnectBackoff {
+			return fmt.Errorf("socket %s unavailable, retrying in %v", s.config.Address, (s.config.ReconnectBackoff - since).Round(time.Millisecond))
+		}
+		conn, err := net.DialTimeout(s.config.Network, s.config.Address, s.config.DialTimeout)
+		if err != nil {
+			s.failedAt = time.Now()
+			return fmt.Errorf("failed to connect to socket: %v", err)
+		}
+		s.conn = conn
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(s.config.WriteTimeout))
+	if _, err := s.conn.Write([]byte(line)); err != nil {
+		s.disconnect()
+		return fmt.Errorf("failed to write to socket: %v", err)
+	}
+	return nil
+}
+
+// disconnect drops the connection; s.mutex must be held.
+func (s *SocketSink) disconnect() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// Close implements Sink.
+func (s *SocketSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.disconnect()
+	return nil
+}
+
+// dialNetwork connects to address, over TLS if tlsConfig is set. The
+// server name defaults to address's host.
+func dialNetwork(network, address string, timeout time.Duration, tlsConfig *tls.Config) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	if tlsConfig == nil {
+		return dialer.Dial(network, address)
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ServerName, _, _ = net.SplitHostPort(address)
+	}
+	return tls.DialWithDialer(dialer, network, address, tlsConfig)
+}
@@ -0,0 +1,81 @@
+package golog
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// SplitMessage is the message of the records an oversized entry is split
+// into; see Config.MaxLineBytes. Each record carries the shared entry_id,
+// its part number, the number of parts and a data chunk. Concatenating the
+// chunks in part order yields the original line without its newline.
+const SplitMessage = "split entry"
+
+// minSplitChunk keeps splitting from degenerating when MaxLineBytes is too
+// small for the records' own overhead; such records exceed the limit.
+const minSplitChunk = 16
+
+// splitLine returns line unchanged if it fits in max bytes, and otherwise
+// split records formatted with f, one per line.
+func splitLine(f Formatter, entry *Entry, line string, max int) string {
+	if len(line) <= max {
+		return line
+	}
+	body := strings.TrimSuffix(line, "\n")
+	id := newScopeID()
+	record := func(part, parts int, data string) string {
+		return formatEntry(f, &Entry{
+			Time:    entry.Time,
+			Level:   entry.Level,
+			Message: SplitMessage,
+			Fields:  map[string]interface{}{"entry_id": id, "part": part, "parts": parts, "data": data},
+		})
+	}
+
+	// Size chunks against records numbered with the most digits possible,
+	// shrinking a chunk further when escaping makes its record too long.
+	placeholder := len(body)
+	step := max - len(record(placeholder, placeholder, ""))
+	if step < minSplitChunk {
+		step = minSplitChunk
+	}
+	var chunks []string
+	for rest := body; rest != ""; {
+		size := runeBoundary(rest, step)
+		for {
+			excess := len(record(placeholder, placeholder, rest[:size])) - max
+			if excess <= 0 || size <= minSplitChunk {
+				break
+			}
+			size = runeBoundary(rest, size-excess)
+		}
+		chunks = append(chunks, rest[:size])
+		rest = rest[size:]
+	}
+
+	var sb strings.Builder
+	for i, chunk := range chunks {
+		sb.WriteString(record(i+1, len(chunks), chunk))
+	}
+	return sb.String()
+}
+
+// runeBoundary returns the largest length of at most n bytes, and at least
+// one rune, that ends s's prefix on a rune boundary.
+func runeBoundary(s string, n int) int {
+	if n >= len(s) {
+		return len(s)
+	}
+	if n < 1 {
+		n = 1
+	}
+	end := n
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	if end == 0 {
+		_, size := utf8.DecodeRuneInString(s)
+		return size
+	}
+	return end
+}
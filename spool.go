@@ -0,0 +1,448 @@
+package golog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	spoolSuffix     = ".spool"
+	spoolCursorFile = "spool.cursor"
+)
+
+// SpoolConfig holds write-ahead spool configuration options.
+type SpoolConfig struct {
+	Dir           string        // Directory holding the spool segments
+	SegmentSizeMB int           // Size at which a new segment is started; defaults to 8
+	MaxSizeMB     int           // Max total spool size; the oldest segments are dropped beyond it. 0 means unlimited
+	RetryInterval time.Duration // Wait after a failed delivery; defaults to 5s
+}
+
+// SpoolSink is a disk-backed write-ahead spool placed in front of an
+// unreliable sink, typically a network one. Entries are appended to
+// checksummed segment files and delivered in order by a background
+// goroutine, which retries until the sink accepts them. Undelivered entries
+// survive process restarts and are delivered by the next SpoolSink opened
+// on the same directory. Delivery is at-least-once: entries delivered just
+// before a crash may be delivered again.
+type SpoolSink struct {
+	mutex       sync.Mutex
+	config      SpoolConfig
+	sink        Sink
+	file        *os.File // active segment
+	seq         uint64   // active segment number
+	size        int64    // active segment size
+	segmentSize int64
+
+	cursorSeq uint64 // next record to deliver; owned by the delivery goroutine
+	cursorOff int64
+	saved     string
+
+	notify chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// spoolRecord is the on-disk form of an entry.
+type spoolRecord struct {
+	Time    time.Time              `json:"time"`
+	Level   LogLevel               `json:"level"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// NewSpoolSink opens the spool in config.Dir and starts delivering its
+// entries to sink. The spool takes ownership of sink and closes it on Close.
+func NewSpoolSink(sink Sink, config SpoolConfig) (*SpoolSink, error) {
+	if config.Dir == "" {
+		return nil, fmt.Errorf("spool requires a directory")
+	}
+	if config.SegmentSizeMB <= 0 {
+		config.SegmentSizeMB = 8
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = 5 * time.Second
+	}
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %v", err)
+	}
+
+	s := &SpoolSink{
+		config:      config,
+		sink:        sink,
+		segmentSize: int64(config.SegmentSizeMB) * 1024 * 1024,
+		notify:      make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	s.loadCursor()
+
+	segments, err := s.segments()
+	if err != nil {
+		return nil, err
+	}
+	// Always start a fresh segment so new records never follow a torn one.
+	next := uint64(1)
+	if len(segments) > 0 {
+		next = segments[len(segments)-1] + 1
+	}
+	if err := s.openSegment(next); err != nil {
+		return nil, err
+	}
+
+	go s.run()
+	return s, nil
+}
+
+// Write implements Sink by appending the entry to the spool.
+func (s *SpoolSink) Write(entry *Entry) error {
+	payload, err := json.Marshal(spoolRecord{
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Message: entry.Message,
+		Fields:  normalizeFields(entry.Fields),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode spool record: %v", err)
+	}
+	record := fmt.Sprintf("%08x %s\n", crc32.ChecksumIEEE(payload), payload)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file == nil {
+		return fmt.Errorf("spool is closed")
+	}
+	if s.size > 0 && s.size+int64(len(record)) > s.segmentSize {
+		s.file.Close()
+		if err := s.openSegment(s.seq + 1); err != nil {
+			return err
+		}
+		s.enforceLimit()
+	}
+	n, err := s.file.WriteString(record)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write spool record: %v", err)
+	}
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Flush implements Flusher by syncing the active segment to disk.
+func (s *SpoolSink) Flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spool: %v", err)
+	}
+	return nil
+}
+
+// Close stops delivery after a final attempt, closes the spool and closes
+// the wrapped sink. Entries that could not be delivered stay on disk.
+func (s *SpoolSink) Close() error {
+	s.mutex.Lock()
+	if s.file == nil {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.mutex.Unlock()
+
+	close(s.stop)
+	<-s.done
+	s.deliver()
+
+	s.mutex.Lock()
+	var firstErr error
+	if err := s.file.Close(); err != nil {
+		firstErr = fmt.Errorf("failed to close spool: %v", err)
+	}
+	s.file = nil
+	s.mutex.Unlock()
+
+	if err := s.sink.Close(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// Pending returns the number of bytes spooled but not yet delivered.
+func (s *SpoolSink) Pending() int64 {
+	segments, err := s.segments()
+	if err != nil {
+		return 0
+	}
+	s.mutex.Lock()
+	cursorSeq, cursorOff := s.cursorSeq, s.cursorOff
+	s.mutex.Unlock()
+
+	var pending int64
+	for _, seq := range segments {
+		if seq < cursorSeq {
+			continue
+		}
+		if info, err := os.Stat(s.segmentPath(seq)); err == nil {
+			pending += info.Size()
+			if seq == cursorSeq {
+				pending -= cursorOff
+			}
+		}
+	}
+	return pending
+}
+
+// run delivers spooled entries until Close is called.
+func (s *SpoolSink) run() {
+	defer close(s.done)
+	for {
+		err := s.deliver()
+
+		if err == nil {
+			select {
+			case <-s.stop:
+				return
+			case <-s.notify:
+			}
+			continue
+		}
+
+		timer := time.NewTimer(s.config.RetryInterval)
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// deliver sends every complete spooled record to the sink, in order,
+// stopping at the first failure.
+func (s *SpoolSink) deliver() error {
+	segments, err := s.segments()
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	activeSeq, activeSize := s.seq, s.size
+	s.mutex.Unlock()
+	defer s.saveCursor()
+
+	for _, seq := range segments {
+		if seq < s.cursorSeq {
+			os.Remove(s.segmentPath(seq))
+			continue
+		}
+		if seq > s.cursorSeq {
+			s.setCursor(seq, 0)
+		}
+		limit := int64(-1)
+		if seq == activeSeq {
+			limit = activeSize
+		}
+		if err := s.deliverSegment(seq, limit); err != nil {
+			return err
+		}
+		if seq != activeSeq {
+			os.Remove(s.segmentPath(seq))
+			s.setCursor(seq+1, 0)
+		}
+	}
+	return nil
+}
+
+// deliverSegment sends the records of one segment from the cursor up to
+// limit bytes, or to the end when limit is negative. Records with a bad
+// checksum or a torn tail are skipped.
+func (s *SpoolSink) deliverSegment(seq uint64, limit int64) error {
+	file, err := os.Open(s.segmentPath(seq))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // dropped by the size limit
+		}
+		return fmt.Errorf("failed to open spool segment: %v", err)
+	}
+	defer file.Close()
+
+	offset := s.cursorOff
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek spool segment: %v", err)
+	}
+	var r io.Reader = file
+	if limit >= 0 {
+		r = io.LimitReader(file, limit-offset)
+	}
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil {
+			if len(line) > 0 && limit < 0 {
+				s.setCursor(seq, offset+int64(len(line)))
+			}
+			return nil
+		}
+		if entry, ok := decodeSpoolRecord(line); ok {
+			if err := s.sink.Write(entry); err != nil {
+				return err
+			}
+		}
+		offset += int64(len(line))
+		s.setCursor(seq, offset)
+	}
+}
+
+// decodeSpoolRecord verifies and decodes a "<crc32> <json>\n" record.
+func decodeSpoolRecord(line []byte) (*Entry, bool) {
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	sum, payload, ok := bytes.Cut(line, []byte(" "))
+	if !ok {
+		return nil, false
+	}
+	expected, err := strconv.ParseUint(string(sum), 16, 32)
+	if err != nil || uint32(expected) != crc32.ChecksumIEEE(payload) {
+		return nil, false
+	}
+
+	var record spoolRecord
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&record); err != nil {
+		return nil, false
+	}
+	if record.Fields == nil {
+		record.Fields = make(map[string]interface{})
+	}
+	return &Entry{Time: record.Time, Level: record.Level, Message: record.Message, Fields: record.Fields}, true
+}
+
+// openSegment makes seq the active segment; s.mutex must be held or the
+// spool not yet started.
+func (s *SpoolSink) openSegment(seq uint64) error {
+	file, err := os.OpenFile(s.segmentPath(seq), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open spool segment: %v", err)
+	}
+	s.file = file
+	s.seq = seq
+	s.size = 0
+	return nil
+}
+
+// enforceLimit removes the oldest segments while the spool exceeds
+// MaxSizeMB, never removing the active one; s.mutex must be held.
+func (s *SpoolSink) enforceLimit() {
+	if s.config.MaxSizeMB <= 0 {
+		return
+	}
+	segments, err := s.segments()
+	if err != nil {
+		return
+	}
+	sizes := make([]int64, len(segments))
+	var total int64
+	for i, seq := range segments {
+		if info, err := os.Stat(s.segmentPath(seq)); err == nil {
+			sizes[i] = info.Size()
+			total += sizes[i]
+		}
+	}
+	limit := int64(s.config.MaxSizeMB) * 1024 * 1024
+	for i, seq := range segments {
+		if total <= limit || seq == s.seq {
+			break
+		}
+		if err := os.Remove(s.segmentPath(seq)); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to remove spool segment: %v\n", err)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Spool size limit exceeded; dropped segment %s\n", s.segmentPath(seq))
+		total -= sizes[i]
+	}
+}
+
+// segments returns the sequence numbers of the segment files, sorted.
+func (s *SpoolSink) segments() ([]uint64, error) {
+	dirents, err := os.ReadDir(s.config.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spool segments: %v", err)
+	}
+	var segments []uint64
+	for _, d := range dirents {
+		name := d.Name()
+		if d.IsDir() || !strings.HasSuffix(name, spoolSuffix) {
+			continue
+		}
+		if seq, err := strconv.ParseUint(strings.TrimSuffix(name, spoolSuffix), 10, 64); err == nil {
+			segments = append(segments, seq)
+		}
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i] < segments[j] })
+	return segments, nil
+}
+
+// segmentPath returns the file name of segment seq.
+func (s *SpoolSink) segmentPath(seq uint64) string {
+	return filepath.Join(s.config.Dir, fmt.Sprintf("%020d%s", seq, spoolSuffix))
+}
+
+// setCursor records the delivery position.
+func (s *SpoolSink) setCursor(seq uint64, offset int64) {
+	s.mutex.Lock()
+	s.cursorSeq, s.cursorOff = seq, offset
+	s.mutex.Unlock()
+}
+
+// loadCursor reads the delivery position saved by a previous spool.
+func (s *SpoolSink) loadCursor() {
+	data, err := os.ReadFile(filepath.Join(s.config.Dir, spoolCursorFile))
+	if err != nil {
+		return
+	}
+	var seq uint64
+	var offset int64
+	if _, err := fmt.Sscanf(string(data), "%d %d", &seq, &offset); err == nil {
+		s.cursorSeq, s.cursorOff = seq, offset
+		s.saved = string(data)
+	}
+}
+
+// saveCursor persists the delivery position if it changed.
+func (s *SpoolSink) saveCursor() {
+	s.mutex.Lock()
+	data := fmt.Sprintf("%d %d\n", s.cursorSeq, s.cursorOff)
+	s.mutex.Unlock()
+	if data == s.saved {
+		return
+	}
+
+	path := filepath.Join(s.config.Dir, spoolCursorFile)
+	if err := os.WriteFile(path+".tmp", []byte(data), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save spool cursor: %v\n", err)
+		return
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save spool cursor: %v\n", err)
+		return
+	}
+	s.saved = data
+}
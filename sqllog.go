@@ -0,0 +1,336 @@
+package golog
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+// SQLConfig holds database/sql logging options.
+type SQLConfig struct {
+	SlowThreshold time.Duration                           // Statements at least this slow are logged at WARN instead of DEBUG; 0 disables
+	LogArgs       bool                                    // Add an args field with the statement arguments
+	RedactArg     func(arg driver.NamedValue) interface{} // Rewrites each logged argument, e.g. returning Redacted for secrets
+}
+
+// WrapSQLDriver returns a driver that logs every statement run through d,
+// for registering with sql.Register:
+//
+//	sql.Register("postgres-logged", golog.WrapSQLDriver(logger, &pq.Driver{}, golog.SQLConfig{}))
+//
+// Each exec, query, begin, commit and rollback, and each failed prepare, is
+// logged as "sql <op>" with query, elapsed and, on failure, error fields.
+// Statements are logged at DEBUG, slow ones at WARN and failed ones at ERROR.
+func WrapSQLDriver(l *Logger, d driver.Driver, config SQLConfig) driver.Driver {
+	return &sqlDriver{driver: d, log: &sqlLogger{logger: l, config: config}}
+}
+
+// NewSQLConnector returns a logging connector for dsn, for use with
+// sql.OpenDB; see WrapSQLDriver.
+func NewSQLConnector(l *Logger, d driver.Driver, dsn string, config SQLConfig) (driver.Connector, error) {
+	wrapped := &sqlDriver{driver: d, log: &sqlLogger{logger: l, config: config}}
+	if dc, ok := d.(driver.DriverContext); ok {
+		connector, err := dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return &sqlConnector{connector: connector, driver: wrapped}, nil
+	}
+	return &sqlConnector{dsn: dsn, driver: wrapped}, nil
+}
+
+// sqlLogger writes statement entries.
+type sqlLogger struct {
+	logger *Logger
+	config SQLConfig
+}
+
+// log writes the entry for one operation that started at start.
+func (s *sqlLogger) log(op, query string, args []driver.NamedValue, start time.Time, err error) {
+	elapsed := time.Since(start)
+	fields := map[string]interface{}{"elapsed": Duration(elapsed)}
+	if query != "" {
+		fields["query"] = query
+	}
+	if s.config.LogArgs && len(args) > 0 {
+		values := make([]interface{}, len(args))
+		for i, arg := range args {
+			values[i] = arg.Value
+			if s.config.RedactArg != nil {
+				values[i] = s.config.RedactArg(arg)
+			}
+		}
+		fields["args"] = values
+	}
+
+	level := DEBUG
+	switch {
+	case err != nil:
+		fields["error"] = err.Error()
+		level = ERROR
+	case s.config.SlowThreshold > 0 && elapsed >= s.config.SlowThreshold:
+		level = WARN
+	}
+	s.logger.log(level, "sql "+op, fields)
+}
+
+// sqlDriver wraps a driver so its connections log.
+type sqlDriver struct {
+	driver driver.Driver
+	log    *sqlLogger
+}
+
+// Open implements driver.Driver.
+func (d *sqlDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.driver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{conn: conn, log: d.log}, nil
+}
+
+// sqlConnector opens logging connections.
+type sqlConnector struct {
+	connector driver.Connector // nil for drivers without DriverContext
+	dsn       string
+	driver    *sqlDriver
+}
+
+// Connect implements driver.Connector.
+func (c *sqlConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if c.connector == nil {
+		return c.driver.Open(c.dsn)
+	}
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{conn: conn, log: c.driver.log}, nil
+}
+
+// Driver implements driver.Connector.
+func (c *sqlConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// sqlConn logs the statements run on a connection.
+type sqlConn struct {
+	conn driver.Conn
+	log  *sqlLogger
+}
+
+// Prepare implements driver.Conn.
+func (c *sqlConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext implements driver.ConnPrepareContext.
+func (c *sqlConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	start := time.Now()
+	var stmt driver.Stmt
+	var err error
+	if pc, ok := c.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = pc.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.conn.Prepare(query)
+	}
+	if err != nil {
+		c.log.log("prepare", query, nil, start, err)
+		return nil, err
+	}
+	return &sqlStmt{stmt: stmt, query: query, log: c.log}, nil
+}
+
+// Close implements driver.Conn.
+func (c *sqlConn) Close() error {
+	return c.conn.Close()
+}
+
+// Begin implements driver.Conn.
+func (c *sqlConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx implements driver.ConnBeginTx.
+func (c *sqlConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	start := time.Now()
+	var tx driver.Tx
+	var err error
+	if bc, ok := c.conn.(driver.ConnBeginTx); ok {
+		tx, err = bc.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.conn.Begin()
+	}
+	c.log.log("begin", "", nil, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlTx{tx: tx, log: c.log}, nil
+}
+
+// ExecContext implements driver.ExecerContext. It returns driver.ErrSkip
+// when the wrapped connection cannot execute directly, so database/sql
+// falls back to a prepared statement.
+func (c *sqlConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := ec.ExecContext(ctx, query, args)
+	if !errors.Is(err, driver.ErrSkip) {
+		c.log.log("exec", query, args, start, err)
+	}
+	return result, err
+}
+
+// QueryContext implements driver.QueryerContext; see ExecContext.
+func (c *sqlConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := qc.QueryContext(ctx, query, args)
+	if !errors.Is(err, driver.ErrSkip) {
+		c.log.log("query", query, args, start, err)
+	}
+	return rows, err
+}
+
+// Ping implements driver.Pinger.
+func (c *sqlConn) Ping(ctx context.Context) error {
+	if p, ok := c.conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession implements driver.SessionResetter.
+func (c *sqlConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid implements driver.Validator.
+func (c *sqlConn) IsValid() bool {
+	if v, ok := c.conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue implements driver.NamedValueChecker.
+func (c *sqlConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// sqlStmt logs the executions of a prepared statement.
+type sqlStmt struct {
+	stmt  driver.Stmt
+	query string
+	log   *sqlLogger
+}
+
+// Close implements driver.Stmt.
+func (s *sqlStmt) Close() error {
+	return s.stmt.Close()
+}
+
+// NumInput implements driver.Stmt.
+func (s *sqlStmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+// Exec implements driver.Stmt.
+func (s *sqlStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+// Query implements driver.Stmt.
+func (s *sqlStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+// ExecContext implements driver.StmtExecContext.
+func (s *sqlStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	var err error
+	if ec, ok := s.stmt.(driver.StmtExecContext); ok {
+		result, err = ec.ExecContext(ctx, args)
+	} else {
+		result, err = s.stmt.Exec(plainValues(args))
+	}
+	s.log.log("exec", s.query, args, start, err)
+	return result, err
+}
+
+// QueryContext implements driver.StmtQueryContext.
+func (s *sqlStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if qc, ok := s.stmt.(driver.StmtQueryContext); ok {
+		rows, err = qc.QueryContext(ctx, args)
+	} else {
+		rows, err = s.stmt.Query(plainValues(args))
+	}
+	s.log.log("query", s.query, args, start, err)
+	return rows, err
+}
+
+// CheckNamedValue implements driver.NamedValueChecker.
+func (s *sqlStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// sqlTx logs commits and rollbacks.
+type sqlTx struct {
+	tx  driver.Tx
+	log *sqlLogger
+}
+
+// Commit implements driver.Tx.
+func (t *sqlTx) Commit() error {
+	start := time.Now()
+	err := t.tx.Commit()
+	t.log.log("commit", "", nil, start, err)
+	return err
+}
+
+// Rollback implements driver.Tx.
+func (t *sqlTx) Rollback() error {
+	start := time.Now()
+	err := t.tx.Rollback()
+	t.log.log("rollback", "", nil, start, err)
+	return err
+}
+
+// namedValues converts positional driver arguments.
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+// plainValues converts named driver arguments for drivers that only take
+// positional ones.
+func plainValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
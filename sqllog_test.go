@@ -0,0 +1,242 @@
+package golog
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDriver is a minimal driver whose statements fail on "BAD" queries.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query: query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeStmt struct{ query string }
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	if strings.Contains(s.query, "BAD") {
+		return nil, errors.New("syntax error")
+	}
+	return driver.RowsAffected(1), nil
+}
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) { return fakeRows{}, nil }
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string         { return []string{"n"} }
+func (fakeRows) Close() error              { return nil }
+func (fakeRows) Next([]driver.Value) error { return io.EOF }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func TestSQLLogging(t *testing.T) {
+	logger, buf := newBufferLogger(t, DEBUG)
+	connector, err := NewSQLConnector(logger, fakeDriver{}, "", SQLConfig{
+		LogArgs: true,
+		RedactArg: func(arg driver.NamedValue) interface{} {
+			if arg.Ordinal == 2 {
+				return Redacted
+			}
+			return arg.Value
+		},
+		SlowThreshold: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create connector: %v", err)
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO users VALUES (?, ?)", "alice", "hunter2"); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if _, err := db.Exec("BAD SQL"); err == nil {
+		t.Fatal("Expected failing statement to return an error")
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	rows, err := tx.Query("SELECT n FROM t")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	rows.Close()
+	tx.Commit()
+
+	output := buf.String()
+	for _, expected := range []string{
+		`"args":["alice","[REDACTED]"],"elapsed":`,
+		`"level":"DEBUG","message":"sql exec","query":"INSERT INTO users VALUES (?, ?)"`,
+		`"error":"syntax error","level":"ERROR","message":"sql exec","query":"BAD SQL"`,
+		`"message":"sql begin"`,
+		`"message":"sql query","query":"SELECT n FROM t"`,
+		`"message":"sql commit"`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("SQL log does not contain %s:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "hunter2") || strings.Contains(output, "sql prepare") {
+		t.Errorf("Unexpected SQL log content:\n%s", output)
+	}
+}
+
+// registerLogged registers fakeDriver wrapped with WrapSQLDriver once per
+// test binary, as sql.Register panics on duplicate names; loggedBuf holds
+// what it logs.
+var (
+	registerLogged sync.Once
+	loggedBuf      *bytes.Buffer
+)
+
+func TestWrapSQLDriver(t *testing.T) {
+	registerLogged.Do(func() {
+		var logger *Logger
+		logger, loggedBuf = newBufferLogger(t, DEBUG)
+		sql.Register("golog-fake", WrapSQLDriver(logger, fakeDriver{}, SQLConfig{SlowThreshold: time.Nanosecond}))
+	})
+	buf := loggedBuf
+	buf.Reset()
+	db, err := sql.Open("golog-fake", "")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT n FROM t WHERE id = ?", 7)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	rows.Close()
+
+	var entry map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Failed to decode entry: %v", err)
+		}
+		if entry["message"] == "sql query" {
+			break
+		}
+	}
+	if entry["message"] != "sql query" || entry["query"] != "SELECT n FROM t WHERE id = ?" || entry["level"] != "WARN" {
+		t.Fatalf("Expected a slow query entry, got: %s", buf.String())
+	}
+	if elapsed, _ := entry["elapsed"].(string); elapsed == "" {
+		t.Errorf("Expected an elapsed duration, got %v", entry["elapsed"])
+	}
+	if _, ok := entry["args"]; ok {
+		t.Errorf("Expected no args without LogArgs: %v", entry)
+	}
+}
+
+// recordingDriver records the statements executed through it.
+type recordingDriver struct {
+	mutex sync.Mutex
+	log   []string
+}
+
+func (d *recordingDriver) record(s string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.log = append(d.log, s)
+}
+
+func (d *recordingDriver) statements() []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]string(nil), d.log...)
+}
+
+func (d *recordingDriver) Connect(context.Context) (driver.Conn, error) { return recordingConn{d}, nil }
+func (d *recordingDriver) Driver() driver.Driver                        { return fakeDriver{} }
+
+type recordingConn struct{ d *recordingDriver }
+
+func (c recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return recordingStmt{d: c.d, query: query}, nil
+}
+func (c recordingConn) Close() error { return nil }
+func (c recordingConn) Begin() (driver.Tx, error) {
+	c.d.record("BEGIN")
+	return recordingTx{c.d}, nil
+}
+
+type recordingStmt struct {
+	d     *recordingDriver
+	query string
+}
+
+func (s recordingStmt) Close() error  { return nil }
+func (s recordingStmt) NumInput() int { return -1 }
+func (s recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.record(fmt.Sprint(s.query, " ", args))
+	return driver.RowsAffected(1), nil
+}
+func (s recordingStmt) Query([]driver.Value) (driver.Rows, error) { return fakeRows{}, nil }
+
+type recordingTx struct{ d *recordingDriver }
+
+func (t recordingTx) Commit() error   { t.d.record("COMMIT"); return nil }
+func (t recordingTx) Rollback() error { t.d.record("ROLLBACK"); return nil }
+
+func TestSQLSink(t *testing.T) {
+	rec := &recordingDriver{}
+	db := sql.OpenDB(rec)
+	defer db.Close()
+
+	if _, err := NewSQLSink(db, SQLSinkConfig{Table: "logs; DROP TABLE users"}); err == nil {
+		t.Error("Expected an invalid table name to be rejected")
+	}
+	sink, err := NewSQLSink(db, SQLSinkConfig{CreateTable: true, IndexFields: []string{"user_id"}, Retention: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 60, time.FixedZone("CET", 3600))
+	sink.Write(&Entry{Time: ts, Level: ERROR, Message: "payment failed", Fields: map[string]interface{}{"user_id": 42}})
+	sink.Write(&Entry{Time: ts, Level: INFO, Message: "no fields"})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Failed to write entries: %v", err)
+	}
+
+	got := rec.statements()
+	want := []string{
+		"CREATE TABLE IF NOT EXISTS logs (id INTEGER PRIMARY KEY AUTOINCREMENT, time TEXT NOT NULL, level TEXT NOT NULL, severity INTEGER NOT NULL, message TEXT NOT NULL, fields TEXT) []",
+		"CREATE INDEX IF NOT EXISTS logs_time ON logs (time) []",
+		"CREATE INDEX IF NOT EXISTS logs_severity ON logs (severity, time) []",
+		"CREATE INDEX IF NOT EXISTS logs_f_user_id ON logs (json_extract(fields, '$.user_id')) []",
+		"DELETE FROM logs WHERE time < ?",
+		"BEGIN",
+		`INSERT INTO logs (time, level, severity, message, fields) VALUES (?, ?, ?, ?, ?) [2024-01-02T02:04:05.000000060Z ERROR 4 payment failed {"user_id":42}]`,
+		"INSERT INTO logs (time, level, severity, message, fields) VALUES (?, ?, ?, ?, ?) [2024-01-02T02:04:05.000000060Z INFO 2 no fields <nil>]",
+		"COMMIT",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Unexpected statements:\n%s", strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("Statement %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package golog
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// SQLDialect selects the SQL a SQLSink generates.
+type SQLDialect int
+
+const (
+	// DialectSQLite uses "?" placeholders and stores times as fixed-width
+	// UTC text, so they sort and compare correctly.
+	DialectSQLite SQLDialect = iota
+	// DialectPostgres uses "$n" placeholders, TIMESTAMPTZ times and JSONB
+	// fields.
+	DialectPostgres
+)
+
+// sqlTimeFormat keeps SQLite times the same width so they sort as text.
+const sqlTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// sqlIdentifier matches the table and field names a SQLSink interpolates.
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLSinkConfig holds database sink options.
+type SQLSinkConfig struct {
+	Dialect       SQLDialect    // SQL dialect; defaults to DialectSQLite
+	Table         string        // Table name; defaults to "logs"
+	CreateTable   bool          // Create the table and its indexes if they do not exist
+	IndexFields   []string      // Fields to index inside the fields JSON column, when creating the table
+	Retention     time.Duration // Delete entries older than this; 0 keeps everything
+	PruneInterval time.Duration // Time between retention prunes; defaults to 1h
+	Batch         BatchConfig   // Batching options; each batch is inserted in one transaction
+}
+
+// SQLSink writes entries into a database table, giving small tools a
+// queryable history without an external log stack:
+//
+//	SELECT time, message FROM logs
+//	WHERE level = 'ERROR' AND json_extract(fields, '$.user_id') = 42
+//
+// The table has id, time, level (the level name), severity (the numeric
+// LogLevel, for range queries), message and fields (the fields as JSON)
+// columns, with indexes on time and on severity and time. The database is
+// owned by the caller and is left open on Close.
+type SQLSink struct {
+	db     *sql.DB
+	config SQLSinkConfig
+	insert string
+	prune  string
+	batch  *batcher
+
+	mutex     sync.Mutex
+	lastPrune time.Time
+}
+
+// NewSQLSink creates a sink writing to config.Table in db, creating the
+// table first if config.CreateTable is set and pruning expired entries.
+func NewSQLSink(db *sql.DB, config SQLSinkConfig) (*SQLSink, error) {
+	if config.Table == "" {
+		config.Table = "logs"
+	}
+	if !sqlIdentifier.MatchString(config.Table) {
+		return nil, fmt.Errorf("invalid table name %q", config.Table)
+	}
+	for _, field := range config.IndexFields {
+		if !sqlIdentifier.MatchString(field) {
+			return nil, fmt.Errorf("invalid index field %q", field)
+		}
+	}
+	if config.PruneInterval <= 0 {
+		config.PruneInterval = time.Hour
+	}
+
+	s := &SQLSink{db: db, config: config}
+	table := config.Table
+	if config.Dialect == DialectPostgres {
+		s.insert = "INSERT INTO " + table + " (time, level, severity, message, fields) VALUES ($1, $2, $3, $4, $5)"
+		s.prune = "DELETE FROM " + table + " WHERE time < $1"
+	} else {
+		s.insert = "INSERT INTO " + table + " (time, level, severity, message, fields) VALUES (?, ?, ?, ?, ?)"
+		s.prune = "DELETE FROM " + table + " WHERE time < ?"
+	}
+	if config.CreateTable {
+		for _, stmt := range s.schema() {
+			if _, err := db.Exec(stmt); err != nil {
+				return nil, fmt.Errorf("failed to create log table: %v", err)
+			}
+		}
+	}
+	if err := s.pruneExpired(time.Now()); err != nil {
+		return nil, err
+	}
+	s.batch = newBatcher(config.Batch, s.send)
+	return s, nil
+}
+
+// schema returns the statements creating the table and its indexes.
+func (s *SQLSink) schema() []string {
+	table := s.config.Table
+	var stmts []string
+	if s.config.Dialect == DialectPostgres {
+		stmts = append(stmts,
+			"CREATE TABLE IF NOT EXISTS "+table+" (id BIGSERIAL PRIMARY KEY, time TIMESTAMPTZ NOT NULL, level TEXT NOT NULL, severity SMALLINT NOT NULL, message TEXT NOT NULL, fields JSONB)",
+			"CREATE INDEX IF NOT EXISTS "+table+"_fields ON "+table+" USING GIN (fields)")
+	} else {
+		stmts = append(stmts,
+			"CREATE TABLE IF NOT EXISTS "+table+" (id INTEGER PRIMARY KEY AUTOINCREMENT, time TEXT NOT NULL, level TEXT NOT NULL, severity INTEGER NOT NULL, message TEXT NOT NULL, fields TEXT)")
+	}
+	stmts = append(stmts,
+		"CREATE INDEX IF NOT EXISTS "+table+"_time ON "+table+" (time)",
+		"CREATE INDEX IF NOT EXISTS "+table+"_severity ON "+table+" (severity, time)")
+	for _, field := range s.config.IndexFields {
+		expr := "json_extract(fields, '$." + field + "')"
+		if s.config.Dialect == DialectPostgres {
+			expr = "(fields->>'" + field + "')"
+		}
+		stmts = append(stmts, "CREATE INDEX IF NOT EXISTS "+table+"_f_"+field+" ON "+table+" ("+expr+")")
+	}
+	return stmts
+}
+
+// Write implements Sink.
+func (s *SQLSink) Write(entry *Entry) error {
+	return s.batch.add(entry)
+}
+
+// Flush implements Flusher.
+func (s *SQLSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close implements Sink, writing any queued entries.
+func (s *SQLSink) Close() error {
+	return s.batch.flush()
+}
+
+// send inserts a batch in one transaction, then prunes if a prune is due.
+func (s *SQLSink) send(entries []*Entry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin log insert: %v", err)
+	}
+	stmt, err := tx.Prepare(s.insert)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to prepare log insert: %v", err)
+	}
+	defer stmt.Close()
+	for _, entry := range entries {
+		var fields interface{}
+		if len(entry.Fields) > 0 {
+			data, err := json.Marshal(normalizeFields(entry.Fields))
+			if err != nil {
+				data, _ = json.Marshal(map[string]string{"!ERROR": err.Error()})
+			}
+			fields = string(data)
+		}
+		if _, err := stmt.Exec(s.timeValue(entry.Time), entry.Level.String(), int(entry.Level), entry.Message, fields); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to insert log entry: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit log entries: %v", err)
+	}
+	return s.pruneExpired(time.Now())
+}
+
+// timeValue converts an entry time for the dialect.
+func (s *SQLSink) timeValue(t time.Time) interface{} {
+	if s.config.Dialect == DialectPostgres {
+		return t
+	}
+	return t.UTC().Format(sqlTimeFormat)
+}
+
+// pruneExpired deletes entries older than the retention period, at most
+// once per PruneInterval.
+func (s *SQLSink) pruneExpired(now time.Time) error {
+	if s.config.Retention <= 0 {
+		return nil
+	}
+	s.mutex.Lock()
+	if now.Sub(s.lastPrune) < s.config.PruneInterval {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.lastPrune = now
+	s.mutex.Unlock()
+
+	if _, err := s.db.Exec(s.prune, s.timeValue(now.Add(-s.config.Retention))); err != nil {
+		return fmt.Errorf("failed to prune log entries: %v", err)
+	}
+	return nil
+}
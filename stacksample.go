@@ -0,0 +1,86 @@
+package golog
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxStackSignatures bounds the number of signatures remembered. When it is
+// reached, expired signatures are pruned, and the oldest one is evicted if
+// none has expired.
+const maxStackSignatures = 1024
+
+// stackSampler attaches a stack trace only to the first entry with a given
+// stack signature per window. Every sampled entry gets a "stack_hash"
+// field, so repeats can be matched to the entry that carries the trace.
+type stackSampler struct {
+	mutex  sync.Mutex
+	window time.Duration
+	seen   map[string]time.Time
+}
+
+// newStackSampler returns nil if window disables sampling.
+func newStackSampler(window time.Duration) *stackSampler {
+	if window <= 0 {
+		return nil
+	}
+	return &stackSampler{window: window, seen: make(map[string]time.Time)}
+}
+
+// sample sets the entry's "stack_hash" field and drops its "stack" field
+// if the same signature was already logged within the window.
+func (s *stackSampler) sample(entry *Entry) {
+	stack, ok := entry.Fields["stack"].(string)
+	if !ok || stack == "" {
+		return
+	}
+	sig := stackSignature(stack)
+	entry.Fields["stack_hash"] = sig
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if first, ok := s.seen[sig]; ok && entry.Time.Sub(first) < s.window {
+		delete(entry.Fields, "stack")
+		return
+	}
+	if len(s.seen) >= maxStackSignatures {
+		var oldest string
+		var oldestTime time.Time
+		for k, first := range s.seen {
+			if entry.Time.Sub(first) >= s.window {
+				delete(s.seen, k)
+			} else if oldest == "" || first.Before(oldestTime) {
+				oldest, oldestTime = k, first
+			}
+		}
+		if len(s.seen) >= maxStackSignatures {
+			delete(s.seen, oldest)
+		}
+	}
+	s.seen[sig] = entry.Time
+}
+
+// stackSignature hashes the frames of a stack trace, ignoring the
+// goroutine header, argument values and program counter offsets, which
+// differ between otherwise identical traces.
+func stackSignature(stack string) string {
+	h := fnv.New64a()
+	for _, line := range strings.Split(stack, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "goroutine ") {
+			continue
+		}
+		if i := strings.LastIndex(line, " +0x"); i >= 0 {
+			line = line[:i]
+		} else if i := strings.LastIndexByte(line, '('); i > 0 {
+			line = line[:i]
+		}
+		h.Write([]byte(line))
+		h.Write([]byte{'\n'})
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
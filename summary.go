@@ -0,0 +1,100 @@
+package golog
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SummaryMessage is the message of the entry Config.Summary logs when the
+// logger is closed.
+const SummaryMessage = "logger summary"
+
+// summary counts a root logger's entries for Config.Summary.
+type summary struct {
+	start     time.Time
+	counts    [FATAL + 1]atomic.Int64
+	sampled   atomic.Int64 // entries dropped by sampling
+	rejected  atomic.Int64 // entries dropped by SchemaDrop
+	exitCode  atomic.Int32
+	mutex     sync.Mutex
+	lastError *Entry
+	logged    atomic.Bool
+}
+
+// newSummary returns a summary starting now, or nil if disabled.
+func newSummary(enabled bool) *summary {
+	if !enabled {
+		return nil
+	}
+	return &summary{start: time.Now()}
+}
+
+// count records a logged entry.
+func (s *summary) count(entry *Entry) {
+	s.counts[entry.Level].Add(1)
+	if entry.Level >= ERROR {
+		s.mutex.Lock()
+		s.lastError = entry
+		s.mutex.Unlock()
+	}
+}
+
+// SetExitCode sets the exit code reported by the Config.Summary entry, for
+// programs that close the logger before exiting with a failure status.
+// Closing the logger from an exit handler reports the code passed to Exit
+// instead, and Fatal reports 1.
+func (l *Logger) SetExitCode(code int) {
+	if s := l.root().summary; s != nil {
+		s.exitCode.Store(int32(code))
+	}
+}
+
+// logSummary writes the Config.Summary entry, once, with the given exit
+// code. It bypasses the level, sampling, middleware and schema, so the
+// footer is always present.
+func (l *Logger) logSummary(exitCode int) {
+	s := l.summary
+	if s == nil || !s.logged.CompareAndSwap(false, true) {
+		return
+	}
+	fields := map[string]interface{}{
+		"uptime":    Duration(time.Since(s.start)),
+		"exit_code": exitCode,
+	}
+	var total int64
+	for level := range s.counts {
+		n := s.counts[level].Load()
+		fields["entries_"+strings.ToLower(LogLevel(level).String())] = n
+		total += n
+	}
+	fields["entries"] = total
+	fields["errors"] = s.counts[ERROR].Load() + s.counts[FATAL].Load()
+
+	var shed int64
+	if l.async != nil {
+		shed = l.async.shedAll.Load()
+	}
+	sampled, rejected := s.sampled.Load(), s.rejected.Load()
+	fields["dropped"] = sampled + shed + rejected
+	fields["dropped_sampled"] = sampled
+	fields["dropped_shed"] = shed
+	fields["dropped_schema"] = rejected
+
+	s.mutex.Lock()
+	if last := s.lastError; last != nil {
+		fields["last_error"] = last.Message
+		fields["last_error_time"] = last.Time
+		if err, ok := last.Fields["error"]; ok {
+			fields["last_error_detail"] = err
+		}
+	}
+	s.mutex.Unlock()
+
+	level := INFO
+	if exitCode != 0 {
+		level = ERROR
+	}
+	l.deliver(&Entry{Time: time.Now(), Level: level, Message: SummaryMessage, Fields: fields})
+}
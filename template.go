@@ -0,0 +1,83 @@
+package golog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TemplateKey is the field under which the raw message template is kept
+// when message templates are enabled.
+const TemplateKey = "message_template"
+
+// renderTemplate replaces "{name}" placeholders in msg with the matching
+// field values. Placeholders without a matching field are left as written,
+// and "{{" and "}}" produce literal braces. It reports whether any
+// placeholder was replaced.
+func renderTemplate(msg string, fields map[string]interface{}) (string, bool) {
+	if !strings.ContainsAny(msg, "{}") {
+		return msg, false
+	}
+
+	var sb strings.Builder
+	templated := false
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if (c == '{' || c == '}') && i+1 < len(msg) && msg[i+1] == c {
+			sb.WriteByte(c)
+			i++
+			continue
+		}
+		if c != '{' {
+			sb.WriteByte(c)
+			continue
+		}
+		end := strings.IndexByte(msg[i+1:], '}')
+		if end < 0 {
+			sb.WriteString(msg[i:])
+			break
+		}
+		name := msg[i+1 : i+1+end]
+		if value, ok := fields[name]; ok {
+			sb.WriteString(fmt.Sprint(normalizeValue(value)))
+			templated = true
+		} else {
+			sb.WriteString(msg[i : i+2+end])
+		}
+		i += end + 1
+	}
+	return sb.String(), templated
+}
+
+// expandName fills the "{name}" placeholders of a subject, stream or topic
+// template from an entry. "{level}" is the lowercase level unless a level
+// field is set. Values are passed through clean, which makes them safe for
+// the destination's naming rules; placeholders without a value become
+// missing.
+func expandName(template string, entry *Entry, clean func(string) string, missing string) string {
+	if !strings.Contains(template, "{") {
+		return template
+	}
+	var sb strings.Builder
+	for rest := template; rest != ""; {
+		start := strings.IndexByte(rest, '{')
+		end := strings.IndexByte(rest[max(start, 0):], '}')
+		if start < 0 || end < 0 {
+			sb.WriteString(rest)
+			break
+		}
+		end += start
+		sb.WriteString(rest[:start])
+		name := rest[start+1 : end]
+		value, ok := entry.Fields[name]
+		switch {
+		case ok:
+			sb.WriteString(clean(fmt.Sprint(normalizeValue(value))))
+		case name == "level":
+			sb.WriteString(strings.ToLower(entry.Level.String()))
+		default:
+			sb.WriteString(missing)
+		}
+		rest = rest[end+1:]
+	}
+	return sb.String()
+}
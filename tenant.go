@@ -0,0 +1,213 @@
+package golog
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TenantSinkConfig holds tenant sink configuration options.
+type TenantSinkConfig struct {
+	Dir           string    // Root directory; each tenant writes to Dir/<tenant>/<FileName>
+	Field         string    // Field holding the tenant ID; defaults to "tenant_id"
+	FileName      string    // Log file name inside each tenant directory; defaults to "app.log"
+	DefaultTenant string    // Tenant for entries without the field; defaults to "_default"
+	Formatter     Formatter // Defaults to JSONFormatter
+	MaxSizeMB     int       // Max file size in MB before rotation, per tenant
+	MaxBackups    int       // Max number of backup files, per tenant
+	Compress      bool      // Compress rotated files
+}
+
+// TenantSink shards entries into per-tenant log files based on a field, so
+// a single customer's logs can be exported or deleted on request. Each
+// tenant's file is rotated and pruned independently.
+type TenantSink struct {
+	mutex   sync.Mutex
+	config  TenantSinkConfig
+	tenants map[string]*tenantFile
+}
+
+// tenantFile is the open log file of one tenant.
+type tenantFile struct {
+	file    *os.File
+	rotator *Rotator
+}
+
+// NewTenantSink creates the root directory and returns a tenant sink.
+func NewTenantSink(config TenantSinkConfig) (*TenantSink, error) {
+	if config.Dir == "" {
+		return nil, fmt.Errorf("tenant sink requires a directory")
+	}
+	if config.Field == "" {
+		config.Field = "tenant_id"
+	}
+	if config.FileName == "" {
+		config.FileName = "app.log"
+	}
+	if config.DefaultTenant == "" {
+		config.DefaultTenant = "_default"
+	}
+	if config.Formatter == nil {
+		config.Formatter = &JSONFormatter{}
+	}
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create tenant log directory: %v", err)
+	}
+	return &TenantSink{config: config, tenants: make(map[string]*tenantFile)}, nil
+}
+
+// Write implements Sink.
+func (s *TenantSink) Write(entry *Entry) error {
+	tenant := s.config.DefaultTenant
+	if v, ok := entry.Fields[s.config.Field]; ok && v != nil && fmt.Sprint(v) != "" {
+		tenant = fmt.Sprint(v)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tf, err := s.open(tenant)
+	if err != nil {
+		return err
+	}
+
+	if rotate, err := tf.rotator.ShouldRotate(tf.file); err == nil && rotate {
+		file, _, err := tf.rotator.Rotate(tf.file)
+		if file == nil {
+			delete(s.tenants, tenantDirName(tenant))
+			return err
+		}
+		tf.file = file
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tf.file.WriteString(formatEntry(s.config.Formatter, entry)); err != nil {
+		return fmt.Errorf("failed to write tenant log: %v", err)
+	}
+	return nil
+}
+
+// open returns the tenant's file, opening it on first use; s.mutex must be held.
+func (s *TenantSink) open(tenant string) (*tenantFile, error) {
+	name := tenantDirName(tenant)
+	if tf, ok := s.tenants[name]; ok {
+		return tf, nil
+	}
+
+	dir := filepath.Join(s.config.Dir, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create tenant directory: %v", err)
+	}
+	path := filepath.Join(dir, s.config.FileName)
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tenant log file: %v", err)
+	}
+
+	tf := &tenantFile{
+		file:    file,
+		rotator: NewRotator(path, s.config.MaxSizeMB, s.config.MaxBackups, s.config.Compress),
+	}
+	s.tenants[name] = tf
+	return tf, nil
+}
+
+// TenantDir returns the directory holding a tenant's logs and backups.
+func (s *TenantSink) TenantDir(tenant string) string {
+	return filepath.Join(s.config.Dir, tenantDirName(tenant))
+}
+
+// Tenants returns the IDs of the tenants that have a log directory, sorted.
+func (s *TenantSink) Tenants() ([]string, error) {
+	dirents, err := os.ReadDir(s.config.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenant directories: %v", err)
+	}
+	var tenants []string
+	for _, d := range dirents {
+		if !d.IsDir() {
+			continue
+		}
+		if tenant, err := url.PathUnescape(d.Name()); err == nil {
+			tenants = append(tenants, tenant)
+		}
+	}
+	sort.Strings(tenants)
+	return tenants, nil
+}
+
+// DeleteTenant closes a tenant's log file and removes all of its logs and
+// backups. Entries for the tenant written afterwards start a fresh file.
+func (s *TenantSink) DeleteTenant(tenant string) error {
+	if tenant == "" {
+		return fmt.Errorf("tenant ID must not be empty")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	name := tenantDirName(tenant)
+	if tf, ok := s.tenants[name]; ok {
+		tf.file.Close()
+		delete(s.tenants, name)
+	}
+	if err := os.RemoveAll(filepath.Join(s.config.Dir, name)); err != nil {
+		return fmt.Errorf("failed to delete tenant logs: %v", err)
+	}
+	return nil
+}
+
+// Flush implements Flusher by syncing every open tenant file.
+func (s *TenantSink) Flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var firstErr error
+	for _, tf := range s.tenants {
+		if err := tf.file.Sync(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to sync tenant log: %v", err)
+		}
+	}
+	return firstErr
+}
+
+// Close implements Sink.
+func (s *TenantSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var firstErr error
+	for name, tf := range s.tenants {
+		if err := tf.file.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close tenant log: %v", err)
+		}
+		delete(s.tenants, name)
+	}
+	return firstErr
+}
+
+// tenantDirName maps a tenant ID to a directory name. Bytes other than
+// letters, digits, '-', '_' and '.' are percent-encoded, as is a leading
+// '.', so the mapping is reversible, distinct IDs never share a directory
+// and no ID can escape the root directory.
+func tenantDirName(tenant string) string {
+	var b strings.Builder
+	for i := 0; i < len(tenant); i++ {
+		c := tenant[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+			b.WriteByte(c)
+		case c == '.' && i > 0:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
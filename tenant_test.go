@@ -0,0 +1,59 @@
+package golog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTenantSink(t *testing.T) {
+	tempDir := t.TempDir()
+	sink, err := NewTenantSink(TenantSinkConfig{Dir: tempDir, MaxSizeMB: 1})
+	if err != nil {
+		t.Fatalf("Failed to create tenant sink: %v", err)
+	}
+
+	logger, err := NewLogger(Config{Level: INFO})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.AddSink(sink)
+	defer logger.Close()
+
+	logger.Info("invoice created", map[string]interface{}{"tenant_id": "acme"})
+	logger.Info("invoice created", map[string]interface{}{"tenant_id": "globex"})
+	logger.Info("escape attempt", map[string]interface{}{"tenant_id": "../etc"})
+	for _, tenant := range []string{"acme/eu", "acme_eu", "acme:eu"} {
+		logger.Info("lookalike", map[string]interface{}{"tenant_id": tenant})
+	}
+	logger.Info("system event")
+
+	tenants, err := sink.Tenants()
+	if err != nil {
+		t.Fatalf("Failed to list tenants: %v", err)
+	}
+	if strings.Join(tenants, ",") != "../etc,_default,acme,acme/eu,acme:eu,acme_eu,globex" {
+		t.Errorf("Unexpected tenants: %v", tenants)
+	}
+
+	content, err := os.ReadFile(filepath.Join(sink.TenantDir("acme"), "app.log"))
+	if err != nil || !strings.Contains(string(content), `"tenant_id":"acme"`) || strings.Contains(string(content), "globex") {
+		t.Errorf("Unexpected acme log content: %s (%v)", content, err)
+	}
+
+	if err := sink.DeleteTenant("acme"); err != nil {
+		t.Fatalf("Failed to delete tenant: %v", err)
+	}
+	if _, err := os.Stat(sink.TenantDir("acme")); !os.IsNotExist(err) {
+		t.Errorf("Expected acme directory to be removed, got: %v", err)
+	}
+
+	if err := sink.DeleteTenant("acme/eu"); err != nil {
+		t.Fatalf("Failed to delete tenant: %v", err)
+	}
+	content, err = os.ReadFile(filepath.Join(sink.TenantDir("acme_eu"), "app.log"))
+	if err != nil || strings.Count(string(content), "lookalike") != 1 {
+		t.Errorf("Deleting one tenant affected a lookalike: %s (%v)", content, err)
+	}
+}
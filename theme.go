@@ -0,0 +1,149 @@
+package golog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ansiColors maps color names accepted by LevelStyle to ANSI SGR codes.
+var ansiColors = map[string]string{
+	"black":   "30",
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+	"white":   "37",
+	"gray":    "90",
+}
+
+// LevelStyle is the console styling of one level.
+type LevelStyle struct {
+	Color     string `json:"color"`     // Color name such as "red", or raw SGR codes such as "38;5;208"
+	Symbol    string `json:"symbol"`    // Written before the level name, e.g. "⚠"
+	Bold      bool   `json:"bold"`      // Render the level name in bold
+	Underline bool   `json:"underline"` // Underline the level name
+}
+
+// Theme styles level names in console output. Levels without an entry are
+// left unstyled.
+type Theme map[LogLevel]LevelStyle
+
+// DefaultTheme is the level coloring shared by the console and the golog
+// viewer's pretty output.
+var DefaultTheme = Theme{
+	TRACE: {Color: "gray"},
+	DEBUG: {Color: "cyan"},
+	INFO:  {Color: "green"},
+	WARN:  {Color: "yellow"},
+	ERROR: {Color: "red"},
+	FATAL: {Color: "magenta", Bold: true},
+}
+
+// SymbolTheme adds symbols to DefaultTheme's colors.
+var SymbolTheme = Theme{
+	TRACE: {Color: "gray", Symbol: "·"},
+	DEBUG: {Color: "cyan", Symbol: "•"},
+	INFO:  {Color: "green", Symbol: "✔"},
+	WARN:  {Color: "yellow", Symbol: "⚠"},
+	ERROR: {Color: "red", Symbol: "✖"},
+	FATAL: {Color: "magenta", Symbol: "✖", Bold: true},
+}
+
+// ParseTheme parses a theme from JSON keyed by level name:
+//
+//	{"warn": {"color": "yellow", "symbol": "⚠", "bold": true}}
+func ParseTheme(data []byte) (Theme, error) {
+	var raw map[string]LevelStyle
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse theme: %v", err)
+	}
+	theme := make(Theme, len(raw))
+	for name, style := range raw {
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid theme: %v", err)
+		}
+		if _, err := sgrColor(style.Color); err != nil {
+			return nil, fmt.Errorf("invalid theme: %v", err)
+		}
+		theme[level] = style
+	}
+	return theme, nil
+}
+
+// LoadTheme reads a JSON theme file; see ParseTheme.
+func LoadTheme(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme: %v", err)
+	}
+	return ParseTheme(data)
+}
+
+// render styles a level name. Colors and text attributes are omitted when
+// the NO_COLOR environment variable is set; symbols are kept.
+func (t Theme) render(level LogLevel) string {
+	style, ok := t[level]
+	name := level.String()
+	if !ok {
+		return name
+	}
+
+	if escape := t.Escape(level); escape != "" && os.Getenv("NO_COLOR") == "" {
+		name = escape + name + "\x1b[0m"
+	}
+	if style.Symbol != "" {
+		name = style.Symbol + " " + name
+	}
+	return name
+}
+
+// Escape returns the ANSI escape sequence that starts the styling of level,
+// or "" if the level has no color or text attributes. It does not consult
+// NO_COLOR.
+func (t Theme) Escape(level LogLevel) string {
+	style := t[level]
+	var codes []string
+	if code, _ := sgrColor(style.Color); code != "" {
+		codes = append(codes, code)
+	}
+	if style.Bold {
+		codes = append(codes, "1")
+	}
+	if style.Underline {
+		codes = append(codes, "4")
+	}
+	if len(codes) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+// sgrColor resolves a color name or raw SGR code list.
+func sgrColor(color string) (string, error) {
+	if color == "" {
+		return "", nil
+	}
+	if code, ok := ansiColors[strings.ToLower(color)]; ok {
+		return code, nil
+	}
+	if strings.Trim(color, "0123456789;") == "" {
+		return color, nil
+	}
+	return "", fmt.Errorf("unknown color %q", color)
+}
+
+// withTheme returns a copy of a TextFormatter using theme, or f itself for
+// other formatters.
+func withTheme(f Formatter, theme Theme) Formatter {
+	if tf, ok := f.(*TextFormatter); ok {
+		clone := *tf
+		clone.Theme = theme
+		return &clone
+	}
+	return f
+}
@@ -0,0 +1,111 @@
+package golog
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ContextSink is implemented by sinks that can abandon a write when its
+// context is done, for example by setting a deadline on their connection.
+// TimeoutSink passes them a context carrying its deadline.
+type ContextSink interface {
+	Sink
+	WriteContext(ctx context.Context, entry *Entry) error
+}
+
+// TimeoutSink bounds how long a write to a sink may take, so a hung
+// connection cannot block the async writer or, in synchronous mode, the
+// application. A write that times out is abandoned and the entry is handed
+// to the fallback sink, typically a SpoolSink, or dropped with an error if
+// there is none. Writes stay serialized: while an abandoned write is still
+// running, further entries go straight to the fallback.
+type TimeoutSink struct {
+	sink     Sink
+	timeout  time.Duration
+	fallback Sink
+	busy     chan struct{} // holds a token while the wrapped sink is writing
+	timeouts atomic.Int64
+}
+
+// NewTimeoutSink wraps sink with a per-write timeout. fallback may be nil.
+// The TimeoutSink takes ownership of both sinks and closes them on Close.
+func NewTimeoutSink(sink Sink, timeout time.Duration, fallback Sink) *TimeoutSink {
+	return &TimeoutSink{sink: sink, timeout: timeout, fallback: fallback, busy: make(chan struct{}, 1)}
+}
+
+// Write implements Sink.
+func (s *TimeoutSink) Write(entry *Entry) error {
+	select {
+	case s.busy <- struct{}{}:
+	default:
+		return s.abandon(entry, fmt.Errorf("sink write abandoned: an earlier write is still running"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		if cs, ok := s.sink.(ContextSink); ok {
+			err = cs.WriteContext(ctx, entry)
+		} else {
+			err = s.sink.Write(entry)
+		}
+		<-s.busy
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		s.timeouts.Add(1)
+		return s.abandon(entry, fmt.Errorf("sink write timed out after %v", s.timeout))
+	}
+}
+
+// abandon hands an entry that could not be written in time to the fallback.
+func (s *TimeoutSink) abandon(entry *Entry, err error) error {
+	if s.fallback == nil {
+		return err
+	}
+	return s.fallback.Write(entry)
+}
+
+// Timeouts returns the number of writes that timed out.
+func (s *TimeoutSink) Timeouts() int64 {
+	return s.timeouts.Load()
+}
+
+// Flush implements Flusher, flushing the fallback and, if it is not busy
+// with an abandoned write, the wrapped sink.
+func (s *TimeoutSink) Flush() error {
+	var firstErr error
+	select {
+	case s.busy <- struct{}{}:
+		if f, ok := s.sink.(Flusher); ok {
+			firstErr = f.Flush()
+		}
+		<-s.busy
+	default:
+	}
+	if f, ok := s.fallback.(Flusher); ok {
+		if err := f.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close implements Sink.
+func (s *TimeoutSink) Close() error {
+	firstErr := s.sink.Close()
+	if s.fallback != nil {
+		if err := s.fallback.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
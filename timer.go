@@ -0,0 +1,109 @@
+package golog
+
+import "time"
+
+// DurationValue is a field value that renders a duration in its
+// human-readable form ("1.5s") in both text and JSON output.
+type DurationValue time.Duration
+
+// Duration wraps d for use as a field value.
+func Duration(d time.Duration) DurationValue {
+	return DurationValue(d)
+}
+
+// String implements fmt.Stringer.
+func (d DurationValue) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d DurationValue) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// Threshold escalates a timer entry to Level once the operation has taken
+// at least After.
+type Threshold struct {
+	After time.Duration
+	Level LogLevel
+}
+
+// Timer starts timing an operation and returns a function that logs msg
+// with the elapsed duration when called, typically via defer. The entry is
+// logged at INFO unless one of the logger's SlowThresholds is exceeded.
+func (l *Logger) Timer(msg string, fields ...map[string]interface{}) func() {
+	return l.TimerWithThresholds(msg, l.root().thresholds, fields...)
+}
+
+// TimerWithThresholds is like Timer but uses the given thresholds instead
+// of the logger's configured ones.
+func (l *Logger) TimerWithThresholds(msg string, thresholds []Threshold, fields ...map[string]interface{}) func() {
+	sw := NewStopwatch()
+	return func() {
+		elapsed := sw.Elapsed()
+		merged := mergeFields(fields)
+		merged["elapsed"] = Duration(elapsed)
+		l.log(escalate(INFO, elapsed, thresholds), msg, merged)
+	}
+}
+
+// escalate returns the highest threshold level reached by elapsed.
+func escalate(level LogLevel, elapsed time.Duration, thresholds []Threshold) LogLevel {
+	for _, t := range thresholds {
+		if elapsed >= t.After && t.Level > level {
+			level = t.Level
+		}
+	}
+	return level
+}
+
+// Stopwatch measures the total duration of an operation and of its
+// individual named phases.
+type Stopwatch struct {
+	start time.Time
+	last  time.Time
+	laps  map[string]time.Duration
+	order []string
+}
+
+// NewStopwatch returns a running stopwatch.
+func NewStopwatch() *Stopwatch {
+	now := time.Now()
+	return &Stopwatch{start: now, last: now, laps: make(map[string]time.Duration)}
+}
+
+// Lap records the time since the previous lap (or the start) under name
+// and returns it.
+func (s *Stopwatch) Lap(name string) time.Duration {
+	now := time.Now()
+	d := now.Sub(s.last)
+	s.last = now
+	if _, ok := s.laps[name]; !ok {
+		s.order = append(s.order, name)
+	}
+	s.laps[name] += d
+	return d
+}
+
+// Elapsed returns the time since the stopwatch was started.
+func (s *Stopwatch) Elapsed() time.Duration {
+	return time.Since(s.start)
+}
+
+// Fields returns the elapsed time and any recorded laps as log fields.
+func (s *Stopwatch) Fields() map[string]interface{} {
+	fields := map[string]interface{}{"elapsed": Duration(s.Elapsed())}
+	if len(s.laps) > 0 {
+		laps := make(map[string]interface{}, len(s.laps))
+		for name, d := range s.laps {
+			laps[name] = Duration(d)
+		}
+		fields["laps"] = laps
+	}
+	return fields
+}
+
+// Laps returns the recorded lap names in the order they were first seen.
+func (s *Stopwatch) Laps() []string {
+	return append([]string(nil), s.order...)
+}
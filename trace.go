@@ -0,0 +1,32 @@
+package golog
+
+// TraceSeqKey is the field holding the sequence number of the TraceFn call
+// an entry was logged in.
+const TraceSeqKey = "trace_seq"
+
+// TraceEnabled reports whether the logger writes TRACE entries.
+func (l *Logger) TraceEnabled() bool {
+	return l.Level() <= TRACE
+}
+
+// TraceFn calls fn only when TRACE is enabled, so expensive instrumentation
+// can stay in production code at no cost while the level is higher:
+//
+//	logger.TraceFn(func(l *golog.Logger) {
+//		l.Trace("cache state", map[string]interface{}{"keys": cache.Keys()})
+//	})
+//
+// Entries logged through the logger passed to fn carry a trace_seq field
+// numbering the TraceFn calls of the root logger, so the entries of one
+// call can be grouped and calls ordered.
+func (l *Logger) TraceFn(fn func(l *Logger)) {
+	if !l.TraceEnabled() {
+		return
+	}
+	static := make(map[string]interface{}, len(l.fields)+1)
+	for k, v := range l.fields {
+		static[k] = v
+	}
+	static[TraceSeqKey] = l.root().traceSeq.Add(1)
+	fn(l.withStatic(static))
+}